@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/internal/yaml"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+// Role is what a caller may do. Each role can do everything the roles
+// below it can.
+type Role string
+
+const (
+	// Viewer may read tasks, objects, events and nodes.
+	Viewer Role = "viewer"
+	// Operator may also submit, change and stop tasks and objects.
+	Operator Role = "operator"
+	// Admin may also manage namespaces and nodes.
+	Admin Role = "admin"
+)
+
+var ranks = map[Role]int{Viewer: 1, Operator: 2, Admin: 3}
+
+func ParseRole(s string) (Role, error) {
+	r := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := ranks[r]; !ok {
+		return "", fmt.Errorf("unknown role %q, expected viewer, operator or admin", s)
+	}
+	return r, nil
+}
+
+// Allows reports whether the role includes the required one.
+func (r Role) Allows(required Role) bool {
+	return ranks[r] >= ranks[required]
+}
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("permission denied")
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// Namespaces restricts the caller to these namespaces. Empty means
+	// every namespace.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Anonymous is the principal of every request when authentication is
+// disabled.
+var Anonymous = &Principal{Name: "anonymous", Role: Admin}
+
+// unauthenticated is the principal of a request no authentication ran
+// for.
+var unauthenticated = &Principal{Name: "unauthenticated"}
+
+// CanAccess reports whether the principal may act in namespace ns. An
+// empty ns stands for every namespace, which only unrestricted principals
+// may act across. A principal without a role may act in none.
+func (p *Principal) CanAccess(ns string) bool {
+	if p.Role == "" {
+		return false
+	}
+	if len(p.Namespaces) == 0 {
+		return true
+	}
+	if ns == "" {
+		return false
+	}
+	for _, allowed := range p.Namespaces {
+		if namespace.OrDefault(allowed) == namespace.OrDefault(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeNamespace returns an error unless the principal may act in
+// namespace ns.
+func (p *Principal) AuthorizeNamespace(ns string) error {
+	if !p.CanAccess(ns) {
+		if ns == "" {
+			return fmt.Errorf("%w: %s may not act across every namespace", ErrForbidden, p.Name)
+		}
+		return fmt.Errorf("%w: %s may not act in namespace %s", ErrForbidden, p.Name, ns)
+	}
+	return nil
+}
+
+// Token binds a static bearer token to a principal.
+type Token struct {
+	Token      string   `json:"token"`
+	Name       string   `json:"name"`
+	Role       Role     `json:"role"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// LoadTokens reads a YAML or JSON list of tokens.
+func LoadTokens(path string) ([]Token, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []Token
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tokens)
+	default:
+		err = json.Unmarshal(data, &tokens)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse tokens file %s: %w", path, err)
+	}
+	return tokens, nil
+}
+
+// Authenticator maps credentials to principals: static tokens, HS256 JSON
+// web tokens when a secret is set, and client certificates verified by
+// the API's TLS config.
+type Authenticator struct {
+	tokens    []Token
+	jwtSecret []byte
+}
+
+func New(tokens []Token, jwtSecret []byte) (*Authenticator, error) {
+	for i, t := range tokens {
+		if t.Token == "" || t.Name == "" {
+			return nil, fmt.Errorf("token %d requires a token and a name", i)
+		}
+		role, err := ParseRole(string(t.Role))
+		if err != nil {
+			return nil, fmt.Errorf("token %s: %w", t.Name, err)
+		}
+		tokens[i].Role = role
+		for _, ns := range t.Namespaces {
+			if err := namespace.ValidateName(ns); err != nil {
+				return nil, fmt.Errorf("token %s: %w", t.Name, err)
+			}
+		}
+	}
+	if len(tokens) == 0 && len(jwtSecret) == 0 {
+		return nil, fmt.Errorf("authentication requires tokens or a JWT secret")
+	}
+
+	return &Authenticator{tokens: tokens, jwtSecret: jwtSecret}, nil
+}
+
+// Authenticate returns the principal a bearer credential belongs to.
+func (a *Authenticator) Authenticate(bearer string) (*Principal, error) {
+	if bearer == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(bearer)) == 1 {
+			return &Principal{Name: t.Name, Role: t.Role, Namespaces: t.Namespaces}, nil
+		}
+	}
+
+	if len(a.jwtSecret) > 0 && strings.Count(bearer, ".") == 2 {
+		p, err := Verify(bearer, a.jwtSecret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+		}
+		return p, nil
+	}
+
+	return nil, ErrUnauthenticated
+}
+
+// AuthenticateCertificate returns the principal a verified client
+// certificate identifies: its common name is the name, its organization
+// the role and its organizational units the namespaces it is restricted to.
+func (a *Authenticator) AuthenticateCertificate(cert *x509.Certificate) (*Principal, error) {
+	if cert.Subject.CommonName == "" || len(cert.Subject.Organization) != 1 {
+		return nil, fmt.Errorf("%w: certificate does not name a principal", ErrUnauthenticated)
+	}
+	role, err := ParseRole(cert.Subject.Organization[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	return &Principal{Name: cert.Subject.CommonName, Role: role, Namespaces: cert.Subject.OrganizationalUnit}, nil
+}
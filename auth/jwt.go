@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// claims are the JWT claims a principal is read from. The subject is the
+// principal's name.
+type claims struct {
+	Subject    string   `json:"sub"`
+	Role       Role     `json:"role"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	IssuedAt   int64    `json:"iat,omitempty"`
+	NotBefore  int64    `json:"nbf,omitempty"`
+	Expiry     int64    `json:"exp,omitempty"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Sign issues an HS256 JSON web token for the principal, valid for ttl. A
+// zero ttl never expires.
+func Sign(p Principal, secret []byte, ttl time.Duration) (string, error) {
+	now := time.Now()
+	c := claims{Subject: p.Name, Role: p.Role, Namespaces: p.Namespaces, IssuedAt: now.Unix()}
+	if ttl > 0 {
+		c.Expiry = now.Add(ttl).Unix()
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + signature(signed, secret), nil
+}
+
+// Verify checks the signature and validity period of an HS256 JSON web
+// token and returns its principal.
+func Verify(token string, secret []byte) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+
+	want := signature(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(want), []byte(parts[2])) {
+		return nil, fmt.Errorf("invalid token signature")
+	}
+
+	c := claims{}
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if c.Expiry != 0 && now >= c.Expiry {
+		return nil, fmt.Errorf("token expired")
+	}
+	if c.NotBefore != 0 && now < c.NotBefore {
+		return nil, fmt.Errorf("token not valid yet")
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	role, err := ParseRole(string(c.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Principal{Name: c.Subject, Role: role, Namespaces: c.Namespaces}, nil
+}
+
+func signature(signed string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret := []byte("secret")
+	p := Principal{Name: "ci", Role: Operator, Namespaces: []string{"a", "b"}}
+
+	for _, ttl := range []time.Duration{0, time.Hour} {
+		token, err := Sign(p, secret, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Verify(token, secret)
+		if err != nil {
+			t.Fatalf("ttl %s: %v", ttl, err)
+		}
+		if !reflect.DeepEqual(*got, p) {
+			t.Fatalf("ttl %s: got %+v, want %+v", ttl, *got, p)
+		}
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now().Unix()
+
+	// token signs the claims with the given header, as a forger or another
+	// issuer would.
+	token := func(header string, c claims) string {
+		payload, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		return signed + "." + signature(signed, secret)
+	}
+	valid := claims{Subject: "ci", Role: Viewer}
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+
+	signed, err := Sign(Principal{Name: "ci", Role: Viewer}, secret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(signed, ".")
+	elevated, _ := json.Marshal(claims{Subject: "ci", Role: Admin})
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{"two segments", parts[0] + "." + parts[1], "malformed token"},
+		{"bad encoding", parts[0] + ".!!!." + parts[2], "invalid token signature"},
+		{"tampered payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString(elevated) + "." + parts[2], "invalid token signature"},
+		{"alg none", token(`{"alg":"none"}`, valid), "unsupported token algorithm \"none\""},
+		{"expired", token(hs256, claims{Subject: "ci", Role: Viewer, Expiry: now - 1}), "token expired"},
+		{"not valid yet", token(hs256, claims{Subject: "ci", Role: Viewer, NotBefore: now + 60}), "token not valid yet"},
+		{"no subject", token(hs256, claims{Role: Viewer}), "token has no subject"},
+		{"unknown role", token(hs256, claims{Subject: "ci", Role: "root"}), "root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(tt.token, secret)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+
+	if _, err := Verify(signed, []byte("other")); err == nil {
+		t.Fatal("verified a token signed with another secret")
+	}
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const principalKey = "pentagon.principal"
+
+// Middleware authenticates each request by the bearer token in its
+// Authorization header or, failing one, its client certificate, then
+// requires the viewer role for reads and the operator role for anything
+// else. Routes needing more add Require. With a nil authenticator every
+// request is let through as Anonymous.
+func Middleware(a *Authenticator) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		p := Anonymous
+		if a != nil {
+			var err error
+			if p, err = authenticate(a, ctx); err != nil {
+				return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+		ctx.Locals(principalKey, p)
+
+		role := Operator
+		if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead {
+			role = Viewer
+		}
+		return checkRole(ctx, role)
+	}
+}
+
+func authenticate(a *Authenticator, ctx *fiber.Ctx) (*Principal, error) {
+	token := bearer(ctx)
+	if token == "" {
+		if cs := ctx.Context().TLSConnectionState(); cs != nil && len(cs.VerifiedChains) > 0 {
+			return a.AuthenticateCertificate(cs.VerifiedChains[0][0])
+		}
+	}
+	return a.Authenticate(token)
+}
+
+// Require lets through only callers with the role.
+func Require(role Role) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return checkRole(ctx, role)
+	}
+}
+
+func checkRole(ctx *fiber.Ctx, role Role) error {
+	if p := FromContext(ctx); !p.Role.Allows(role) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": fmt.Sprintf("%s: %s %s cannot act as %s", ErrForbidden, p.Role, p.Name, role),
+		})
+	}
+	return ctx.Next()
+}
+
+// FromContext returns the principal of an authenticated request. A
+// request that did not go through Middleware gets a principal without a
+// role, which is allowed nothing.
+func FromContext(ctx *fiber.Ctx) *Principal {
+	if p, ok := ctx.Locals(principalKey).(*Principal); ok {
+		return p
+	}
+	return unauthenticated
+}
+
+// RequireToken lets through only requests bearing the shared token. It
+// guards APIs meant for a single trusted caller, such as the worker API
+// the manager calls. An empty token lets every request through.
+func RequireToken(token string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if token != "" && subtle.ConstantTimeCompare([]byte(bearer(ctx)), []byte(token)) != 1 {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": ErrUnauthenticated.Error(),
+			})
+		}
+		return ctx.Next()
+	}
+}
+
+func bearer(ctx *fiber.Ctx) string {
+	scheme, token, ok := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
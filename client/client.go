@@ -0,0 +1,238 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTimeout   = 30 * time.Second
+	DefaultRetries   = 2
+	DefaultRetryWait = 500 * time.Millisecond
+)
+
+// Client holds the transport shared by the manager and worker clients.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Retries    int
+	RetryWait  time.Duration
+	// Token is sent as a bearer token with every request, if set.
+	Token string
+}
+
+type Option func(c *Client)
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.HTTPClient.Timeout = timeout
+	}
+}
+
+// WithRetries sets how many times idempotent requests are retried after a
+// connection error or a 5xx response, and the initial wait between
+// attempts, which doubles on each retry.
+func WithRetries(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.Retries = retries
+		c.RetryWait = wait
+	}
+}
+
+// WithToken authenticates every request with a bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.Token = token
+	}
+}
+
+// WithTLS dials the server over TLS with the config, switching the
+// address to https.
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *Client) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg
+		c.HTTPClient.Transport = transport
+		c.BaseURL = "https://" + strings.TrimPrefix(strings.TrimPrefix(c.BaseURL, "http://"), "https://")
+	}
+}
+
+// WithTLSFor dials the server over TLS with the config tlsFor returns for
+// the host of the address, switching the address to https.
+func WithTLSFor(tlsFor func(host string) *tls.Config) Option {
+	return func(c *Client) {
+		host := ""
+		if u, err := url.Parse(c.BaseURL); err == nil {
+			host = u.Hostname()
+		}
+		WithTLS(tlsFor(host))(c)
+	}
+}
+
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = hc
+	}
+}
+
+func New(address string, opts ...Option) *Client {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = "http://" + address
+	}
+
+	c := &Client{
+		BaseURL:    strings.TrimRight(address, "/"),
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Retries:    DefaultRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Error is returned for any response outside the 2xx range. Its fields
+// mirror the ErrResponse bodies returned by the manager and worker APIs.
+type Error struct {
+	HTTPStatusCode int
+	Message        string
+	// Reason is a machine-readable cause some errors carry, such as the
+	// resource a worker is short of when it rejects a task.
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed (%d): %s", e.HTTPStatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an API error with a 404 status.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.HTTPStatusCode == http.StatusNotFound
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("unable to decode response from %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, body interface{}) (*http.Response, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := 1
+	if method != http.MethodPost {
+		attempts += c.Retries
+	}
+
+	wait := c.RetryWait
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+
+		resp, err := c.send(ctx, method, path, data)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			lastErr = decodeError(resp)
+			resp.Body.Close()
+			if resp.StatusCode >= 500 {
+				continue
+			}
+			return nil, lastErr
+		}
+
+		return resp, nil
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, data []byte) (*http.Response, error) {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	return c.HTTPClient.Do(req)
+}
+
+func (c *Client) getText(ctx context.Context, path string) (string, error) {
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	text, err := io.ReadAll(resp.Body)
+	return string(text), err
+}
+
+func decodeError(resp *http.Response) error {
+	e := &Error{}
+
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, e); err != nil || e.Message == "" {
+		e.Message = strings.TrimSpace(string(data))
+	}
+	e.HTTPStatusCode = resp.StatusCode
+
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return e
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/configs"
+)
+
+func (m *Manager) CreateConfig(ctx context.Context, c configs.Config) (*configs.Config, error) {
+	if c.Namespace == "" {
+		c.Namespace = m.Namespace
+	}
+
+	created := &configs.Config{}
+	err := m.do(ctx, http.MethodPost, "/api/configs", c, created)
+	return created, err
+}
+
+func (m *Manager) GetConfigs(ctx context.Context) ([]*configs.Config, error) {
+	cs := []*configs.Config{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/configs", url.Values{}), nil, &cs)
+	return cs, err
+}
+
+func (m *Manager) GetConfig(ctx context.Context, name string) (*configs.Config, error) {
+	c := &configs.Config{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/configs/"+url.PathEscape(name), url.Values{}), nil, c)
+	return c, err
+}
+
+// UpdateConfig replaces the files of a config.
+func (m *Manager) UpdateConfig(ctx context.Context, name string, data map[string]string) (*configs.Config, error) {
+	c := &configs.Config{}
+	err := m.do(ctx, http.MethodPut, m.scoped("/api/configs/"+url.PathEscape(name), url.Values{}), configs.Config{Data: data}, c)
+	return c, err
+}
+
+func (m *Manager) DeleteConfig(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/configs/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/crontask"
+)
+
+func (m *Manager) CreateCronTask(ctx context.Context, c crontask.CronTask) (*crontask.CronTask, error) {
+	if c.Namespace == "" {
+		c.Namespace = m.Namespace
+	}
+
+	created := &crontask.CronTask{}
+	err := m.do(ctx, http.MethodPost, "/api/crontasks", c, created)
+	return created, err
+}
+
+func (m *Manager) GetCronTasks(ctx context.Context) ([]*crontask.CronTask, error) {
+	cronTasks := []*crontask.CronTask{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/crontasks", url.Values{}), nil, &cronTasks)
+	return cronTasks, err
+}
+
+func (m *Manager) GetCronTask(ctx context.Context, name string) (*crontask.CronTask, error) {
+	c := &crontask.CronTask{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/crontasks/"+url.PathEscape(name), url.Values{}), nil, c)
+	return c, err
+}
+
+func (m *Manager) SuspendCronTask(ctx context.Context, name string, suspend bool) (*crontask.CronTask, error) {
+	c := &crontask.CronTask{}
+	body := map[string]bool{"suspend": suspend}
+	err := m.do(ctx, http.MethodPost, m.scoped("/api/crontasks/"+url.PathEscape(name)+"/suspend", url.Values{}), body, c)
+	return c, err
+}
+
+func (m *Manager) DeleteCronTask(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/crontasks/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
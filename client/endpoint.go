@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// GetEndpoints lists where the running tasks are reached, only those of
+// the service svc unless it is empty.
+func (m *Manager) GetEndpoints(ctx context.Context, svc string) ([]task.Endpoint, error) {
+	endpoints := []task.Endpoint{}
+	path := m.scoped("/api/endpoints", url.Values{"service": {svc}})
+	err := m.do(ctx, http.MethodGet, path, nil, &endpoints)
+	return endpoints, err
+}
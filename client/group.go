@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/group"
+)
+
+func (m *Manager) CreateGroup(ctx context.Context, g group.Group) (*group.Group, error) {
+	if g.Namespace == "" {
+		g.Namespace = m.Namespace
+	}
+
+	created := &group.Group{}
+	err := m.do(ctx, http.MethodPost, "/api/groups", g, created)
+	return created, err
+}
+
+func (m *Manager) GetGroups(ctx context.Context) ([]*group.Group, error) {
+	groups := []*group.Group{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/groups", url.Values{}), nil, &groups)
+	return groups, err
+}
+
+func (m *Manager) GetGroup(ctx context.Context, name string) (*group.Group, error) {
+	g := &group.Group{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/groups/"+url.PathEscape(name), url.Values{}), nil, g)
+	return g, err
+}
+
+func (m *Manager) DeleteGroup(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/groups/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/image"
+)
+
+// GetNodeImages lists the images cached on a node.
+func (m *Manager) GetNodeImages(ctx context.Context, name string) ([]image.Image, error) {
+	images := []image.Image{}
+	err := m.do(ctx, http.MethodGet, "/api/nodes/"+url.PathEscape(name)+"/images", nil, &images)
+	return images, err
+}
+
+// CollectNodeImages has a node remove its unused images and returns them.
+func (m *Manager) CollectNodeImages(ctx context.Context, name string, all bool) ([]image.Image, error) {
+	removed := []image.Image{}
+	err := m.do(ctx, http.MethodPost, fmt.Sprintf("/api/nodes/%s/images/gc?all=%t", url.PathEscape(name), all), nil, &removed)
+	return removed, err
+}
+
+// PullImages pre-pulls images on the nodes matching the request's selector
+// and waits for the pulls to end.
+func (m *Manager) PullImages(ctx context.Context, req image.PullRequest) ([]image.NodePull, error) {
+	if req.Namespace == "" {
+		req.Namespace = m.Namespace
+	}
+
+	results := []image.NodePull{}
+	err := m.do(ctx, http.MethodPost, "/api/images/pull", req, &results)
+	return results, err
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/job"
+)
+
+func (m *Manager) CreateJob(ctx context.Context, j job.Job) (*job.Job, error) {
+	if j.Namespace == "" {
+		j.Namespace = m.Namespace
+	}
+
+	created := &job.Job{}
+	err := m.do(ctx, http.MethodPost, "/api/jobs", j, created)
+	return created, err
+}
+
+func (m *Manager) GetJobs(ctx context.Context) ([]*job.Job, error) {
+	jobs := []*job.Job{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/jobs", url.Values{}), nil, &jobs)
+	return jobs, err
+}
+
+func (m *Manager) GetJob(ctx context.Context, name string) (*job.Job, error) {
+	j := &job.Job{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/jobs/"+url.PathEscape(name), url.Values{}), nil, j)
+	return j, err
+}
+
+func (m *Manager) DeleteJob(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/jobs/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
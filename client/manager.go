@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/manifest"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Manager is a client for the manager API.
+type Manager struct {
+	*Client
+
+	// Namespace scopes task calls and lists, and is given to new tasks and
+	// objects that do not name one. Empty means every namespace, and the
+	// default one for submissions.
+	Namespace string
+}
+
+func NewManager(address string, opts ...Option) *Manager {
+	return &Manager{Client: New(address, opts...)}
+}
+
+func (m *Manager) StartTask(ctx context.Context, te task.TaskEvent) (*task.Task, error) {
+	if te.Task.Namespace == "" {
+		te.Task.Namespace = m.Namespace
+	}
+
+	t := &task.Task{}
+	err := m.do(ctx, http.MethodPost, "/api/tasks", te, t)
+	return t, err
+}
+
+// GetTasks lists tasks matching the label selector, optionally restricted
+// to the given states. An empty selector matches every task.
+func (m *Manager) GetTasks(ctx context.Context, selector string, states ...task.State) ([]*task.Task, error) {
+	query := url.Values{}
+	if len(states) > 0 {
+		names := make([]string, 0, len(states))
+		for _, s := range states {
+			names = append(names, s.String())
+		}
+		query.Set("state", strings.Join(names, ","))
+	}
+	if selector != "" {
+		query.Set("selector", selector)
+	}
+
+	tasks := []*task.Task{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/tasks", query), nil, &tasks)
+	return tasks, err
+}
+
+func (m *Manager) GetTask(ctx context.Context, id string) (*task.Task, error) {
+	t := &task.Task{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/tasks/"+url.PathEscape(id), url.Values{}), nil, t)
+	return t, err
+}
+
+func (m *Manager) StopTask(ctx context.Context, id string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/tasks/"+url.PathEscape(id), url.Values{}), nil, nil)
+}
+
+// StopTasks stops every active task matching the label selector and
+// returns the tasks stopped.
+func (m *Manager) StopTasks(ctx context.Context, selector string) ([]*task.Task, error) {
+	tasks := []*task.Task{}
+	path := m.scoped("/api/tasks", url.Values{"selector": {selector}})
+	err := m.do(ctx, http.MethodDelete, path, nil, &tasks)
+	return tasks, err
+}
+
+// GetTaskLogs returns the last tail lines of the task's container output. A
+// tail of zero returns all of it.
+func (m *Manager) GetTaskLogs(ctx context.Context, id string, tail int) (string, error) {
+	path := m.scoped("/api/tasks/"+url.PathEscape(id)+"/logs", url.Values{"tail": {strconv.Itoa(tail)}})
+	return m.getText(ctx, path)
+}
+
+// GetEvents lists the events of tasks matching the label selector.
+func (m *Manager) GetEvents(ctx context.Context, selector string) ([]*task.TaskEvent, error) {
+	events := []*task.TaskEvent{}
+	path := m.scoped("/api/events", url.Values{"selector": {selector}})
+	err := m.do(ctx, http.MethodGet, path, nil, &events)
+	return events, err
+}
+
+// GetNodes lists the nodes matching the label selector.
+func (m *Manager) GetNodes(ctx context.Context, selector string) ([]*node.Node, error) {
+	nodes := []*node.Node{}
+	path := withQuery("/api/nodes", url.Values{"selector": {selector}})
+	err := m.do(ctx, http.MethodGet, path, nil, &nodes)
+	return nodes, err
+}
+
+// CordonNode marks a node unschedulable, or schedulable again.
+func (m *Manager) CordonNode(ctx context.Context, name string, cordon bool) (*node.Node, error) {
+	n := &node.Node{}
+	body := map[string]bool{"cordon": cordon}
+	err := m.do(ctx, http.MethodPost, "/api/nodes/"+url.PathEscape(name)+"/cordon", body, n)
+	return n, err
+}
+
+// withQuery appends the non-empty query parameters to path.
+func withQuery(path string, query url.Values) string {
+	for k, v := range query {
+		if len(v) == 0 || v[0] == "" {
+			query.Del(k)
+		}
+	}
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// scoped adds the client's namespace, if any, to the query of path.
+func (m *Manager) scoped(path string, query url.Values) string {
+	query.Set("namespace", m.Namespace)
+	return withQuery(path, query)
+}
+
+// WhoAmI returns the principal the manager authenticates the client as.
+func (m *Manager) WhoAmI(ctx context.Context) (*auth.Principal, error) {
+	p := &auth.Principal{}
+	err := m.do(ctx, http.MethodGet, "/api/whoami", nil, p)
+	return p, err
+}
+
+// Apply submits a manifest. With dryRun set the manager only reports the
+// changes it would make.
+func (m *Manager) Apply(ctx context.Context, mf manifest.Manifest, dryRun bool, prune bool) (*manifest.Result, error) {
+	if mf.Namespace == "" {
+		mf.Namespace = m.Namespace
+	}
+
+	result := &manifest.Result{}
+	path := fmt.Sprintf("/api/apply?dry_run=%t&prune=%t", dryRun, prune)
+	err := m.do(ctx, http.MethodPost, path, mf, result)
+	return result, err
+}
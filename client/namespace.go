@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+func (m *Manager) CreateNamespace(ctx context.Context, ns namespace.Namespace) (*namespace.Namespace, error) {
+	created := &namespace.Namespace{}
+	err := m.do(ctx, http.MethodPost, "/api/namespaces", ns, created)
+	return created, err
+}
+
+func (m *Manager) GetNamespaces(ctx context.Context) ([]*namespace.Namespace, error) {
+	namespaces := []*namespace.Namespace{}
+	err := m.do(ctx, http.MethodGet, "/api/namespaces", nil, &namespaces)
+	return namespaces, err
+}
+
+func (m *Manager) GetNamespace(ctx context.Context, name string) (*namespace.Namespace, error) {
+	ns := &namespace.Namespace{}
+	err := m.do(ctx, http.MethodGet, "/api/namespaces/"+url.PathEscape(name), nil, ns)
+	return ns, err
+}
+
+// SetQuota replaces the quota of a namespace.
+func (m *Manager) SetQuota(ctx context.Context, name string, q namespace.Quota) (*namespace.Namespace, error) {
+	ns := &namespace.Namespace{}
+	err := m.do(ctx, http.MethodPut, "/api/namespaces/"+url.PathEscape(name)+"/quota", q, ns)
+	return ns, err
+}
+
+func (m *Manager) DeleteNamespace(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, "/api/namespaces/"+url.PathEscape(name), nil, nil)
+}
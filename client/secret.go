@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/secret"
+)
+
+func (m *Manager) CreateSecret(ctx context.Context, s secret.Secret) (*secret.Secret, error) {
+	if s.Namespace == "" {
+		s.Namespace = m.Namespace
+	}
+
+	created := &secret.Secret{}
+	err := m.do(ctx, http.MethodPost, "/api/secrets", s, created)
+	return created, err
+}
+
+func (m *Manager) GetSecrets(ctx context.Context) ([]*secret.Secret, error) {
+	secrets := []*secret.Secret{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/secrets", url.Values{}), nil, &secrets)
+	return secrets, err
+}
+
+func (m *Manager) GetSecret(ctx context.Context, name string) (*secret.Secret, error) {
+	s := &secret.Secret{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/secrets/"+url.PathEscape(name), url.Values{}), nil, s)
+	return s, err
+}
+
+// RotateSecret replaces the values of a secret.
+func (m *Manager) RotateSecret(ctx context.Context, name string, data map[string]string) (*secret.Secret, error) {
+	s := &secret.Secret{}
+	err := m.do(ctx, http.MethodPut, m.scoped("/api/secrets/"+url.PathEscape(name), url.Values{}), secret.Secret{Data: data}, s)
+	return s, err
+}
+
+func (m *Manager) DeleteSecret(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/secrets/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/service"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func (m *Manager) CreateService(ctx context.Context, s service.Service) (*service.Service, error) {
+	if s.Namespace == "" {
+		s.Namespace = m.Namespace
+	}
+
+	created := &service.Service{}
+	err := m.do(ctx, http.MethodPost, "/api/services", s, created)
+	return created, err
+}
+
+func (m *Manager) GetServices(ctx context.Context) ([]*service.Service, error) {
+	services := []*service.Service{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/services", url.Values{}), nil, &services)
+	return services, err
+}
+
+func (m *Manager) GetService(ctx context.Context, name string) (*service.Service, error) {
+	s := &service.Service{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/services/"+url.PathEscape(name), url.Values{}), nil, s)
+	return s, err
+}
+
+func (m *Manager) ScaleService(ctx context.Context, name string, replicas int) (*service.Service, error) {
+	s := &service.Service{}
+	body := map[string]int{"replicas": replicas}
+	err := m.do(ctx, http.MethodPost, m.scoped("/api/services/"+url.PathEscape(name)+"/scale", url.Values{}), body, s)
+	return s, err
+}
+
+// UpdateService starts a rolling update of a service to a new template. A
+// nil config keeps the service's current update config.
+func (m *Manager) UpdateService(ctx context.Context, name string, template task.Task, cfg *service.UpdateConfig) (*service.Service, error) {
+	s := &service.Service{}
+	body := map[string]interface{}{"template": template}
+	if cfg != nil {
+		body["update_config"] = cfg
+	}
+	err := m.do(ctx, http.MethodPut, m.scoped("/api/services/"+url.PathEscape(name), url.Values{}), body, s)
+	return s, err
+}
+
+func (m *Manager) RollbackService(ctx context.Context, name string) (*service.Service, error) {
+	s := &service.Service{}
+	err := m.do(ctx, http.MethodPost, m.scoped("/api/services/"+url.PathEscape(name)+"/rollback", url.Values{}), nil, s)
+	return s, err
+}
+
+func (m *Manager) DeleteService(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/services/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
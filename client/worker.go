@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/image"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/hugoleodev/pentagon/worker"
+)
+
+// Worker is a client for the worker API.
+type Worker struct {
+	*Client
+}
+
+func NewWorker(address string, opts ...Option) *Worker {
+	return &Worker{Client: New(address, opts...)}
+}
+
+// StartTask asks the worker to start the task of an event and returns the
+// event the worker records for the start.
+func (w *Worker) StartTask(ctx context.Context, te task.TaskEvent) (*task.TaskEvent, error) {
+	started := &task.TaskEvent{}
+	err := w.do(ctx, http.MethodPost, "/api/tasks", te, started)
+	return started, err
+}
+
+func (w *Worker) GetTasks(ctx context.Context) ([]*task.Task, error) {
+	tasks := []*task.Task{}
+	err := w.do(ctx, http.MethodGet, "/api/tasks", nil, &tasks)
+	return tasks, err
+}
+
+// GetNode returns the worker's description of its node.
+func (w *Worker) GetNode(ctx context.Context) (*node.Node, error) {
+	n := &node.Node{}
+	err := w.do(ctx, http.MethodGet, "/api/node", nil, n)
+	return n, err
+}
+
+func (w *Worker) StopTask(ctx context.Context, id string) error {
+	return w.do(ctx, http.MethodDelete, "/api/tasks/"+url.PathEscape(id), nil, nil)
+}
+
+func (w *Worker) GetTaskLogs(ctx context.Context, id string, tail int) (string, error) {
+	return w.getText(ctx, fmt.Sprintf("/api/tasks/%s/logs?tail=%d", url.PathEscape(id), tail))
+}
+
+func (w *Worker) GetImages(ctx context.Context) ([]image.Image, error) {
+	images := []image.Image{}
+	err := w.do(ctx, http.MethodGet, "/api/images", nil, &images)
+	return images, err
+}
+
+// PullImages asks the worker to pull images and waits for the pulls to
+// end.
+func (w *Worker) PullImages(ctx context.Context, req worker.ImagePullRequest) ([]image.Pull, error) {
+	pulls := []image.Pull{}
+	err := w.do(ctx, http.MethodPost, "/api/images/pull", req, &pulls)
+	return pulls, err
+}
+
+// CollectImages asks the worker to remove unused images and returns them.
+func (w *Worker) CollectImages(ctx context.Context, all bool) ([]image.Image, error) {
+	removed := []image.Image{}
+	err := w.do(ctx, http.MethodPost, fmt.Sprintf("/api/images/gc?all=%t", all), nil, &removed)
+	return removed, err
+}
+
+func (w *Worker) GetStats(ctx context.Context) (*worker.Stats, error) {
+	s := &worker.Stats{}
+	err := w.do(ctx, http.MethodGet, "/api/stats", nil, s)
+	return s, err
+}
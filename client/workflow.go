@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hugoleodev/pentagon/workflow"
+)
+
+func (m *Manager) CreateWorkflow(ctx context.Context, w workflow.Workflow) (*workflow.Workflow, error) {
+	if w.Namespace == "" {
+		w.Namespace = m.Namespace
+	}
+
+	created := &workflow.Workflow{}
+	err := m.do(ctx, http.MethodPost, "/api/workflows", w, created)
+	return created, err
+}
+
+func (m *Manager) GetWorkflows(ctx context.Context) ([]*workflow.Workflow, error) {
+	workflows := []*workflow.Workflow{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/workflows", url.Values{}), nil, &workflows)
+	return workflows, err
+}
+
+func (m *Manager) GetWorkflow(ctx context.Context, name string) (*workflow.Workflow, error) {
+	w := &workflow.Workflow{}
+	err := m.do(ctx, http.MethodGet, m.scoped("/api/workflows/"+url.PathEscape(name), url.Values{}), nil, w)
+	return w, err
+}
+
+func (m *Manager) DeleteWorkflow(ctx context.Context, name string) error {
+	return m.do(ctx, http.MethodDelete, m.scoped("/api/workflows/"+url.PathEscape(name), url.Values{}), nil, nil)
+}
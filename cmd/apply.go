@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hugoleodev/pentagon/manifest"
+)
+
+func init() {
+	register("apply", "Apply a task manifest", runApply)
+	register("diff", "Show the changes applying a manifest would make", runDiff)
+}
+
+func runApply(args []string) error {
+	return applyManifest("apply", args, false)
+}
+
+func runDiff(args []string) error {
+	return applyManifest("diff", args, true)
+}
+
+func applyManifest(name string, args []string, diff bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cf := newClientFlags(fs)
+	file := fs.String("f", "", "YAML or JSON manifest file")
+	prune := fs.Bool("prune", false, "delete tasks of specs that are no longer in the manifest")
+	dryRun := fs.Bool("dry-run", false, "only show the changes that would be made")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if *file == "" {
+		return fmt.Errorf("%s requires a manifest file (-f)", name)
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	mf := manifest.Manifest{}
+	if err := decodeFile(*file, &mf); err != nil {
+		return err
+	}
+	if err := mf.Validate(); err != nil {
+		return err
+	}
+
+	result, err := cf.client().Apply(ctx, mf, diff || *dryRun, *prune)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(result, func(w io.Writer) {
+		fmt.Fprintln(w, "ACTION\tNAME\tREPLICA\tCURRENT\tIMAGE")
+		for _, c := range result.Changes {
+			current, image := "-", "-"
+			if c.Current != nil {
+				current = c.Current.ID.String()
+				image = c.Current.Image
+			}
+			if c.Desired != nil {
+				image = c.Desired.Image
+				if c.Current != nil && c.Current.Image != c.Desired.Image {
+					image = c.Current.Image + " -> " + c.Desired.Image
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", c.Action, c.Name, c.Replica, current, image)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/config"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+func init() {
+	register("whoami", "Show who the manager authenticates you as", runWhoAmI)
+	register("token", "Issue a JSON web token for the manager API", runToken)
+}
+
+func runWhoAmI(args []string) error {
+	fs := flag.NewFlagSet("whoami", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	p, err := cf.client().WhoAmI(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(p, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", p.Name)
+		fmt.Fprintf(w, "Role:\t%s\n", p.Role)
+		namespaces := "*"
+		if len(p.Namespaces) > 0 {
+			namespaces = strings.Join(p.Namespaces, ",")
+		}
+		fmt.Fprintf(w, "Namespaces:\t%s\n", namespaces)
+	})
+}
+
+// runToken signs a token with the manager's JWT secret, read from
+// PENTAGON_MANAGER_JWT_SECRET so it stays off the command line.
+func runToken(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	name := fs.String("name", "", "name of the principal the token identifies")
+	role := fs.String("role", string(auth.Viewer), "role granted by the token (viewer, operator, admin)")
+	var namespaces stringList
+	fs.Var(&namespaces, "namespace", "namespace the token is restricted to (repeatable, default every namespace)")
+	ttl := fs.Duration("ttl", 0, "how long the token is valid (0 means it never expires)")
+	fs.Parse(args)
+
+	secret := os.Getenv(config.EnvPrefix + "MANAGER_JWT_SECRET")
+	if secret == "" {
+		return fmt.Errorf("%sMANAGER_JWT_SECRET is required to sign tokens", config.EnvPrefix)
+	}
+	if *name == "" {
+		return fmt.Errorf("token requires a -name")
+	}
+
+	r, err := auth.ParseRole(*role)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if err := namespace.ValidateName(ns); err != nil {
+			return err
+		}
+	}
+
+	token, err := auth.Sign(auth.Principal{Name: *name, Role: r, Namespaces: namespaces}, []byte(secret), *ttl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+	return nil
+}
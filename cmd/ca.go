@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"crypto/x509/pkix"
+	"flag"
+	"fmt"
+
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/pki"
+)
+
+func init() {
+	register("ca", "Manage the cluster CA and issue certificates from its files (init, issue, hash)", runCA)
+}
+
+func runCA(args []string) error {
+	return runSubcommand("ca", args, map[string]func([]string) error{
+		"init":  runCAInit,
+		"issue": runCAIssue,
+		"hash":  runCAHash,
+	})
+}
+
+func runCAInit(args []string) error {
+	fs := flag.NewFlagSet("ca init", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory to create the CA in, the manager's -tls-dir")
+	fs.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("ca init requires a -dir")
+	}
+
+	ca, err := pki.CreateCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ca.Hash())
+	return nil
+}
+
+// runCAIssue issues a certificate straight from the CA files, without the
+// manager: a worker certificate, as a stand-in for joining, or a client
+// certificate for a user.
+func runCAIssue(args []string) error {
+	fs := flag.NewFlagSet("ca issue", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of the CA")
+	out := fs.String("out", "", "directory to write the certificate, its key and the CA certificate to")
+	name := fs.String("name", "", "worker name, or user name with -client")
+	var hosts stringList
+	fs.Var(&hosts, "host", "additional DNS name or IP address of a worker (repeatable)")
+	clientCert := fs.Bool("client", false, "issue a client certificate for a user instead of a worker certificate")
+	role := fs.String("role", string(auth.Viewer), "role of the user (viewer, operator, admin)")
+	var namespaces stringList
+	fs.Var(&namespaces, "namespace", "namespace the user is restricted to (repeatable, default every namespace)")
+	fs.Parse(args)
+
+	if *dir == "" || *out == "" || *name == "" {
+		return fmt.Errorf("ca issue requires -dir, -out and -name")
+	}
+	if *name == pki.ManagerName {
+		return fmt.Errorf("%s is reserved for the manager", pki.ManagerName)
+	}
+
+	ca, err := pki.LoadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	var certPEM, keyPEM []byte
+	files := map[string][]byte{pki.CACertFile: ca.CertPEM}
+	if *clientCert {
+		r, err := auth.ParseRole(*role)
+		if err != nil {
+			return err
+		}
+		for _, ns := range namespaces {
+			if err := namespace.ValidateName(ns); err != nil {
+				return err
+			}
+		}
+
+		subject := pkix.Name{CommonName: *name, Organization: []string{string(r)}, OrganizationalUnit: namespaces}
+		if certPEM, keyPEM, err = ca.Issue(subject, nil, pki.Client); err != nil {
+			return err
+		}
+		files[*name+".crt"], files[*name+".key"] = certPEM, keyPEM
+	} else {
+		if certPEM, keyPEM, err = ca.Issue(pkix.Name{CommonName: *name}, append(pki.Hosts(""), hosts...), pki.Server); err != nil {
+			return err
+		}
+		files[pki.WorkerCertFile], files[pki.WorkerKeyFile] = certPEM, keyPEM
+	}
+
+	if err := pki.WriteFiles(*out, files); err != nil {
+		return err
+	}
+
+	fmt.Println(*out)
+	return nil
+}
+
+func runCAHash(args []string) error {
+	fs := flag.NewFlagSet("ca hash", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of the CA")
+	fs.Parse(args)
+
+	ca, err := pki.LoadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ca.Hash())
+	return nil
+}
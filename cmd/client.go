@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hugoleodev/pentagon/client"
+	"github.com/hugoleodev/pentagon/config"
+	"github.com/hugoleodev/pentagon/internal/yaml"
+	"github.com/hugoleodev/pentagon/pki"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// clientFlags are shared by every command that talks to the manager API.
+type clientFlags struct {
+	manager   string
+	namespace string
+	token     string
+	tlsCA     string
+	tlsCert   string
+	tlsKey    string
+	all       bool
+	output    string
+	timeout   time.Duration
+}
+
+func newClientFlags(fs *flag.FlagSet) *clientFlags {
+	f := &clientFlags{}
+
+	manager := os.Getenv(config.EnvPrefix + "MANAGER_URL")
+	if manager == "" {
+		manager = "localhost:8888"
+	}
+
+	namespace := os.Getenv(config.EnvPrefix + "NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	fs.StringVar(&f.manager, "manager", manager, "manager API address (env PENTAGON_MANAGER_URL)")
+	fs.StringVar(&f.namespace, "n", namespace, "namespace to work in (env PENTAGON_NAMESPACE)")
+	fs.StringVar(&f.token, "token", "", "API token or JWT to authenticate with (env PENTAGON_TOKEN)")
+	fs.StringVar(&f.tlsCA, "tls-ca", os.Getenv(config.EnvPrefix+"TLS_CA"), "CA certificate to verify the manager with; enables TLS (env PENTAGON_TLS_CA)")
+	fs.StringVar(&f.tlsCert, "tls-cert", os.Getenv(config.EnvPrefix+"TLS_CERT"), "client certificate to authenticate with (env PENTAGON_TLS_CERT)")
+	fs.StringVar(&f.tlsKey, "tls-key", os.Getenv(config.EnvPrefix+"TLS_KEY"), "key of the client certificate (env PENTAGON_TLS_KEY)")
+	fs.StringVar(&f.output, "o", outputTable, "output format (table, json)")
+	fs.DurationVar(&f.timeout, "timeout", client.DefaultTimeout, "timeout for each request to the manager")
+	return f
+}
+
+// allNamespaces adds the -A flag to a listing command.
+func (f *clientFlags) allNamespaces(fs *flag.FlagSet) {
+	fs.BoolVar(&f.all, "A", false, "list across every namespace")
+}
+
+func (f *clientFlags) client() *client.Manager {
+	token := f.token
+	if token == "" {
+		token = os.Getenv(config.EnvPrefix + "TOKEN")
+	}
+
+	opts := []client.Option{client.WithTimeout(f.timeout), client.WithToken(token)}
+	if f.tlsCA != "" || f.tlsCert != "" {
+		cfg, err := pki.ClientTLS(f.tlsCA, f.tlsCert, f.tlsKey)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to load TLS credentials")
+		}
+		opts = append(opts, client.WithTLS(cfg))
+	}
+
+	c := client.NewManager(f.manager, opts...)
+	c.Namespace = f.namespace
+	if f.all {
+		c.Namespace = ""
+	}
+	return c
+}
+
+func (f *clientFlags) validate() error {
+	if f.output != outputTable && f.output != outputJSON {
+		return fmt.Errorf("unknown output format %q", f.output)
+	}
+	return nil
+}
+
+// print writes v as indented JSON or, for table output, calls table with a
+// tab separated writer.
+func (f *clientFlags) print(v interface{}, table func(w io.Writer)) error {
+	if f.output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// requireArg returns the single positional argument of a command.
+func requireArg(fs *flag.FlagSet, name string) (string, error) {
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s requires exactly one %s argument", fs.Name(), name)
+	}
+	return fs.Arg(0), nil
+}
+
+// decodeFile decodes a YAML or JSON file into v, based on its extension.
+func decodeFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, v)
+	default:
+		err = json.Unmarshal(data, v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// formatLabels renders a label set as sorted KEY=VALUE pairs.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
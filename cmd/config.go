@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/configs"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+func init() {
+	register("config", "Manage config files tasks mount (create, ls, inspect, update, rm)", runConfig)
+}
+
+func runConfig(args []string) error {
+	return runSubcommand("config", args, map[string]func([]string) error{
+		"create":  runConfigCreate,
+		"ls":      runConfigList,
+		"inspect": runConfigInspect,
+		"update":  runConfigUpdate,
+		"rm":      runConfigRemove,
+	})
+}
+
+func runConfigCreate(args []string) error {
+	fs := flag.NewFlagSet("config create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	df := newDataFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "config name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	data, err := df.data()
+	if err != nil {
+		return err
+	}
+
+	created, err := cf.client().CreateConfig(ctx, configs.Config{Name: name, Data: data})
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runConfigList(args []string) error {
+	fs := flag.NewFlagSet("config ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	cs, err := cf.client().GetConfigs(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(cs, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tKEYS\tVERSION\tCREATED\tUPDATED")
+		for _, c := range cs {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", namespace.OrDefault(c.Namespace), c.Name, len(c.Data), c.Version, formatTime(c.CreatedAt), formatTime(c.UpdatedAt))
+		}
+	})
+}
+
+func runConfigInspect(args []string) error {
+	fs := flag.NewFlagSet("config inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "config name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	c, err := cf.client().GetConfig(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(c, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", c.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(c.Namespace))
+		fmt.Fprintf(w, "Version:\t%d\n", c.Version)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(c.CreatedAt))
+		fmt.Fprintf(w, "Updated:\t%s\n", formatTime(c.UpdatedAt))
+		fmt.Fprintf(w, "Keys:\t%s\n", strings.Join(c.Keys(), ", "))
+	})
+}
+
+// runConfigUpdate replaces every file of a config; keys left out are
+// removed.
+func runConfigUpdate(args []string) error {
+	fs := flag.NewFlagSet("config update", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	df := newDataFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "config name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	data, err := df.data()
+	if err != nil {
+		return err
+	}
+
+	updated, err := cf.client().UpdateConfig(ctx, name, data)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(updated, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\tversion %d\n", updated.Name, updated.Version)
+	})
+}
+
+func runConfigRemove(args []string) error {
+	fs := flag.NewFlagSet("config rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "config name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteConfig(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hugoleodev/pentagon/crontask"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+func init() {
+	register("cron", "Manage cron-scheduled tasks (create, ls, inspect, suspend, resume, rm)", runCron)
+}
+
+func runCron(args []string) error {
+	return runSubcommand("cron", args, map[string]func([]string) error{
+		"create":  runCronCreate,
+		"ls":      runCronList,
+		"inspect": runCronInspect,
+		"suspend": func(args []string) error { return runCronSuspend("suspend", args, true) },
+		"resume":  func(args []string) error { return runCronSuspend("resume", args, false) },
+		"rm":      runCronRemove,
+	})
+}
+
+func runCronCreate(args []string) error {
+	fs := flag.NewFlagSet("cron create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tf := newTaskFlags(fs)
+	file := fs.String("f", "", "YAML or JSON file describing the cron task")
+	schedule := fs.String("schedule", "", "cron expression, e.g. \"0 2 * * *\" or @hourly")
+	timezone := fs.String("timezone", "", "time zone the schedule is evaluated in (default UTC)")
+	policy := fs.String("concurrency-policy", crontask.Allow, "what to do when a run is due while the previous one is active (allow, forbid, replace)")
+	deadline := fs.Int("starting-deadline", 0, "seconds after its schedule time a missed run may still start (0 means no limit)")
+	successLimit := fs.Int("successful-history", crontask.DefaultSuccessfulHistoryLimit, "number of successful runs to keep")
+	failedLimit := fs.Int("failed-history", crontask.DefaultFailedHistoryLimit, "number of failed runs to keep")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	c := crontask.CronTask{}
+	if *file != "" {
+		if err := decodeFile(*file, &c); err != nil {
+			return err
+		}
+	}
+
+	set := explicitFlags(fs)
+	if *file == "" || set["schedule"] {
+		c.Schedule = *schedule
+	}
+	if *file == "" || set["timezone"] {
+		c.Timezone = *timezone
+	}
+	if *file == "" || set["concurrency-policy"] {
+		c.ConcurrencyPolicy = *policy
+	}
+	if *file == "" || set["starting-deadline"] {
+		c.StartingDeadlineSeconds = *deadline
+	}
+	if *file == "" || set["successful-history"] {
+		c.SuccessfulHistoryLimit = successLimit
+	}
+	if *file == "" || set["failed-history"] {
+		c.FailedHistoryLimit = failedLimit
+	}
+
+	if err := tf.apply(&c.Template); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		c.Name = c.Template.Name
+	}
+
+	created, err := cf.client().CreateCronTask(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runCronList(args []string) error {
+	fs := flag.NewFlagSet("cron ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	cronTasks, err := cf.client().GetCronTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(cronTasks, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tSCHEDULE\tIMAGE\tSUSPENDED\tACTIVE\tLAST SCHEDULE\tNEXT SCHEDULE")
+		for _, c := range cronTasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%d\t%s\t%s\n", namespace.OrDefault(c.Namespace), c.Name, c.Schedule, c.Template.Image, c.Suspend, len(c.Status.Active), formatTime(c.Status.LastScheduleTime), formatTime(c.Status.NextScheduleTime))
+		}
+	})
+}
+
+func runCronInspect(args []string) error {
+	fs := flag.NewFlagSet("cron inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "cron task name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	c, err := cf.client().GetCronTask(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(c, func(w io.Writer) {
+		timezone := c.Timezone
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		fmt.Fprintf(w, "Name:\t%s\n", c.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(c.Namespace))
+		fmt.Fprintf(w, "Schedule:\t%s (%s)\n", c.Schedule, timezone)
+		fmt.Fprintf(w, "Image:\t%s\n", c.Template.Image)
+		fmt.Fprintf(w, "Concurrency policy:\t%s\n", c.ConcurrencyPolicy)
+		fmt.Fprintf(w, "Suspended:\t%t\n", c.Suspend)
+		fmt.Fprintf(w, "History limits:\t%d successful, %d failed\n", *c.SuccessfulHistoryLimit, *c.FailedHistoryLimit)
+		fmt.Fprintf(w, "Active:\t%d\n", len(c.Status.Active))
+		fmt.Fprintf(w, "Last schedule:\t%s\n", formatTime(c.Status.LastScheduleTime))
+		fmt.Fprintf(w, "Last success:\t%s\n", formatTime(c.Status.LastSuccessfulTime))
+		fmt.Fprintf(w, "Next schedule:\t%s\n", formatTime(c.Status.NextScheduleTime))
+		if c.Status.Message != "" {
+			fmt.Fprintf(w, "Message:\t%s\n", c.Status.Message)
+		}
+	})
+}
+
+func runCronSuspend(name string, args []string, suspend bool) error {
+	fs := flag.NewFlagSet("cron "+name, flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	cronName, err := requireArg(fs, "cron task name")
+	if err != nil {
+		return err
+	}
+
+	if _, err := cf.client().SuspendCronTask(ctx, cronName, suspend); err != nil {
+		return err
+	}
+
+	fmt.Println(cronName)
+	return nil
+}
+
+func runCronRemove(args []string) error {
+	fs := flag.NewFlagSet("cron rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "cron task name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteCronTask(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hugoleodev/pentagon/group"
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func init() {
+	register("group", "Manage groups of tasks scheduled together (create, ls, inspect, rm)", runGroup)
+}
+
+func runGroup(args []string) error {
+	return runSubcommand("group", args, map[string]func([]string) error{
+		"create":  runGroupCreate,
+		"ls":      runGroupList,
+		"inspect": runGroupInspect,
+		"rm":      runGroupRemove,
+	})
+}
+
+func runGroupCreate(args []string) error {
+	fs := flag.NewFlagSet("group create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tf := newTaskFlags(fs)
+	file := fs.String("f", "", "YAML or JSON file describing the group")
+	size := fs.Int("size", 0, "number of identical tasks built from the task flags")
+	timeout := fs.Int("schedule-timeout", group.DefaultTimeoutSeconds, "seconds to wait for room for every task before giving up")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	g := group.Group{}
+	if *file != "" {
+		if err := decodeFile(*file, &g); err != nil {
+			return err
+		}
+	}
+
+	set := explicitFlags(fs)
+	if *file == "" || set["schedule-timeout"] {
+		g.TimeoutSeconds = *timeout
+	}
+
+	if *size > 0 {
+		t := task.Task{}
+		if err := tf.apply(&t); err != nil {
+			return err
+		}
+		if g.Name == "" {
+			g.Name = t.Name
+		}
+		t.Name = ""
+		for i := 0; i < *size; i++ {
+			g.Tasks = append(g.Tasks, t)
+		}
+	} else if *file == "" {
+		return fmt.Errorf("a group file (-f) or a size (-size) is required")
+	}
+
+	created, err := cf.client().CreateGroup(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runGroupList(args []string) error {
+	fs := flag.NewFlagSet("group ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	groups, err := cf.client().GetGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(groups, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tTASKS\tSTATE\tCREATED\tSCHEDULED")
+		for _, g := range groups {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", namespace.OrDefault(g.Namespace), g.Name, len(g.Tasks), g.Status.State, formatTime(g.CreatedAt), formatTime(g.Status.ScheduledAt))
+		}
+	})
+}
+
+func runGroupInspect(args []string) error {
+	fs := flag.NewFlagSet("group inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "group name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	c := cf.client()
+	g, err := c.GetGroup(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	c.Namespace = g.Namespace
+	selector := labels.SelectorFromMap(map[string]string{group.LabelID: g.ID.String()}).String()
+	tasks, err := c.GetTasks(ctx, selector)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(g, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", g.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(g.Namespace))
+		fmt.Fprintf(w, "State:\t%s\n", g.Status.State)
+		fmt.Fprintf(w, "Timeout:\t%ds\n", g.TimeoutSeconds)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(g.CreatedAt))
+		fmt.Fprintf(w, "Scheduled:\t%s\n", formatTime(g.Status.ScheduledAt))
+		if g.Status.Message != "" {
+			fmt.Fprintf(w, "Message:\t%s\n", g.Status.Message)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "MEMBER\tTASK\tNAME\tIMAGE\tSTATE")
+		for _, t := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Labels[group.LabelMember], t.ID, t.Name, t.Image, t.State)
+		}
+	})
+}
+
+func runGroupRemove(args []string) error {
+	fs := flag.NewFlagSet("group rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "group name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteGroup(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/image"
+	"github.com/hugoleodev/pentagon/manager"
+)
+
+func init() {
+	register("image", "Manage the images cached on nodes (ls, pull, gc)", runImage)
+}
+
+func runImage(args []string) error {
+	return runSubcommand("image", args, map[string]func([]string) error{
+		"ls":   runImageList,
+		"pull": runImagePull,
+		"gc":   runImageGC,
+	})
+}
+
+func runImageList(args []string) error {
+	fs := flag.NewFlagSet("image ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	nodeName, err := requireArg(fs, "node name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	images, err := cf.client().GetNodeImages(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(images, printImages(images))
+}
+
+// runImagePull pulls images on every node matching a selector ahead of the
+// tasks that need them. Pulls of large images take a while, so the timeout
+// defaults to the manager's own.
+func runImagePull(args []string) error {
+	fs := flag.NewFlagSet("image pull", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	selector := fs.String("l", "", "label selector of the nodes to pull on, e.g. zone=eu-west-1a (default: every node)")
+	registryAuth := fs.String("registry-auth", "", "secret holding the username and password keys to pull the images with")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("at least one image is required")
+	}
+	if !explicitFlags(fs)["timeout"] {
+		cf.timeout = manager.ImageRequestTimeout
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	results, err := cf.client().PullImages(ctx, image.PullRequest{
+		Images:       fs.Args(),
+		Selector:     *selector,
+		RegistryAuth: *registryAuth,
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+			continue
+		}
+		for _, p := range r.Pulls {
+			if p.Error != "" {
+				failed++
+			}
+		}
+	}
+
+	err = cf.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "NODE\tIMAGE\tSTATUS")
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(w, "%s\t-\t%s\n", r.Node, r.Error)
+				continue
+			}
+			for _, p := range r.Pulls {
+				status := "pulled"
+				if p.Error != "" {
+					status = p.Error
+				} else if len(p.Progress) > 0 {
+					status = p.Progress[len(p.Progress)-1]
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", r.Node, p.Image, status)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d pulls failed", failed)
+	}
+	return nil
+}
+
+func runImageGC(args []string) error {
+	fs := flag.NewFlagSet("image gc", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	all := fs.Bool("all", false, "remove every unused image past the minimum age, whatever the disk usage")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	nodeName, err := requireArg(fs, "node name")
+	if err != nil {
+		return err
+	}
+	if !explicitFlags(fs)["timeout"] {
+		cf.timeout = manager.ImageRequestTimeout
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	removed, err := cf.client().CollectNodeImages(ctx, nodeName, *all)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(removed, printImages(removed))
+}
+
+func printImages(images []image.Image) func(io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tTAGS\tSIZE\tLAST USED\tIN USE")
+		for _, img := range images {
+			tags := strings.Join(img.Tags, ",")
+			if tags == "" {
+				tags = "-"
+			}
+			id := strings.TrimPrefix(img.ID, "sha256:")
+			if len(id) > 12 {
+				id = id[:12]
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%t\n", id, tags, img.Size, formatTime(img.LastUsed), img.InUse)
+		}
+	}
+}
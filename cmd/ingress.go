@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/hugoleodev/pentagon/config"
+	"github.com/hugoleodev/pentagon/ingress"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	register("ingress", "Run a reverse proxy routing HTTP traffic to the tasks of services", runIngress)
+}
+
+// runIngress serves the routes of the config file and of -route flags.
+// Routes given as flags send requests to services of the -n namespace.
+func runIngress(args []string) error {
+	fs := flag.NewFlagSet("ingress", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	configPath := fs.String("config", "", "path to a YAML or JSON config file")
+	address := fs.String("address", "", "address the ingress listens on")
+	port := fs.Int("port", 0, "port the ingress listens on")
+	refreshInterval := fs.Duration("refresh-interval", 0, "interval between fetching the tasks of the routed services from the manager")
+	failTimeout := fs.Duration("fail-timeout", 0, "how long a task a request could not reach is left out of the rotation")
+	var routes stringList
+	fs.Var(&routes, "route", "route HOST/PATH=SERVICE[:PORT], HOST and PATH being optional, e.g. shop.example.com/api=api:8080 (repeatable)")
+	fs.Parse(args)
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	ic := &c.Ingress
+	for flagName := range explicitFlags(fs) {
+		switch flagName {
+		case "address":
+			ic.Address = *address
+		case "port":
+			ic.Port = *port
+		case "refresh-interval":
+			ic.RefreshInterval = config.Duration{Duration: *refreshInterval}
+		case "fail-timeout":
+			ic.FailTimeout = config.Duration{Duration: *failTimeout}
+		}
+	}
+	for _, s := range routes {
+		r, err := ingress.ParseRoute(s)
+		if err != nil {
+			return err
+		}
+		r.Namespace = cf.namespace
+		ic.Routes = append(ic.Routes, r)
+	}
+
+	if err := ic.Validate(); err != nil {
+		return err
+	}
+
+	p, err := ingress.New(ic.Routes)
+	if err != nil {
+		return err
+	}
+	p.FailTimeout = ic.FailTimeout.Duration
+
+	// The tasks are fetched namespace by namespace, so the ingress only
+	// needs access to those of its routes.
+	mc := cf.client()
+	fetch := func() ([]task.Endpoint, error) {
+		var endpoints []task.Endpoint
+		for _, ns := range p.Namespaces() {
+			mc.Namespace = ns
+			found, err := mc.GetEndpoints(context.Background(), "")
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, found...)
+		}
+		return endpoints, nil
+	}
+
+	for _, r := range ic.Routes {
+		log.Info().Msgf("Routing %s to service %s in namespace %s", r, r.Service, namespace.OrDefault(r.Namespace))
+	}
+	log.Info().Msgf("Starting Pentagon ingress on %s:%d with manager %s", ic.Address, ic.Port, cf.manager)
+
+	go p.Sync(fetch, ic.RefreshInterval.Duration)
+
+	return http.ListenAndServe(fmt.Sprintf("%s:%d", ic.Address, ic.Port), p)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hugoleodev/pentagon/job"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+func init() {
+	register("job", "Manage run-to-completion jobs (create, ls, inspect, rm)", runJob)
+}
+
+func runJob(args []string) error {
+	return runSubcommand("job", args, map[string]func([]string) error{
+		"create":  runJobCreate,
+		"ls":      runJobList,
+		"inspect": runJobInspect,
+		"rm":      runJobRemove,
+	})
+}
+
+func runJobCreate(args []string) error {
+	fs := flag.NewFlagSet("job create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tf := newTaskFlags(fs)
+	file := fs.String("f", "", "YAML or JSON file describing the job")
+	completions := fs.Int("completions", 1, "number of successful completions required")
+	parallelism := fs.Int("parallelism", 1, "maximum number of tasks running at once")
+	backoffLimit := fs.Int("backoff-limit", job.DefaultBackoffLimit, "number of failed tasks tolerated before the job fails")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	j := job.Job{}
+	if *file != "" {
+		if err := decodeFile(*file, &j); err != nil {
+			return err
+		}
+	}
+
+	set := explicitFlags(fs)
+	if *file == "" || set["completions"] {
+		j.Completions = *completions
+	}
+	if *file == "" || set["parallelism"] {
+		j.Parallelism = *parallelism
+	}
+	if *file == "" || set["backoff-limit"] {
+		j.BackoffLimit = backoffLimit
+	}
+
+	if err := tf.apply(&j.Template); err != nil {
+		return err
+	}
+	if j.Name == "" {
+		j.Name = j.Template.Name
+	}
+
+	created, err := cf.client().CreateJob(ctx, j)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runJobList(args []string) error {
+	fs := flag.NewFlagSet("job ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	jobs, err := cf.client().GetJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(jobs, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tIMAGE\tSTATE\tCOMPLETIONS\tACTIVE\tFAILED")
+		for _, j := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d\t%d\t%d\n", namespace.OrDefault(j.Namespace), j.Name, j.Template.Image, j.Status.State, j.Status.Succeeded, j.Completions, j.Status.Active, j.Status.Failed)
+		}
+	})
+}
+
+func runJobInspect(args []string) error {
+	fs := flag.NewFlagSet("job inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "job name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	j, err := cf.client().GetJob(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(j, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", j.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(j.Namespace))
+		fmt.Fprintf(w, "Image:\t%s\n", j.Template.Image)
+		fmt.Fprintf(w, "State:\t%s\n", j.Status.State)
+		fmt.Fprintf(w, "Completions:\t%d/%d\n", j.Status.Succeeded, j.Completions)
+		fmt.Fprintf(w, "Parallelism:\t%d\n", j.Parallelism)
+		fmt.Fprintf(w, "Active:\t%d\n", j.Status.Active)
+		fmt.Fprintf(w, "Failed:\t%d (backoff limit %d)\n", j.Status.Failed, *j.BackoffLimit)
+		fmt.Fprintf(w, "Next retry:\t%s\n", formatTime(j.Status.NextRetryAt))
+		fmt.Fprintf(w, "Started:\t%s\n", formatTime(j.Status.StartedAt))
+		fmt.Fprintf(w, "Completed:\t%s\n", formatTime(j.Status.CompletedAt))
+		if j.Status.Message != "" {
+			fmt.Fprintf(w, "Message:\t%s\n", j.Status.Message)
+		}
+	})
+}
+
+func runJobRemove(args []string) error {
+	fs := flag.NewFlagSet("job rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "job name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteJob(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/client"
+	"github.com/hugoleodev/pentagon/config"
+	"github.com/hugoleodev/pentagon/manager"
+	"github.com/hugoleodev/pentagon/manager/api"
+	"github.com/hugoleodev/pentagon/manager/dns"
+	"github.com/hugoleodev/pentagon/pki"
+	"github.com/hugoleodev/pentagon/scheduler"
+	"github.com/hugoleodev/pentagon/secret"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	register("manager", "Run a pentagon manager", runManager)
+}
+
+func runManager(args []string) error {
+	fs := flag.NewFlagSet("manager", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to a YAML or JSON config file")
+	address := fs.String("address", "", "address the manager API listens on")
+	port := fs.Int("port", 0, "port the manager API listens on")
+	workers := fs.String("workers", "", "comma separated list of worker host:port addresses")
+	schedulerType := fs.String("scheduler", "", "scheduler to use (roundrobin, greedy)")
+	storeType := fs.String("store", "", "store backend (memory, file)")
+	storePath := fs.String("store-path", "", "directory used by the file store")
+	processInterval := fs.Duration("process-interval", 0, "interval between dispatching pending tasks")
+	updateInterval := fs.Duration("update-interval", 0, "interval between polling workers for task updates")
+	requestTimeout := fs.Duration("request-timeout", 0, "timeout for requests sent to workers")
+	reconcileInterval := fs.Duration("reconcile-interval", 0, "interval between service and job reconciliations")
+	tokensFile := fs.String("tokens-file", "", "YAML or JSON file of API tokens and their roles; enables authentication")
+	tlsDir := fs.String("tls-dir", "", "directory of the cluster CA and manager certificate, created if missing; enables TLS")
+	secretsKeyFile := fs.String("secrets-key-file", "", "file holding the key secrets are encrypted with, see secret keygen; enables secrets")
+	preferCachedImages := fs.Bool("prefer-cached-images", false, "prefer the workers already holding the image of a task")
+	dnsAddress := fs.String("dns-address", "", "address the DNS server listens on")
+	dnsPort := fs.Int("dns-port", 0, "port the DNS server listens on, 53 for containers to use it; enables the DNS server")
+	dnsDomain := fs.String("dns-domain", "", "domain the DNS server serves task names under")
+	dnsTTL := fs.Duration("dns-ttl", 0, "time to live of DNS answers")
+	var dnsUpstreams stringList
+	fs.Var(&dnsUpstreams, "dns-upstream", "IP or IP:port of a name server answering names outside the DNS domain, defaults to those of the host (repeatable)")
+	fs.Parse(args)
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	mc := &c.Manager
+	for name := range explicitFlags(fs) {
+		switch name {
+		case "address":
+			mc.Address = *address
+		case "port":
+			mc.Port = *port
+		case "workers":
+			mc.Workers = config.SplitList(*workers)
+		case "scheduler":
+			mc.Scheduler = *schedulerType
+		case "store":
+			mc.Store.Type = *storeType
+		case "store-path":
+			mc.Store.Path = *storePath
+		case "process-interval":
+			mc.ProcessInterval = config.Duration{Duration: *processInterval}
+		case "update-interval":
+			mc.UpdateInterval = config.Duration{Duration: *updateInterval}
+		case "request-timeout":
+			mc.RequestTimeout = config.Duration{Duration: *requestTimeout}
+		case "reconcile-interval":
+			mc.ReconcileInterval = config.Duration{Duration: *reconcileInterval}
+		case "tokens-file":
+			mc.Auth.TokensFile = *tokensFile
+		case "tls-dir":
+			mc.TLS.Dir = *tlsDir
+		case "secrets-key-file":
+			mc.SecretsKeyFile = *secretsKeyFile
+		case "prefer-cached-images":
+			mc.PreferCachedImages = *preferCachedImages
+		case "dns-address":
+			mc.DNS.Address = *dnsAddress
+		case "dns-port":
+			mc.DNS.Port = *dnsPort
+		case "dns-domain":
+			mc.DNS.Domain = *dnsDomain
+		case "dns-ttl":
+			mc.DNS.TTL = config.Duration{Duration: *dnsTTL}
+		case "dns-upstream":
+			mc.DNS.Upstreams = dnsUpstreams
+		}
+	}
+
+	if err := mc.Validate(); err != nil {
+		return err
+	}
+
+	authenticator, err := newAuthenticator(mc.Auth)
+	if err != nil {
+		return err
+	}
+	if mc.Auth.WorkerToken == "" {
+		log.Warn().Msg("No worker token set (PENTAGON_MANAGER_WORKER_TOKEN), workers must accept unauthenticated requests")
+	}
+
+	a := api.API{Address: mc.Address, Port: mc.Port, Auth: authenticator}
+	opts := []client.Option{client.WithToken(mc.Auth.WorkerToken)}
+	if mc.TLS.Dir != "" {
+		server, workers, ca, err := pki.ManagerTLS(mc.TLS.Dir, mc.Address)
+		if err != nil {
+			return err
+		}
+		a.TLS, a.CA, a.JoinToken = server, ca, mc.TLS.JoinToken
+		opts = append(opts, client.WithTLSFor(workers))
+
+		log.Info().Msgf("Serving TLS with the cluster CA in %s (%s)", mc.TLS.Dir, ca.Hash())
+		if mc.TLS.JoinToken != "" {
+			log.Info().Msgf("Workers join with: pentagon worker -tls-dir DIR -join https://%s:%d -ca-hash %s (join token in PENTAGON_WORKER_JOIN_TOKEN)", mc.Address, mc.Port, ca.Hash())
+		}
+	} else {
+		log.Warn().Msg("TLS is disabled, traffic with workers and clients is sent in the clear")
+	}
+
+	m, err := manager.New(mc.Workers, mc.Scheduler, mc.Store.Type, mc.Store.Path, opts...)
+	if err != nil {
+		return err
+	}
+	a.Manager = m
+	m.ProcessInterval = mc.ProcessInterval.Duration
+	m.UpdateInterval = mc.UpdateInterval.Duration
+	m.RequestTimeout = mc.RequestTimeout.Duration
+	m.ReconcileInterval = mc.ReconcileInterval.Duration
+	if mc.PreferCachedImages {
+		m.Scheduler = scheduler.ImageLocality{Scheduler: m.Scheduler}
+	}
+	if mc.SecretsKeyFile != "" {
+		key, err := secret.LoadKey(mc.SecretsKeyFile)
+		if err != nil {
+			return err
+		}
+		if m.SecretCipher, err = secret.NewCipher(key); err != nil {
+			return err
+		}
+	} else {
+		log.Warn().Msg("No secrets key file set (PENTAGON_MANAGER_SECRETS_KEY_FILE), secrets are disabled")
+	}
+
+	log.Info().Msgf("Starting Pentagon manager on %s:%d with workers %v", mc.Address, mc.Port, mc.Workers)
+
+	go m.ProcessTasks()
+	go m.UpdateTasks()
+	go m.Reconcile()
+	if mc.DNS.Port > 0 {
+		d := dns.Server{Address: mc.DNS.Address, Port: mc.DNS.Port, Domain: mc.DNS.Domain, TTL: mc.DNS.TTL.Duration, Manager: m, Upstreams: mc.DNS.Upstreams}
+		go d.Start()
+	}
+
+	a.Start()
+
+	return nil
+}
+
+// newAuthenticator builds the manager API authenticator, or returns nil,
+// leaving the API open, when no tokens file or JWT secret is configured.
+func newAuthenticator(c config.AuthConfig) (*auth.Authenticator, error) {
+	if !c.Enabled() {
+		log.Warn().Msg("Authentication is disabled, anyone reaching the manager API can act as admin")
+		return nil, nil
+	}
+
+	var tokens []auth.Token
+	if c.TokensFile != "" {
+		var err error
+		if tokens, err = auth.LoadTokens(c.TokensFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.New(tokens, []byte(c.JWTSecret))
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+func init() {
+	register("namespace", "Manage namespaces and their quotas (create, ls, inspect, quota, rm)", runNamespace)
+}
+
+func runNamespace(args []string) error {
+	return runSubcommand("namespace", args, map[string]func([]string) error{
+		"create":  runNamespaceCreate,
+		"ls":      runNamespaceList,
+		"inspect": runNamespaceInspect,
+		"quota":   runNamespaceQuota,
+		"rm":      runNamespaceRemove,
+	})
+}
+
+// quotaFlags are the quota limits of namespace create and quota.
+type quotaFlags struct {
+	fs    *flag.FlagSet
+	quota namespace.Quota
+}
+
+func newQuotaFlags(fs *flag.FlagSet) *quotaFlags {
+	f := &quotaFlags{fs: fs}
+	fs.Float64Var(&f.quota.Cpu, "cpu", 0, "total CPUs the namespace's tasks may request (0 means unlimited)")
+	fs.Int64Var(&f.quota.Memory, "memory", 0, "total memory in bytes the namespace's tasks may request (0 means unlimited)")
+	fs.Int64Var(&f.quota.Disk, "disk", 0, "total disk in bytes the namespace's tasks may request (0 means unlimited)")
+	fs.IntVar(&f.quota.Tasks, "tasks", 0, "number of active tasks allowed (0 means unlimited)")
+	return f
+}
+
+// apply sets the limits given on the command line, leaving the others.
+func (f *quotaFlags) apply(q *namespace.Quota) {
+	for name := range explicitFlags(f.fs) {
+		switch name {
+		case "cpu":
+			q.Cpu = f.quota.Cpu
+		case "memory":
+			q.Memory = f.quota.Memory
+		case "disk":
+			q.Disk = f.quota.Disk
+		case "tasks":
+			q.Tasks = f.quota.Tasks
+		}
+	}
+}
+
+func runNamespaceCreate(args []string) error {
+	fs := flag.NewFlagSet("namespace create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	qf := newQuotaFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "namespace name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	ns := namespace.Namespace{Name: name}
+	qf.apply(&ns.Quota)
+
+	created, err := cf.client().CreateNamespace(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runNamespaceList(args []string) error {
+	fs := flag.NewFlagSet("namespace ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	namespaces, err := cf.client().GetNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(namespaces, func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tTASKS\tCPU\tMEMORY\tDISK\tCREATED")
+		for _, ns := range namespaces {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", ns.Name,
+				formatQuota(ns.Usage.Tasks, ns.Quota.Tasks),
+				formatQuota(ns.Usage.Cpu, ns.Quota.Cpu),
+				formatQuota(ns.Usage.Memory, ns.Quota.Memory),
+				formatQuota(ns.Usage.Disk, ns.Quota.Disk),
+				formatTime(ns.CreatedAt))
+		}
+	})
+}
+
+func runNamespaceInspect(args []string) error {
+	fs := flag.NewFlagSet("namespace inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "namespace name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	ns, err := cf.client().GetNamespace(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(ns, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", ns.Name)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(ns.CreatedAt))
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "RESOURCE\tUSED/LIMIT")
+		fmt.Fprintf(w, "tasks\t%s\n", formatQuota(ns.Usage.Tasks, ns.Quota.Tasks))
+		fmt.Fprintf(w, "cpu\t%s\n", formatQuota(ns.Usage.Cpu, ns.Quota.Cpu))
+		fmt.Fprintf(w, "memory\t%s\n", formatQuota(ns.Usage.Memory, ns.Quota.Memory))
+		fmt.Fprintf(w, "disk\t%s\n", formatQuota(ns.Usage.Disk, ns.Quota.Disk))
+	})
+}
+
+func runNamespaceQuota(args []string) error {
+	fs := flag.NewFlagSet("namespace quota", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	qf := newQuotaFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "namespace name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	c := cf.client()
+	ns, err := c.GetNamespace(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	q := ns.Quota
+	qf.apply(&q)
+
+	updated, err := c.SetQuota(ctx, name, q)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(updated, func(w io.Writer) {
+		fmt.Fprintln(w, updated.Name)
+	})
+}
+
+func runNamespaceRemove(args []string) error {
+	fs := flag.NewFlagSet("namespace rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "namespace name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteNamespace(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
+
+// formatQuota renders usage against a limit, e.g. 2/4, or 2/- when
+// unlimited.
+func formatQuota[T int | int64 | float64](used T, limit T) string {
+	if limit == 0 {
+		return fmt.Sprintf("%v/-", used)
+	}
+	return fmt.Sprintf("%v/%v", used, limit)
+}
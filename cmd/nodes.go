@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	register("nodes", "List the worker nodes known to the manager", runNodes)
+	register("cordon", "Stop scheduling new tasks on a node", func(args []string) error { return runCordon("cordon", args, true) })
+	register("uncordon", "Allow scheduling tasks on a cordoned node again", func(args []string) error { return runCordon("uncordon", args, false) })
+}
+
+func runNodes(args []string) error {
+	fs := flag.NewFlagSet("nodes", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	selector := fs.String("l", "", "label selector, e.g. zone=eu-west-1a")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	nodes, err := cf.client().GetNodes(ctx, *selector)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(nodes, func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tAPI\tROLE\tSTATUS\tTASKS\tCPU\tMEMORY\tDISK\tLABELS\tTAINTS")
+		for _, n := range nodes {
+			taints := make([]string, 0, len(n.Taints))
+			for _, t := range n.Taints {
+				taints = append(taints, t.String())
+			}
+			if len(taints) == 0 {
+				taints = append(taints, "-")
+			}
+			status := n.Status
+			if n.Unschedulable {
+				status += ",cordoned"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%g/%d\t%d/%d\t%d/%d\t%s\t%s\n", n.Name, n.Api, n.Role, status, n.TaskCount, n.CpuAllocated, n.Cores, n.MemoryAllocated, n.Memory, n.DiskAllocated, n.Disk, formatLabels(n.Labels), strings.Join(taints, ","))
+		}
+	})
+}
+
+func runCordon(name string, args []string, cordon bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	nodeName, err := requireArg(fs, "node name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	if _, err := cf.client().CordonNode(ctx, nodeName, cordon); err != nil {
+		return err
+	}
+
+	fmt.Println(nodeName)
+	return nil
+}
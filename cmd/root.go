@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/config"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{}
+
+func register(name string, summary string, run func(args []string) error) {
+	commands[name] = command{summary: summary, run: run}
+}
+
+// Execute runs the subcommand named by the first argument.
+func Execute(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage()
+		return nil
+	}
+
+	c, ok := commands[args[0]]
+	if !ok {
+		usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	return c.run(args[1:])
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: pentagon <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// loadConfig resolves the configuration file from the -config flag or the
+// PENTAGON_CONFIG environment variable.
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv(config.EnvPrefix + "CONFIG"))
+	}
+	return config.Load(path)
+}
+
+// explicitFlags returns the names of the flags set on the command line, so
+// they can take precedence over the config file and environment.
+func explicitFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// runSubcommand dispatches to one of the subcommands of a command group.
+func runSubcommand(group string, args []string, subcommands map[string]func([]string) error) error {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(args) == 0 {
+		return fmt.Errorf("usage: pentagon %s <%s>", group, strings.Join(names, "|"))
+	}
+
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown %s command %q, expected one of %s", group, args[0], strings.Join(names, ", "))
+	}
+
+	return run(args[1:])
+}
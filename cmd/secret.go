@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/secret"
+)
+
+func init() {
+	register("secret", "Manage secrets tasks reference (create, ls, inspect, rotate, rm, keygen)", runSecret)
+}
+
+func runSecret(args []string) error {
+	return runSubcommand("secret", args, map[string]func([]string) error{
+		"create":  runSecretCreate,
+		"ls":      runSecretList,
+		"inspect": runSecretInspect,
+		"rotate":  runSecretRotate,
+		"rm":      runSecretRemove,
+		"keygen":  runSecretKeygen,
+	})
+}
+
+// dataFlags are the values of secret create and rotate, and the files of
+// config create and update.
+type dataFlags struct {
+	literals stringList
+	files    stringList
+}
+
+func newDataFlags(fs *flag.FlagSet) *dataFlags {
+	f := &dataFlags{}
+	fs.Var(&f.literals, "from-literal", "key and value KEY=VALUE (repeatable)")
+	fs.Var(&f.files, "from-file", "key and file holding its value KEY=PATH (repeatable)")
+	return f
+}
+
+func (f *dataFlags) data() (map[string]string, error) {
+	data := map[string]string{}
+	for _, l := range f.literals {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid literal %q, expected KEY=VALUE", l)
+		}
+		data[k] = v
+	}
+	for _, file := range f.files {
+		k, path, ok := strings.Cut(file, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid file %q, expected KEY=PATH", file)
+		}
+		v, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data[k] = string(v)
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("at least one -from-literal or -from-file is required")
+	}
+	return data, nil
+}
+
+func runSecretCreate(args []string) error {
+	fs := flag.NewFlagSet("secret create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	df := newDataFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "secret name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	data, err := df.data()
+	if err != nil {
+		return err
+	}
+
+	created, err := cf.client().CreateSecret(ctx, secret.Secret{Name: name, Data: data})
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runSecretList(args []string) error {
+	fs := flag.NewFlagSet("secret ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	secrets, err := cf.client().GetSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(secrets, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tKEYS\tVERSION\tCREATED\tUPDATED")
+		for _, s := range secrets {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", namespace.OrDefault(s.Namespace), s.Name, len(s.Keys), s.Version, formatTime(s.CreatedAt), formatTime(s.UpdatedAt))
+		}
+	})
+}
+
+func runSecretInspect(args []string) error {
+	fs := flag.NewFlagSet("secret inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "secret name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	s, err := cf.client().GetSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(s, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", s.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(s.Namespace))
+		fmt.Fprintf(w, "Version:\t%d\n", s.Version)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(s.CreatedAt))
+		fmt.Fprintf(w, "Updated:\t%s\n", formatTime(s.UpdatedAt))
+		fmt.Fprintf(w, "Keys:\t%s\n", strings.Join(s.Keys, ", "))
+	})
+}
+
+// runSecretRotate replaces every value of a secret; keys left out are
+// removed.
+func runSecretRotate(args []string) error {
+	fs := flag.NewFlagSet("secret rotate", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	df := newDataFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "secret name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	data, err := df.data()
+	if err != nil {
+		return err
+	}
+
+	rotated, err := cf.client().RotateSecret(ctx, name, data)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(rotated, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\tversion %d\n", rotated.Name, rotated.Version)
+	})
+}
+
+func runSecretRemove(args []string) error {
+	fs := flag.NewFlagSet("secret rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "secret name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteSecret(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
+
+// runSecretKeygen prints a new key for the manager to seal secrets with.
+func runSecretKeygen(args []string) error {
+	fs := flag.NewFlagSet("secret keygen", flag.ExitOnError)
+	fs.Parse(args)
+
+	key, err := secret.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(key)
+	return nil
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/service"
+)
+
+func init() {
+	register("service", "Manage replicated services (create, ls, inspect, scale, rm)", runService)
+}
+
+func runService(args []string) error {
+	return runSubcommand("service", args, map[string]func([]string) error{
+		"create":   runServiceCreate,
+		"ls":       runServiceList,
+		"inspect":  runServiceInspect,
+		"scale":    runServiceScale,
+		"update":   runServiceUpdate,
+		"rollback": runServiceRollback,
+		"rm":       runServiceRemove,
+	})
+}
+
+func runServiceCreate(args []string) error {
+	fs := flag.NewFlagSet("service create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tf := newTaskFlags(fs)
+	file := fs.String("f", "", "YAML or JSON file describing the service")
+	replicas := fs.Int("replicas", 1, "number of replicas to keep running")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	s := service.Service{Replicas: *replicas}
+	if *file != "" {
+		if err := decodeFile(*file, &s); err != nil {
+			return err
+		}
+		if explicitFlags(fs)["replicas"] {
+			s.Replicas = *replicas
+		}
+	}
+
+	if err := tf.apply(&s.Template); err != nil {
+		return err
+	}
+	if s.Name == "" {
+		s.Name = s.Template.Name
+	}
+
+	created, err := cf.client().CreateService(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.Name)
+	})
+}
+
+func runServiceList(args []string) error {
+	fs := flag.NewFlagSet("service ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	services, err := cf.client().GetServices(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(services, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tIMAGE\tREPLICAS\tRUNNING\tPENDING")
+		for _, s := range services {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n", namespace.OrDefault(s.Namespace), s.Name, s.Template.Image, s.Replicas, s.Status.Running, s.Status.Pending)
+		}
+	})
+}
+
+func runServiceInspect(args []string) error {
+	fs := flag.NewFlagSet("service inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "service name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	s, err := cf.client().GetService(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(s, func(w io.Writer) {
+		fmt.Fprintf(w, "Name:\t%s\n", s.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(s.Namespace))
+		fmt.Fprintf(w, "Image:\t%s\n", s.Template.Image)
+		fmt.Fprintf(w, "Version:\t%d\n", s.Version)
+		fmt.Fprintf(w, "Replicas:\t%d\n", s.Replicas)
+		fmt.Fprintf(w, "Running:\t%d\n", s.Status.Running)
+		fmt.Fprintf(w, "Pending:\t%d\n", s.Status.Pending)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(s.CreatedAt))
+		fmt.Fprintf(w, "Last reconciled:\t%s\n", formatTime(s.Status.LastReconciled))
+		if s.Status.Message != "" {
+			fmt.Fprintf(w, "Message:\t%s\n", s.Status.Message)
+		}
+		if u := s.Update; u != nil {
+			fmt.Fprintf(w, "Update:\t%s (version %d -> %d)\n", u.State, u.FromVersion, u.ToVersion)
+			fmt.Fprintf(w, "Update progress:\t%d/%d updated, %d failed\n", u.Updated, u.Total, u.Failed)
+			if u.Message != "" {
+				fmt.Fprintf(w, "Update message:\t%s\n", u.Message)
+			}
+		}
+	})
+}
+
+func runServiceScale(args []string) error {
+	fs := flag.NewFlagSet("service scale", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("service scale requires a service name and a replica count")
+	}
+	replicas, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("invalid replica count %q", fs.Arg(1))
+	}
+
+	s, err := cf.client().ScaleService(ctx, fs.Arg(0), replicas)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s scaled to %d\n", s.Name, s.Replicas)
+	return nil
+}
+
+func runServiceUpdate(args []string) error {
+	fs := flag.NewFlagSet("service update", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tf := newTaskFlags(fs)
+	maxSurge := fs.Int("max-surge", 0, "tasks allowed above the replica count during the update")
+	maxUnavailable := fs.Int("max-unavailable", 0, "tasks allowed below the replica count during the update")
+	failureThreshold := fs.Int("failure-threshold", 0, "failed new tasks tolerated before rolling back")
+	healthTimeout := fs.Int("health-timeout", 0, "seconds a new task may take to become healthy")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("service update requires a service name")
+	}
+	name := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+
+	c := cf.client()
+	s, err := c.GetService(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	template := s.Template
+	if err := tf.apply(&template); err != nil {
+		return err
+	}
+
+	var cfg *service.UpdateConfig
+	set := explicitFlags(fs)
+	if set["max-surge"] || set["max-unavailable"] || set["failure-threshold"] || set["health-timeout"] {
+		uc := s.UpdateConfig
+		if set["max-surge"] {
+			uc.MaxSurge = *maxSurge
+		}
+		if set["max-unavailable"] {
+			uc.MaxUnavailable = *maxUnavailable
+		}
+		if set["failure-threshold"] {
+			uc.FailureThreshold = *failureThreshold
+		}
+		if set["health-timeout"] {
+			uc.HealthTimeoutSeconds = *healthTimeout
+		}
+		cfg = &uc
+	}
+
+	updated, err := c.UpdateService(ctx, name, template, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s updating to version %d\n", updated.Name, updated.Version)
+	return nil
+}
+
+func runServiceRollback(args []string) error {
+	fs := flag.NewFlagSet("service rollback", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "service name")
+	if err != nil {
+		return err
+	}
+
+	s, err := cf.client().RollbackService(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s rolling back to version %d\n", s.Name, s.Version)
+	return nil
+}
+
+func runServiceRemove(args []string) error {
+	fs := flag.NewFlagSet("service rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "service name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteService(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// taskFlags describe a task on the command line. They are shared by every
+// command that submits a task or a task template.
+type taskFlags struct {
+	fs            *flag.FlagSet
+	name          string
+	image         string
+	pullPolicy    string
+	registryAuth  string
+	cpu           float64
+	memory        int64
+	disk          int64
+	restartPolicy string
+	priority      int
+	healthCheck   string
+	ports         stringList
+	env           stringList
+	labels        stringList
+	annotations   stringList
+	nodeSelector  stringList
+	antiAffinity  stringList
+	tolerations   stringList
+	secretEnv     stringList
+	secretFiles   stringList
+	configFiles   stringList
+	configRestart bool
+}
+
+func newTaskFlags(fs *flag.FlagSet) *taskFlags {
+	f := &taskFlags{fs: fs}
+	fs.StringVar(&f.name, "name", "", "name of the task")
+	fs.StringVar(&f.image, "image", "", "container image to run")
+	fs.StringVar(&f.pullPolicy, "pull-policy", "", "when the worker pulls the image: always, if-not-present or never (default: the worker's)")
+	fs.StringVar(&f.registryAuth, "registry-auth", "", "secret holding the username and password keys to pull the image with")
+	fs.Float64Var(&f.cpu, "cpu", 0, "number of CPUs to reserve")
+	fs.Int64Var(&f.memory, "memory", 0, "memory limit in bytes")
+	fs.Int64Var(&f.disk, "disk", 0, "disk to reserve in bytes")
+	fs.StringVar(&f.restartPolicy, "restart-policy", "", "docker restart policy (e.g. always, on-failure)")
+	fs.IntVar(&f.priority, "priority", 0, "scheduling priority; higher priority tasks are scheduled first and may preempt lower priority ones")
+	fs.StringVar(&f.healthCheck, "health-check", "", "HTTP path probed on the first published port, e.g. /healthz")
+	fs.Var(&f.ports, "port", "port to expose, e.g. 80/tcp (repeatable)")
+	fs.Var(&f.env, "env", "environment variable KEY=VALUE (repeatable)")
+	fs.Var(&f.secretEnv, "secret-env", "environment variable set from a secret, ENV=SECRET/KEY (repeatable)")
+	fs.Var(&f.configFiles, "config-file", "mount a config key as the file PATH, PATH=CONFIG/KEY, or every key under the directory PATH, PATH=CONFIG (repeatable)")
+	fs.BoolVar(&f.configRestart, "config-restart", false, "restart the task when a config mounted with -config-file changes")
+	fs.Var(&f.secretFiles, "secret-file", "mount a secret key as the file PATH, PATH=SECRET/KEY, or every key under the directory PATH, PATH=SECRET (repeatable)")
+	fs.Var(&f.labels, "label", "label KEY=VALUE (repeatable)")
+	fs.Var(&f.annotations, "annotation", "annotation KEY=VALUE (repeatable)")
+	fs.Var(&f.nodeSelector, "node-selector", "node label KEY=VALUE the task requires (repeatable)")
+	fs.Var(&f.tolerations, "toleration", "tolerate taints matching KEY[=VALUE][:EFFECT]; without a value any value matches (repeatable)")
+	fs.Var(&f.antiAffinity, "anti-affinity", "never place the task with tasks matching SELECTOR[@TOPOLOGY_KEY], e.g. app=db@zone (repeatable)")
+	return f
+}
+
+// apply overrides the fields of t with the flags set on the command line.
+// The first positional argument is used as the image when none is given.
+func (f *taskFlags) apply(t *task.Task) error {
+	for flagName := range explicitFlags(f.fs) {
+		switch flagName {
+		case "name":
+			t.Name = f.name
+		case "image":
+			t.Image = f.image
+		case "pull-policy":
+			t.ImagePullPolicy = task.PullPolicy(f.pullPolicy)
+		case "registry-auth":
+			t.RegistryAuth = f.registryAuth
+		case "cpu":
+			t.Cpu = f.cpu
+		case "memory":
+			t.Memory = f.memory
+		case "disk":
+			t.Disk = f.disk
+		case "restart-policy":
+			t.RestartPolicy = f.restartPolicy
+		case "priority":
+			t.Priority = f.priority
+		case "health-check":
+			t.HealthCheck = f.healthCheck
+		}
+	}
+
+	if f.fs.NArg() > 0 && t.Image == "" {
+		t.Image = f.fs.Arg(0)
+	}
+	if t.Image == "" {
+		return fmt.Errorf("an image is required")
+	}
+	if err := task.ValidatePullPolicy(t.ImagePullPolicy); err != nil {
+		return err
+	}
+
+	if len(f.ports) > 0 {
+		if t.ExposedPorts == nil {
+			t.ExposedPorts = nat.PortSet{}
+		}
+		for _, p := range f.ports {
+			port, err := nat.NewPort(nat.SplitProtoPort(p))
+			if err != nil {
+				return fmt.Errorf("invalid port %q: %w", p, err)
+			}
+			t.ExposedPorts[port] = struct{}{}
+		}
+	}
+
+	for _, e := range f.env {
+		if !strings.Contains(e, "=") {
+			return fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", e)
+		}
+		t.Env = append(t.Env, e)
+	}
+
+	for _, s := range f.secretEnv {
+		env, ref, _ := strings.Cut(s, "=")
+		name, key, _ := strings.Cut(ref, "/")
+		r := task.SecretRef{Secret: name, Key: key, Env: env}
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid secret env %q, expected ENV=SECRET/KEY: %w", s, err)
+		}
+		t.Secrets = append(t.Secrets, r)
+	}
+
+	for _, s := range f.secretFiles {
+		path, ref, _ := strings.Cut(s, "=")
+		name, key, _ := strings.Cut(ref, "/")
+		r := task.SecretRef{Secret: name, Key: key, Path: path}
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid secret file %q, expected PATH=SECRET[/KEY]: %w", s, err)
+		}
+		t.Secrets = append(t.Secrets, r)
+	}
+
+	for _, s := range f.configFiles {
+		path, ref, _ := strings.Cut(s, "=")
+		name, key, _ := strings.Cut(ref, "/")
+		r := task.ConfigRef{Config: name, Key: key, Path: path, RestartOnChange: f.configRestart}
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid config file %q, expected PATH=CONFIG[/KEY]: %w", s, err)
+		}
+		t.Configs = append(t.Configs, r)
+	}
+
+	for _, l := range f.labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid label %q, expected KEY=VALUE", l)
+		}
+		if t.Labels == nil {
+			t.Labels = map[string]string{}
+		}
+		t.Labels[k] = v
+	}
+
+	for _, a := range f.annotations {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid annotation %q, expected KEY=VALUE", a)
+		}
+		if t.Annotations == nil {
+			t.Annotations = map[string]string{}
+		}
+		t.Annotations[k] = v
+	}
+
+	for _, l := range f.nodeSelector {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid node selector %q, expected KEY=VALUE", l)
+		}
+		if t.Placement == nil {
+			t.Placement = &task.Placement{}
+		}
+		if t.Placement.NodeSelector == nil {
+			t.Placement.NodeSelector = map[string]string{}
+		}
+		t.Placement.NodeSelector[k] = v
+	}
+
+	for _, s := range f.tolerations {
+		kv, effect, _ := strings.Cut(s, ":")
+		key, value, hasValue := strings.Cut(kv, "=")
+		tol := task.Toleration{Key: key, Value: value, Effect: effect, Operator: task.TolerationExists}
+		if hasValue {
+			tol.Operator = task.TolerationEqual
+		}
+		if err := tol.Validate(); err != nil {
+			return err
+		}
+		t.Tolerations = append(t.Tolerations, tol)
+	}
+
+	for _, a := range f.antiAffinity {
+		selector, topologyKey, _ := strings.Cut(a, "@")
+		if t.Placement == nil {
+			t.Placement = &task.Placement{}
+		}
+		t.Placement.AntiAffinity = append(t.Placement.AntiAffinity, task.TaskAffinity{
+			Selector:    selector,
+			TopologyKey: topologyKey,
+		})
+	}
+
+	return nil
+}
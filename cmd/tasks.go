@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func init() {
+	register("run", "Submit a task to the manager", runRun)
+	register("ps", "List tasks", runPs)
+	register("ls", "List tasks (alias of ps)", runPs)
+	register("inspect", "Show the details of a task", runInspect)
+	register("stop", "Stop a task", runStop)
+	register("logs", "Show the container logs of a task", runLogs)
+	register("events", "List task events", runEvents)
+}
+
+func runRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tf := newTaskFlags(fs)
+	file := fs.String("f", "", "YAML or JSON file describing the task")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	t := task.Task{}
+	if *file != "" {
+		if err := decodeFile(*file, &t); err != nil {
+			return err
+		}
+	}
+
+	if err := tf.apply(&t); err != nil {
+		return err
+	}
+
+	t.State = task.Scheduled
+
+	te := task.TaskEvent{
+		ID:        uuid.New(),
+		State:     task.Scheduled,
+		Timestamp: time.Now().UTC(),
+		Task:      t,
+	}
+
+	created, err := cf.client().StartTask(ctx, te)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.ID)
+	})
+}
+
+func runPs(args []string) error {
+	fs := flag.NewFlagSet("ps", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	stateFilter := fs.String("state", "", "comma separated list of states to show")
+	all := fs.Bool("a", false, "show tasks in every state (default shows pending, scheduled and running)")
+	selector := fs.String("l", "", "label selector, e.g. team=payments,env in (prod,staging)")
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	var states []task.State
+	for _, name := range strings.Split(*stateFilter, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		s, err := task.ParseState(name)
+		if err != nil {
+			return err
+		}
+		states = append(states, s)
+	}
+	if len(states) == 0 && !*all {
+		states = []task.State{task.Pending, task.Scheduled, task.Running}
+	}
+
+	tasks, err := cf.client().GetTasks(ctx, *selector, states...)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(tasks, func(w io.Writer) {
+		fmt.Fprintln(w, "NAMESPACE\tID\tNAME\tIMAGE\tSTATE\tCONTAINER\tSTARTED")
+		for _, t := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", namespace.OrDefault(t.Namespace), t.ID, t.Name, t.Image, t.State, shortID(t.ContainerID), formatTime(t.StartTime))
+		}
+	})
+}
+
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	id, err := requireArg(fs, "task id")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	t, err := cf.client().GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(t, func(w io.Writer) {
+		fmt.Fprintf(w, "ID:\t%s\n", t.ID)
+		fmt.Fprintf(w, "Name:\t%s\n", t.Name)
+		fmt.Fprintf(w, "Namespace:\t%s\n", namespace.OrDefault(t.Namespace))
+		fmt.Fprintf(w, "Image:\t%s\n", t.Image)
+		if t.ImagePullPolicy != "" {
+			fmt.Fprintf(w, "Pull policy:\t%s\n", t.ImagePullPolicy)
+		}
+		if t.RegistryAuth != "" {
+			fmt.Fprintf(w, "Registry auth:\t%s\n", t.RegistryAuth)
+		}
+		fmt.Fprintf(w, "State:\t%s\n", t.State)
+		if t.State == task.Pending && t.PendingReason != "" {
+			fmt.Fprintf(w, "Pending reason:\t%s\n", t.PendingReason)
+			fmt.Fprintf(w, "Pending message:\t%s\n", t.PendingMessage)
+		}
+		fmt.Fprintf(w, "Container:\t%s\n", t.ContainerID)
+		fmt.Fprintf(w, "CPU:\t%v\n", t.Cpu)
+		fmt.Fprintf(w, "Memory:\t%d\n", t.Memory)
+		fmt.Fprintf(w, "Disk:\t%d\n", t.Disk)
+		fmt.Fprintf(w, "Restart policy:\t%s\n", t.RestartPolicy)
+		fmt.Fprintf(w, "Priority:\t%d\n", t.Priority)
+		fmt.Fprintf(w, "Started:\t%s\n", formatTime(t.StartTime))
+		fmt.Fprintf(w, "Finished:\t%s\n", formatTime(t.FinishTime))
+		fmt.Fprintf(w, "Labels:\t%s\n", formatLabels(t.Labels))
+		fmt.Fprintf(w, "Annotations:\t%s\n", formatLabels(t.Annotations))
+		if t.State == task.Completed || t.State == task.Failed {
+			fmt.Fprintf(w, "Exit code:\t%d\n", t.ExitCode)
+			fmt.Fprintf(w, "OOM killed:\t%t\n", t.OOMKilled)
+		}
+		if t.Error != "" {
+			fmt.Fprintf(w, "Error:\t%s\n", t.Error)
+		}
+		if t.StopReason != "" {
+			fmt.Fprintf(w, "Stop reason:\t%s\n", t.StopReason)
+		}
+	})
+}
+
+func runStop(args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	selector := fs.String("l", "", "stop every active task matching this label selector instead of a single task")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if *selector != "" {
+		stopped, err := cf.client().StopTasks(ctx, *selector)
+		if err != nil {
+			return err
+		}
+		for _, t := range stopped {
+			fmt.Println(t.ID)
+		}
+		return nil
+	}
+
+	id, err := requireArg(fs, "task id")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().StopTask(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
+
+func runLogs(args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	tail := fs.Int("tail", 0, "number of lines to show from the end of the logs")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	id, err := requireArg(fs, "task id")
+	if err != nil {
+		return err
+	}
+
+	logs, err := cf.client().GetTaskLogs(ctx, id, *tail)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(os.Stdout, logs)
+	return err
+}
+
+func runEvents(args []string) error {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	selector := fs.String("l", "", "only show events of tasks matching this label selector")
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	events, err := cf.client().GetEvents(ctx, *selector)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(events, func(w io.Writer) {
+		fmt.Fprintln(w, "TIME\tEVENT\tTASK\tNAME\tSTATE\tREASON")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", formatTime(e.Timestamp), e.ID, e.Task.ID, e.Task.Name, e.State, e.Reason)
+			for _, p := range e.Progress {
+				fmt.Fprintf(w, "\t\t\t\t\t  %s\n", p)
+			}
+		}
+	})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+
+	"github.com/hugoleodev/pentagon/config"
+	"github.com/hugoleodev/pentagon/internal/docker"
+	"github.com/hugoleodev/pentagon/pki"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/hugoleodev/pentagon/worker"
+	"github.com/hugoleodev/pentagon/worker/api"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	register("worker", "Run a pentagon worker", runWorker)
+}
+
+func runWorker(args []string) error {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to a YAML or JSON config file")
+	name := fs.String("name", "", "name of the worker")
+	address := fs.String("address", "", "address the worker API listens on")
+	port := fs.Int("port", 0, "port the worker API listens on")
+	runInterval := fs.Duration("run-interval", 0, "interval between processing queued tasks")
+	statsInterval := fs.Duration("stats-interval", 0, "interval between collecting host stats")
+	inspectInterval := fs.Duration("inspect-interval", 0, "interval between checking running containers for exits")
+	var nodeLabels stringList
+	fs.Var(&nodeLabels, "label", "node label KEY=VALUE, e.g. zone=eu-west-1a (repeatable)")
+	var taints stringList
+	fs.Var(&taints, "taint", "node taint KEY=VALUE:EFFECT, e.g. dedicated=batch:NoSchedule (repeatable)")
+	tlsDir := fs.String("tls-dir", "", "directory of the worker certificate; enables mutual TLS")
+	join := fs.String("join", "", "manager address to obtain a worker certificate from, e.g. https://manager:8888")
+	caHash := fs.String("ca-hash", "", "hash of the cluster CA, as printed by the manager, trusted when joining")
+	secretsDir := fs.String("secrets-dir", "", "directory, preferably on a tmpfs, the secret files of tasks are written to")
+	configsDir := fs.String("configs-dir", "", "directory the config files of tasks are written to")
+	pullPolicy := fs.String("pull-policy", "", "when images of tasks not setting a pull policy are pulled: always, if-not-present or never")
+	registryAuthFile := fs.String("registry-auth-file", "", "Docker client config file holding the credentials of private registries")
+	imageGCHigh := fs.Int("image-gc-high", 0, "disk usage percent above which unused images are removed; 0 disables image collection")
+	imageGCLow := fs.Int("image-gc-low", 0, "disk usage percent image collection frees down to")
+	imageGCMinAge := fs.Duration("image-gc-min-age", 0, "how long an image is kept after it was last used")
+	imageGCInterval := fs.Duration("image-gc-interval", 0, "interval between refreshing the image list and collecting images")
+	var dnsServers stringList
+	fs.Var(&dnsServers, "dns-server", "IP address of a name server of the containers, e.g. the manager's DNS server (repeatable)")
+	var dnsSearch stringList
+	fs.Var(&dnsSearch, "dns-search", "search domain of the containers, e.g. default.pentagon.local (repeatable)")
+	fs.Parse(args)
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	wc := &c.Worker
+	for flagName := range explicitFlags(fs) {
+		switch flagName {
+		case "name":
+			wc.Name = *name
+		case "address":
+			wc.Address = *address
+		case "port":
+			wc.Port = *port
+		case "run-interval":
+			wc.RunInterval = config.Duration{Duration: *runInterval}
+		case "stats-interval":
+			wc.StatsInterval = config.Duration{Duration: *statsInterval}
+		case "inspect-interval":
+			wc.InspectInterval = config.Duration{Duration: *inspectInterval}
+		case "tls-dir":
+			wc.TLS.Dir = *tlsDir
+		case "join":
+			wc.TLS.Join = *join
+		case "ca-hash":
+			wc.TLS.CAHash = *caHash
+		case "secrets-dir":
+			wc.SecretsDir = *secretsDir
+		case "configs-dir":
+			wc.ConfigsDir = *configsDir
+		case "pull-policy":
+			wc.PullPolicy = *pullPolicy
+		case "registry-auth-file":
+			wc.RegistryAuthFile = *registryAuthFile
+		case "image-gc-high":
+			wc.ImageGC.HighPercent = *imageGCHigh
+		case "image-gc-low":
+			wc.ImageGC.LowPercent = *imageGCLow
+		case "image-gc-min-age":
+			wc.ImageGC.MinAge = config.Duration{Duration: *imageGCMinAge}
+		case "image-gc-interval":
+			wc.ImageGC.Interval = config.Duration{Duration: *imageGCInterval}
+		case "dns-server":
+			wc.DNSServers = dnsServers
+		case "dns-search":
+			wc.DNSSearch = dnsSearch
+		case "taint":
+			wc.Taints = taints
+		case "label":
+			if wc.Labels, err = config.ParseLabels(nodeLabels); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := wc.Validate(); err != nil {
+		return err
+	}
+
+	w := worker.New(wc.Name)
+	w.RunInterval = wc.RunInterval.Duration
+	w.StatsInterval = wc.StatsInterval.Duration
+	w.InspectInterval = wc.InspectInterval.Duration
+	w.Labels = wc.Labels
+	w.SecretsDir = wc.SecretsDir
+	w.ConfigsDir = wc.ConfigsDir
+	w.PullPolicy = task.PullPolicy(wc.PullPolicy)
+	if wc.RegistryAuthFile != "" {
+		if w.Registries, err = docker.LoadAuthFile(wc.RegistryAuthFile); err != nil {
+			return err
+		}
+		log.Info().Msgf("Loaded the credentials of %d registries from %s", len(w.Registries), wc.RegistryAuthFile)
+	}
+	w.DNS = wc.DNSServers
+	w.DNSSearch = wc.DNSSearch
+	w.ImageGC = worker.ImageGC{
+		HighPercent: wc.ImageGC.HighPercent,
+		LowPercent:  wc.ImageGC.LowPercent,
+		MinAge:      wc.ImageGC.MinAge.Duration,
+		Interval:    wc.ImageGC.Interval.Duration,
+	}
+	if w.Taints, err = wc.ParseTaints(); err != nil {
+		return err
+	}
+
+	if wc.Token == "" {
+		log.Warn().Msg("No worker token set (PENTAGON_WORKER_TOKEN), the worker API accepts unauthenticated requests")
+	}
+
+	a := api.API{Address: wc.Address, Port: wc.Port, Worker: w, Token: wc.Token}
+	if wc.TLS.Dir != "" {
+		if wc.TLS.Join != "" && !pki.HasWorkerCert(wc.TLS.Dir) {
+			log.Info().Msgf("Joining manager %s", wc.TLS.Join)
+			if err := pki.Join(context.Background(), wc.TLS.Join, wc.Name, wc.TLS.CAHash, wc.TLS.JoinToken, wc.TLS.Dir); err != nil {
+				return err
+			}
+		}
+		if a.TLS, err = pki.WorkerTLS(wc.TLS.Dir); err != nil {
+			return err
+		}
+	} else {
+		log.Warn().Msg("TLS is disabled, the worker API is served in the clear")
+	}
+
+	log.Info().Msgf("Starting Pentagon worker %s on %s:%d", wc.Name, wc.Address, wc.Port)
+
+	go w.RunTasks()
+	go w.CollectStats()
+	go w.InspectTasks()
+	go w.ManageImages()
+
+	a.Start()
+
+	return nil
+}
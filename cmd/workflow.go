@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/workflow"
+)
+
+func init() {
+	register("workflow", "Manage task workflows (create, ls, inspect, rm)", runWorkflow)
+}
+
+func runWorkflow(args []string) error {
+	return runSubcommand("workflow", args, map[string]func([]string) error{
+		"create":  runWorkflowCreate,
+		"ls":      runWorkflowList,
+		"inspect": runWorkflowInspect,
+		"rm":      runWorkflowRemove,
+	})
+}
+
+func runWorkflowCreate(args []string) error {
+	fs := flag.NewFlagSet("workflow create", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	file := fs.String("f", "", "YAML or JSON file describing the workflow")
+	name := fs.String("name", "", "name of the workflow, overriding the file")
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if *file == "" {
+		return fmt.Errorf("a workflow file is required (-f)")
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	w := workflow.Workflow{}
+	if err := decodeFile(*file, &w); err != nil {
+		return err
+	}
+	if *name != "" {
+		w.Name = *name
+	}
+
+	created, err := cf.client().CreateWorkflow(ctx, w)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(created, func(out io.Writer) {
+		fmt.Fprintln(out, created.Name)
+	})
+}
+
+func runWorkflowList(args []string) error {
+	fs := flag.NewFlagSet("workflow ls", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	cf.allNamespaces(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	workflows, err := cf.client().GetWorkflows(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(workflows, func(out io.Writer) {
+		fmt.Fprintln(out, "NAMESPACE\tNAME\tSTATE\tSTEPS\tSUCCEEDED\tSTARTED\tCOMPLETED")
+		for _, w := range workflows {
+			succeeded := 0
+			for _, ss := range w.Status.Steps {
+				if ss.State == workflow.Succeeded {
+					succeeded++
+				}
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n", namespace.OrDefault(w.Namespace), w.Name, w.Status.State, len(w.Steps), succeeded, formatTime(w.Status.StartedAt), formatTime(w.Status.CompletedAt))
+		}
+	})
+}
+
+func runWorkflowInspect(args []string) error {
+	fs := flag.NewFlagSet("workflow inspect", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "workflow name")
+	if err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	w, err := cf.client().GetWorkflow(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return cf.print(w, func(out io.Writer) {
+		fmt.Fprintf(out, "Name:\t%s\n", w.Name)
+		fmt.Fprintf(out, "Namespace:\t%s\n", namespace.OrDefault(w.Namespace))
+		fmt.Fprintf(out, "State:\t%s\n", w.Status.State)
+		fmt.Fprintf(out, "Started:\t%s\n", formatTime(w.Status.StartedAt))
+		fmt.Fprintf(out, "Completed:\t%s\n", formatTime(w.Status.CompletedAt))
+		if w.Status.Message != "" {
+			fmt.Fprintf(out, "Message:\t%s\n", w.Status.Message)
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "STEP\tDEPENDS ON\tSTATE\tTASK\tEXIT CODE\tMESSAGE")
+		for _, s := range w.Steps {
+			ss := w.Status.Steps[s.Name]
+			taskID := "-"
+			if ss.TaskID != uuid.Nil {
+				taskID = ss.TaskID.String()
+			}
+			deps := strings.Join(s.DependsOn, ",")
+			if deps == "" {
+				deps = "-"
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%d\t%s\n", s.Name, deps, ss.State, taskID, ss.ExitCode, ss.Message)
+		}
+	})
+}
+
+func runWorkflowRemove(args []string) error {
+	fs := flag.NewFlagSet("workflow rm", flag.ExitOnError)
+	cf := newClientFlags(fs)
+	fs.Parse(args)
+	ctx := context.Background()
+
+	name, err := requireArg(fs, "workflow name")
+	if err != nil {
+		return err
+	}
+
+	if err := cf.client().DeleteWorkflow(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,538 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugoleodev/pentagon/ingress"
+	"github.com/hugoleodev/pentagon/internal/yaml"
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/manager"
+	"github.com/hugoleodev/pentagon/manager/dns"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/hugoleodev/pentagon/worker"
+)
+
+const EnvPrefix = "PENTAGON_"
+
+type Config struct {
+	Manager ManagerConfig `json:"manager"`
+	Worker  WorkerConfig  `json:"worker"`
+	Ingress IngressConfig `json:"ingress"`
+}
+
+type ManagerConfig struct {
+	Address         string      `json:"address"`
+	Port            int         `json:"port"`
+	Workers         []string    `json:"workers"`
+	Scheduler       string      `json:"scheduler"`
+	Store           StoreConfig `json:"store"`
+	ProcessInterval Duration    `json:"process_interval"`
+	UpdateInterval  Duration    `json:"update_interval"`
+	RequestTimeout  Duration    `json:"request_timeout"`
+
+	ReconcileInterval Duration `json:"reconcile_interval"`
+
+	// Auth configures who may call the manager API, and the credential the
+	// manager presents to workers.
+	Auth AuthConfig `json:"auth"`
+	// TLS enables TLS on the manager API and mutual TLS with workers.
+	TLS ManagerTLSConfig `json:"tls"`
+	// SecretsKeyFile holds the base64 encoded key secrets are encrypted
+	// with at rest. Secrets are disabled without one.
+	SecretsKeyFile string `json:"secrets_key_file"`
+	// PreferCachedImages has the scheduler favour the workers already
+	// holding the image of a task.
+	PreferCachedImages bool `json:"prefer_cached_images"`
+	// DNS serves the names of the running tasks.
+	DNS DNSConfig `json:"dns"`
+}
+
+// DNSConfig enables the DNS server of the manager when it sets a port.
+// Containers only query DNS servers on port 53.
+type DNSConfig struct {
+	Address string   `json:"address"`
+	Port    int      `json:"port"`
+	Domain  string   `json:"domain"`
+	TTL     Duration `json:"ttl"`
+	// Upstreams answer the names outside Domain, as IP or IP:port. Empty
+	// uses the name servers of the manager's host.
+	Upstreams []string `json:"upstreams"`
+}
+
+// ManagerTLSConfig enables TLS when it names a directory, where the
+// cluster CA and the manager certificate are kept, created on first start.
+type ManagerTLSConfig struct {
+	Dir string `json:"dir"`
+	// JoinToken lets workers obtain certificates from the CA.
+	JoinToken string `json:"join_token"`
+}
+
+// AuthConfig enables authentication of the manager API when it names a
+// tokens file or a JWT secret.
+type AuthConfig struct {
+	// TokensFile is a YAML or JSON list of static tokens with their roles.
+	TokensFile string `json:"tokens_file"`
+	// JWTSecret verifies HS256 JSON web tokens.
+	JWTSecret string `json:"jwt_secret"`
+	// WorkerToken is sent to workers, which must be given the same token.
+	WorkerToken string `json:"worker_token"`
+}
+
+// Enabled reports whether the manager API requires authentication.
+func (c AuthConfig) Enabled() bool {
+	return c.TokensFile != "" || c.JWTSecret != ""
+}
+
+type StoreConfig struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+type WorkerConfig struct {
+	Name          string   `json:"name"`
+	Address       string   `json:"address"`
+	Port          int      `json:"port"`
+	RunInterval   Duration `json:"run_interval"`
+	StatsInterval Duration `json:"stats_interval"`
+	// InspectInterval is how often running containers are checked for exits.
+	InspectInterval Duration `json:"inspect_interval"`
+	// Labels describe the worker's node, e.g. zone or disk type.
+	Labels map[string]string `json:"labels"`
+	// Taints dedicate the worker to tasks tolerating them, written as
+	// KEY=VALUE:EFFECT.
+	Taints []string `json:"taints"`
+	// Token is the credential the manager must present to the worker API.
+	Token string `json:"token"`
+	// TLS enables mutual TLS on the worker API.
+	TLS WorkerTLSConfig `json:"tls"`
+	// SecretsDir is where the secret files of tasks are written before
+	// being mounted in their containers. It should be on a tmpfs.
+	SecretsDir string `json:"secrets_dir"`
+	// ConfigsDir is where the config files of tasks are written before
+	// being mounted in their containers.
+	ConfigsDir string `json:"configs_dir"`
+	// PullPolicy is when images of tasks not setting one are pulled:
+	// always, if-not-present or never.
+	PullPolicy string `json:"pull_policy"`
+	// RegistryAuthFile is a Docker client config file, such as
+	// ~/.docker/config.json, holding the credentials of private
+	// registries.
+	RegistryAuthFile string `json:"registry_auth_file"`
+	// ImageGC is when unused images are removed.
+	ImageGC ImageGCConfig `json:"image_gc"`
+	// DNSServers and DNSSearch set the name servers and search domains of
+	// containers, such as the manager's DNS server and
+	// default.pentagon.local.
+	DNSServers []string `json:"dns_servers"`
+	DNSSearch  []string `json:"dns_search"`
+}
+
+// ImageGCConfig removes the unused images of a worker, least recently used
+// first, when its disk usage goes above HighPercent, until it falls to
+// LowPercent. Images used within MinAge are kept. A zero HighPercent
+// disables collection.
+type ImageGCConfig struct {
+	HighPercent int      `json:"high_percent"`
+	LowPercent  int      `json:"low_percent"`
+	MinAge      Duration `json:"min_age"`
+	Interval    Duration `json:"interval"`
+}
+
+// WorkerTLSConfig enables mutual TLS when it names a directory holding the
+// worker certificate. With Join set, a worker without one obtains it from
+// the manager.
+type WorkerTLSConfig struct {
+	Dir string `json:"dir"`
+	// Join is the address of the manager to obtain a certificate from.
+	Join string `json:"join"`
+	// CAHash pins the cluster CA the manager must present when joining.
+	CAHash    string `json:"ca_hash"`
+	JoinToken string `json:"join_token"`
+}
+
+type IngressConfig struct {
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	// RefreshInterval is how often the tasks of the routed services are
+	// fetched from the manager.
+	RefreshInterval Duration `json:"refresh_interval"`
+	// FailTimeout is how long a task a request could not reach is left
+	// out of the rotation.
+	FailTimeout Duration        `json:"fail_timeout"`
+	Routes      []ingress.Route `json:"routes"`
+}
+
+// Duration accepts either a Go duration string ("10s") or a number of seconds.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		d.Duration = time.Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		d.Duration = parsed
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+
+	return nil
+}
+
+func Default() *Config {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+
+	return &Config{
+		Manager: ManagerConfig{
+			Address:         "localhost",
+			Port:            8888,
+			Workers:         []string{"localhost:7777"},
+			Scheduler:       "roundrobin",
+			Store:           StoreConfig{Type: "memory", Path: "pentagon-data"},
+			ProcessInterval: Duration{manager.DefaultProcessInterval},
+			UpdateInterval:  Duration{manager.DefaultUpdateInterval},
+			RequestTimeout:  Duration{manager.DefaultRequestTimeout},
+
+			ReconcileInterval: Duration{manager.DefaultReconcileInterval},
+			DNS: DNSConfig{
+				Address: "0.0.0.0",
+				Domain:  dns.DefaultDomain,
+				TTL:     Duration{dns.DefaultTTL},
+			},
+		},
+		Worker: WorkerConfig{
+			Name:          hostname,
+			Address:       "localhost",
+			Port:          7777,
+			RunInterval:   Duration{worker.DefaultRunInterval},
+			StatsInterval: Duration{worker.DefaultStatsInterval},
+
+			InspectInterval: Duration{worker.DefaultInspectInterval},
+			SecretsDir:      worker.DefaultSecretsDir,
+			ConfigsDir:      worker.DefaultConfigsDir,
+			PullPolicy:      string(worker.DefaultPullPolicy),
+			ImageGC: ImageGCConfig{
+				HighPercent: worker.DefaultImageGCHighPercent,
+				LowPercent:  worker.DefaultImageGCLowPercent,
+				MinAge:      Duration{worker.DefaultImageGCMinAge},
+				Interval:    Duration{worker.DefaultImageGCInterval},
+			},
+		},
+		Ingress: IngressConfig{
+			Address:         "0.0.0.0",
+			Port:            8080,
+			RefreshInterval: Duration{ingress.DefaultRefreshInterval},
+			FailTimeout:     Duration{ingress.DefaultFailTimeout},
+		},
+	}
+}
+
+// Load returns the default configuration overlaid with the given file, if
+// any, and then with PENTAGON_* environment variables.
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".json":
+		err = json.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var err error
+
+	setString("MANAGER_ADDRESS", &c.Manager.Address)
+	setString("MANAGER_SCHEDULER", &c.Manager.Scheduler)
+	setString("MANAGER_STORE", &c.Manager.Store.Type)
+	setString("MANAGER_STORE_PATH", &c.Manager.Store.Path)
+	setString("MANAGER_TOKENS_FILE", &c.Manager.Auth.TokensFile)
+	setString("MANAGER_JWT_SECRET", &c.Manager.Auth.JWTSecret)
+	setString("MANAGER_WORKER_TOKEN", &c.Manager.Auth.WorkerToken)
+	setString("MANAGER_TLS_DIR", &c.Manager.TLS.Dir)
+	setString("MANAGER_JOIN_TOKEN", &c.Manager.TLS.JoinToken)
+	setString("MANAGER_SECRETS_KEY_FILE", &c.Manager.SecretsKeyFile)
+	setString("MANAGER_DNS_ADDRESS", &c.Manager.DNS.Address)
+	setString("MANAGER_DNS_DOMAIN", &c.Manager.DNS.Domain)
+	setString("INGRESS_ADDRESS", &c.Ingress.Address)
+	setString("WORKER_NAME", &c.Worker.Name)
+	setString("WORKER_ADDRESS", &c.Worker.Address)
+	setString("WORKER_TOKEN", &c.Worker.Token)
+	setString("WORKER_TLS_DIR", &c.Worker.TLS.Dir)
+	setString("WORKER_JOIN", &c.Worker.TLS.Join)
+	setString("WORKER_CA_HASH", &c.Worker.TLS.CAHash)
+	setString("WORKER_JOIN_TOKEN", &c.Worker.TLS.JoinToken)
+	setString("WORKER_SECRETS_DIR", &c.Worker.SecretsDir)
+	setString("WORKER_CONFIGS_DIR", &c.Worker.ConfigsDir)
+	setString("WORKER_REGISTRY_AUTH_FILE", &c.Worker.RegistryAuthFile)
+	setString("WORKER_PULL_POLICY", &c.Worker.PullPolicy)
+
+	if v, ok := lookup("MANAGER_WORKERS"); ok {
+		c.Manager.Workers = SplitList(v)
+	}
+	if v, ok := lookup("MANAGER_DNS_UPSTREAMS"); ok {
+		c.Manager.DNS.Upstreams = SplitList(v)
+	}
+	if v, ok := lookup("WORKER_DNS_SERVERS"); ok {
+		c.Worker.DNSServers = SplitList(v)
+	}
+	if v, ok := lookup("WORKER_DNS_SEARCH"); ok {
+		c.Worker.DNSSearch = SplitList(v)
+	}
+	if v, ok := lookup("WORKER_TAINTS"); ok {
+		c.Worker.Taints = SplitList(v)
+	}
+	if v, ok := lookup("WORKER_LABELS"); ok {
+		if c.Worker.Labels, err = ParseLabels(SplitList(v)); err != nil {
+			return fmt.Errorf("invalid %sWORKER_LABELS: %w", EnvPrefix, err)
+		}
+	}
+
+	if v, ok := lookup("MANAGER_PREFER_CACHED_IMAGES"); ok {
+		if c.Manager.PreferCachedImages, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("invalid %sMANAGER_PREFER_CACHED_IMAGES: %w", EnvPrefix, err)
+		}
+	}
+
+	for name, target := range map[string]*int{
+		"MANAGER_PORT":                 &c.Manager.Port,
+		"MANAGER_DNS_PORT":             &c.Manager.DNS.Port,
+		"INGRESS_PORT":                 &c.Ingress.Port,
+		"WORKER_PORT":                  &c.Worker.Port,
+		"WORKER_IMAGE_GC_HIGH_PERCENT": &c.Worker.ImageGC.HighPercent,
+		"WORKER_IMAGE_GC_LOW_PERCENT":  &c.Worker.ImageGC.LowPercent,
+	} {
+		if v, ok := lookup(name); ok {
+			if *target, err = strconv.Atoi(v); err != nil {
+				return fmt.Errorf("invalid %s%s: %w", EnvPrefix, name, err)
+			}
+		}
+	}
+
+	for name, target := range map[string]*Duration{
+		"MANAGER_PROCESS_INTERVAL":   &c.Manager.ProcessInterval,
+		"MANAGER_UPDATE_INTERVAL":    &c.Manager.UpdateInterval,
+		"MANAGER_REQUEST_TIMEOUT":    &c.Manager.RequestTimeout,
+		"MANAGER_RECONCILE_INTERVAL": &c.Manager.ReconcileInterval,
+		"MANAGER_DNS_TTL":            &c.Manager.DNS.TTL,
+		"INGRESS_REFRESH_INTERVAL":   &c.Ingress.RefreshInterval,
+		"INGRESS_FAIL_TIMEOUT":       &c.Ingress.FailTimeout,
+		"WORKER_RUN_INTERVAL":        &c.Worker.RunInterval,
+		"WORKER_STATS_INTERVAL":      &c.Worker.StatsInterval,
+		"WORKER_INSPECT_INTERVAL":    &c.Worker.InspectInterval,
+		"WORKER_IMAGE_GC_MIN_AGE":    &c.Worker.ImageGC.MinAge,
+		"WORKER_IMAGE_GC_INTERVAL":   &c.Worker.ImageGC.Interval,
+	} {
+		if v, ok := lookup(name); ok {
+			if target.Duration, err = time.ParseDuration(v); err != nil {
+				return fmt.Errorf("invalid %s%s: %w", EnvPrefix, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func lookup(name string) (string, bool) {
+	v, ok := os.LookupEnv(EnvPrefix + name)
+	if !ok || strings.TrimSpace(v) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(v), true
+}
+
+func setString(name string, target *string) {
+	if v, ok := lookup(name); ok {
+		*target = v
+	}
+}
+
+// SplitList splits a comma separated list, dropping empty entries.
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseLabels parses KEY=VALUE pairs into a label set.
+func ParseLabels(pairs []string) (map[string]string, error) {
+	l := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label %q, expected KEY=VALUE", pair)
+		}
+		l[k] = v
+	}
+	return l, nil
+}
+
+func (c ManagerConfig) Validate() error {
+	if len(c.Workers) == 0 {
+		return fmt.Errorf("manager requires at least one worker")
+	}
+	if c.Port <= 0 {
+		return fmt.Errorf("manager port must be positive")
+	}
+	if c.ProcessInterval.Duration <= 0 || c.UpdateInterval.Duration <= 0 || c.RequestTimeout.Duration <= 0 || c.ReconcileInterval.Duration <= 0 {
+		return fmt.Errorf("manager intervals and timeouts must be positive")
+	}
+	if c.DNS.Port < 0 || c.DNS.Port > 65535 {
+		return fmt.Errorf("manager dns port must be between 0 and 65535")
+	}
+	if c.DNS.Port > 0 && (strings.Trim(c.DNS.Domain, ".") == "" || c.DNS.TTL.Duration < time.Second) {
+		return fmt.Errorf("manager dns requires a domain and a ttl of at least 1s")
+	}
+	for _, upstream := range c.DNS.Upstreams {
+		host := upstream
+		if h, _, err := net.SplitHostPort(upstream); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("invalid dns upstream %q, expected an IP address or IP:port", upstream)
+		}
+	}
+	return nil
+}
+
+func (c IngressConfig) Validate() error {
+	if c.Port <= 0 {
+		return fmt.Errorf("ingress port must be positive")
+	}
+	if c.RefreshInterval.Duration <= 0 || c.FailTimeout.Duration <= 0 {
+		return fmt.Errorf("ingress intervals and timeouts must be positive")
+	}
+	if len(c.Routes) == 0 {
+		return fmt.Errorf("ingress requires at least one route")
+	}
+	for _, r := range c.Routes {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c WorkerConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("worker name is required")
+	}
+	if c.Port <= 0 {
+		return fmt.Errorf("worker port must be positive")
+	}
+	if c.RunInterval.Duration <= 0 || c.StatsInterval.Duration <= 0 || c.InspectInterval.Duration <= 0 {
+		return fmt.Errorf("worker intervals must be positive")
+	}
+	if c.SecretsDir == "" {
+		return fmt.Errorf("worker secrets directory is required")
+	}
+	if c.ConfigsDir == "" {
+		return fmt.Errorf("worker configs directory is required")
+	}
+	if c.PullPolicy == "" {
+		return fmt.Errorf("worker pull policy is required")
+	}
+	if err := task.ValidatePullPolicy(task.PullPolicy(c.PullPolicy)); err != nil {
+		return err
+	}
+	for _, server := range c.DNSServers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("invalid dns server %q, expected an IP address", server)
+		}
+	}
+	if err := c.ImageGC.Validate(); err != nil {
+		return err
+	}
+	if _, err := c.ParseTaints(); err != nil {
+		return err
+	}
+	if c.TLS.Join != "" && (c.TLS.Dir == "" || c.TLS.CAHash == "" || c.TLS.JoinToken == "") {
+		return fmt.Errorf("joining a manager requires a TLS directory, a CA hash and a join token")
+	}
+	return labels.Validate(c.Labels)
+}
+
+func (c ImageGCConfig) Validate() error {
+	if c.Interval.Duration <= 0 {
+		return fmt.Errorf("image gc interval must be positive")
+	}
+	if c.MinAge.Duration < 0 {
+		return fmt.Errorf("image gc minimum age must not be negative")
+	}
+	if c.HighPercent < 0 || c.HighPercent > 100 {
+		return fmt.Errorf("image gc high percent must be between 0 and 100")
+	}
+	if c.HighPercent > 0 && (c.LowPercent < 0 || c.LowPercent >= c.HighPercent) {
+		return fmt.Errorf("image gc low percent must be between 0 and the high percent")
+	}
+	return nil
+}
+
+func (c WorkerConfig) ParseTaints() ([]node.Taint, error) {
+	var taints []node.Taint
+	for _, s := range c.Taints {
+		t, err := node.ParseTaint(s)
+		if err != nil {
+			return nil, err
+		}
+		taints = append(taints, t)
+	}
+	return taints, nil
+}
@@ -0,0 +1,90 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxSize bounds the total size of the files of a config.
+const MaxSize = 1 << 20
+
+// Config is a named set of configuration files, such as an nginx.conf,
+// that tasks of its namespace mount in their containers. Every change
+// bumps its version; tasks referencing it with RestartOnChange are
+// restarted to pick the change up.
+type Config struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Data maps each key, the name of a file, to its content.
+	Data      map[string]string `json:"data"`
+	Version   int               `json:"version"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func (c *Config) Validate() error {
+	if err := ValidateName(c.Name); err != nil {
+		return err
+	}
+	return ValidateData(c.Data)
+}
+
+// ValidateData checks the files of a config. Keys become file names, so
+// they are restricted to alphanumerics, '-', '_' and '.'.
+func ValidateData(data map[string]string) error {
+	if len(data) == 0 {
+		return fmt.Errorf("a config requires at least one key")
+	}
+
+	size := 0
+	for k, v := range data {
+		if err := validateKey(k); err != nil {
+			return err
+		}
+		size += len(k) + len(v)
+	}
+	if size > MaxSize {
+		return fmt.Errorf("config files take %d bytes, more than the %d allowed", size, MaxSize)
+	}
+	return nil
+}
+
+// ValidateName checks a config name: at most 63 lowercase alphanumerics,
+// '-' or '.', starting and ending with an alphanumeric.
+func ValidateName(name string) error {
+	if name == "" || len(name) > 63 {
+		return fmt.Errorf("invalid config name %q", name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		alnum := c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+		if !alnum && (c != '-' && c != '.' || i == 0 || i == len(name)-1) {
+			return fmt.Errorf("invalid config name %q", name)
+		}
+	}
+	return nil
+}
+
+func validateKey(key string) error {
+	if key == "" || key == "." || key == ".." || len(key) > 253 {
+		return fmt.Errorf("invalid config key %q", key)
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return fmt.Errorf("invalid config key %q", key)
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys of the config, sorted.
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.Data))
+	for k := range c.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
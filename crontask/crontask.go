@@ -0,0 +1,200 @@
+package crontask
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/internal/cron"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+const (
+	// LabelName is set on every task created for a cron task.
+	LabelName = "pentagon.io/crontask"
+	// LabelID holds the ID of the cron task a task was created for.
+	LabelID = "pentagon.io/crontask-id"
+	// LabelScheduledTime records the schedule time a task was created for,
+	// as Unix seconds, so a time is never fired twice.
+	LabelScheduledTime = "pentagon.io/scheduled-time"
+)
+
+// Concurrency policies decide what happens when a run is due while the
+// previous one is still active.
+const (
+	Allow   = "allow"
+	Forbid  = "forbid"
+	Replace = "replace"
+)
+
+const (
+	DefaultSuccessfulHistoryLimit = 3
+	DefaultFailedHistoryLimit     = 1
+)
+
+// CronTask submits a task built from its template every time its cron
+// schedule fires.
+type CronTask struct {
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	Namespace         string    `json:"namespace,omitempty"`
+	Schedule          string    `json:"schedule"`
+	Timezone          string    `json:"timezone,omitempty"`
+	Template          task.Task `json:"template"`
+	ConcurrencyPolicy string    `json:"concurrency_policy"`
+	Suspend           bool      `json:"suspend"`
+	// StartingDeadlineSeconds bounds how late a missed run may still be
+	// started, for instance after the manager was down. Zero means no limit.
+	StartingDeadlineSeconds int       `json:"starting_deadline_seconds,omitempty"`
+	SuccessfulHistoryLimit  *int      `json:"successful_history_limit,omitempty"`
+	FailedHistoryLimit      *int      `json:"failed_history_limit,omitempty"`
+	Status                  Status    `json:"status"`
+	CreatedAt               time.Time `json:"created_at"`
+}
+
+type Status struct {
+	Active             []uuid.UUID `json:"active"`
+	LastScheduleTime   time.Time   `json:"last_schedule_time,omitempty"`
+	LastSuccessfulTime time.Time   `json:"last_successful_time,omitempty"`
+	NextScheduleTime   time.Time   `json:"next_schedule_time,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+func (c *CronTask) SetDefaults() {
+	if c.ConcurrencyPolicy == "" {
+		c.ConcurrencyPolicy = Allow
+	}
+	if c.SuccessfulHistoryLimit == nil {
+		limit := DefaultSuccessfulHistoryLimit
+		c.SuccessfulHistoryLimit = &limit
+	}
+	if c.FailedHistoryLimit == nil {
+		limit := DefaultFailedHistoryLimit
+		c.FailedHistoryLimit = &limit
+	}
+}
+
+func (c *CronTask) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("cron task name is required")
+	}
+	if c.Template.Image == "" {
+		return fmt.Errorf("cron task %s: template image is required", c.Name)
+	}
+	if err := c.Template.ValidateScheduling(); err != nil {
+		return fmt.Errorf("cron task %s: %w", c.Name, err)
+	}
+	if _, err := cron.Parse(c.Schedule); err != nil {
+		return fmt.Errorf("cron task %s: %w", c.Name, err)
+	}
+	if _, err := c.Location(); err != nil {
+		return fmt.Errorf("cron task %s: %w", c.Name, err)
+	}
+	switch c.ConcurrencyPolicy {
+	case Allow, Forbid, Replace:
+	default:
+		return fmt.Errorf("cron task %s: unknown concurrency policy %q", c.Name, c.ConcurrencyPolicy)
+	}
+	if c.StartingDeadlineSeconds < 0 {
+		return fmt.Errorf("cron task %s: starting_deadline_seconds must not be negative", c.Name)
+	}
+	if (c.SuccessfulHistoryLimit != nil && *c.SuccessfulHistoryLimit < 0) ||
+		(c.FailedHistoryLimit != nil && *c.FailedHistoryLimit < 0) {
+		return fmt.Errorf("cron task %s: history limits must not be negative", c.Name)
+	}
+	switch c.Template.RestartPolicy {
+	case "", "no", "on-failure":
+	default:
+		return fmt.Errorf("cron task %s: restart policy %q would prevent tasks from completing", c.Name, c.Template.RestartPolicy)
+	}
+	return nil
+}
+
+// Location returns the time zone the schedule is evaluated in, UTC by default.
+func (c *CronTask) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
+
+func (c *CronTask) schedule() (*cron.Schedule, *time.Location, error) {
+	s, err := cron.Parse(c.Schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := c.Location()
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, loc, nil
+}
+
+// Next returns the first schedule time after t.
+func (c *CronTask) Next(t time.Time) (time.Time, error) {
+	s, loc, err := c.schedule()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Next(t.In(loc)), nil
+}
+
+// Due returns the most recent schedule time in (after, now], if any.
+// Earlier missed times are skipped so that a long outage starts a single
+// run rather than one per missed time.
+func (c *CronTask) Due(after, now time.Time) (time.Time, bool, error) {
+	s, loc, err := c.schedule()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var due time.Time
+	for next := s.Next(after.In(loc)); !next.IsZero() && !next.After(now); next = s.Next(next) {
+		due = next
+	}
+	return due, !due.IsZero(), nil
+}
+
+// NewTask returns a fresh task built from the template for the given
+// schedule time.
+func (c *CronTask) NewTask(scheduled time.Time) *task.Task {
+	t := c.Template
+	t.ID = uuid.New()
+	t.State = task.Pending
+	t.ContainerID = ""
+	t.StartTime = time.Time{}
+	t.FinishTime = time.Time{}
+
+	if t.Name == "" {
+		t.Name = c.Name
+	}
+	t.Name = fmt.Sprintf("%s-%d", t.Name, scheduled.Unix())
+
+	t.Namespace = c.Namespace
+
+	t.Labels = make(map[string]string, len(c.Template.Labels)+3)
+	for k, v := range c.Template.Labels {
+		t.Labels[k] = v
+	}
+	t.Labels[LabelName] = c.Name
+	t.Labels[LabelID] = c.ID.String()
+	t.Labels[LabelScheduledTime] = strconv.FormatInt(scheduled.Unix(), 10)
+
+	return &t
+}
+
+func (c *CronTask) Owns(t *task.Task) bool {
+	return t.Labels[LabelID] == c.ID.String() && namespace.OrDefault(t.Namespace) == namespace.OrDefault(c.Namespace)
+}
+
+// ScheduledTime returns the schedule time a task was created for.
+func ScheduledTime(t *task.Task) time.Time {
+	secs, err := strconv.ParseInt(t.Labels[LabelScheduledTime], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
@@ -0,0 +1,116 @@
+package group
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+const (
+	// LabelName is set on every task created for a group.
+	LabelName = "pentagon.io/group"
+	// LabelID holds the ID of the group a task was created for.
+	LabelID = "pentagon.io/group-id"
+	// LabelMember holds the index of the member a task runs.
+	LabelMember = "pentagon.io/group-member"
+)
+
+// Group states.
+const (
+	Pending       = "pending"
+	Scheduled     = "scheduled"
+	Unschedulable = "unschedulable"
+)
+
+const DefaultTimeoutSeconds = 300
+
+// Group is a set of tasks scheduled as a gang: either every member is
+// placed on a node with room for it and dispatched, or none is. A group
+// that cannot be placed within its timeout is reported unschedulable and
+// its tasks fail.
+type Group struct {
+	ID             uuid.UUID   `json:"id"`
+	Name           string      `json:"name"`
+	Namespace      string      `json:"namespace,omitempty"`
+	Tasks          []task.Task `json:"tasks"`
+	TimeoutSeconds int         `json:"timeout_seconds,omitempty"`
+	Status         Status      `json:"status"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+type Status struct {
+	State       string    `json:"state"`
+	Message     string    `json:"message,omitempty"`
+	ScheduledAt time.Time `json:"scheduled_at,omitempty"`
+}
+
+func (g *Group) SetDefaults() {
+	if g.TimeoutSeconds == 0 {
+		g.TimeoutSeconds = DefaultTimeoutSeconds
+	}
+}
+
+func (g *Group) Validate() error {
+	if g.Name == "" {
+		return fmt.Errorf("group name is required")
+	}
+	if len(g.Tasks) == 0 {
+		return fmt.Errorf("group %s: at least one task is required", g.Name)
+	}
+	for i, t := range g.Tasks {
+		if t.Image == "" {
+			return fmt.Errorf("group %s: task %d: image is required", g.Name, i)
+		}
+		if err := t.ValidateScheduling(); err != nil {
+			return fmt.Errorf("group %s: task %d: %w", g.Name, i, err)
+		}
+	}
+	if g.TimeoutSeconds < 0 {
+		return fmt.Errorf("group %s: timeout_seconds must not be negative", g.Name)
+	}
+	return nil
+}
+
+// Deadline is the time after which a group still not placed is reported
+// unschedulable.
+func (g *Group) Deadline() time.Time {
+	return g.CreatedAt.Add(time.Duration(g.TimeoutSeconds) * time.Second)
+}
+
+// NewTasks returns fresh tasks for every member of the group.
+func (g *Group) NewTasks() []task.Task {
+	tasks := make([]task.Task, 0, len(g.Tasks))
+	for i, member := range g.Tasks {
+		t := member
+		t.ID = uuid.New()
+		t.State = task.Pending
+		t.ContainerID = ""
+		t.StartTime = time.Time{}
+		t.FinishTime = time.Time{}
+
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("%s-%d", g.Name, i)
+		}
+
+		t.Namespace = g.Namespace
+
+		t.Labels = make(map[string]string, len(member.Labels)+3)
+		for k, v := range member.Labels {
+			t.Labels[k] = v
+		}
+		t.Labels[LabelName] = g.Name
+		t.Labels[LabelID] = g.ID.String()
+		t.Labels[LabelMember] = strconv.Itoa(i)
+
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+func (g *Group) Owns(t *task.Task) bool {
+	return t.Labels[LabelID] == g.ID.String() && namespace.OrDefault(t.Namespace) == namespace.OrDefault(g.Namespace)
+}
@@ -0,0 +1,60 @@
+package image
+
+import (
+	"strings"
+	"time"
+)
+
+// Image is an image in the cache of a worker.
+type Image struct {
+	ID      string    `json:"id"`
+	Tags    []string  `json:"tags,omitempty"`
+	Digests []string  `json:"digests,omitempty"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+	// LastUsed is when a task last started with the image, or the image
+	// was pre-pulled. Images not used since the worker started count as
+	// used then.
+	LastUsed time.Time `json:"last_used"`
+	// InUse is set when a container, running or not, uses the image.
+	InUse bool `json:"in_use"`
+}
+
+// PullRequest asks for images to be pulled on the nodes matching a label
+// selector ahead of the tasks that need them.
+type PullRequest struct {
+	Images   []string `json:"images"`
+	Selector string   `json:"selector,omitempty"`
+	// Namespace and RegistryAuth name the secret holding the registry
+	// credentials, if the workers' own are not to be used.
+	Namespace    string `json:"namespace,omitempty"`
+	RegistryAuth string `json:"registry_auth,omitempty"`
+}
+
+// Pull is the outcome of pulling an image.
+type Pull struct {
+	Image    string   `json:"image"`
+	Progress []string `json:"progress,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// NodePull is the outcome of pulling images on a node.
+type NodePull struct {
+	Node  string `json:"node"`
+	Pulls []Pull `json:"pulls,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Normalize returns the reference Docker lists an image under: with the
+// latest tag when it has neither a tag nor a digest, and without the
+// docker.io registry and library namespace.
+func Normalize(ref string) string {
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	ref = strings.TrimPrefix(ref, "library/")
+
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if !strings.ContainsAny(name, ":@") {
+		ref += ":latest"
+	}
+	return ref
+}
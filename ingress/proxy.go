@@ -0,0 +1,274 @@
+// Package ingress routes external HTTP traffic to the running tasks of
+// services, through the host ports their workers publish.
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultRefreshInterval is how often the tasks of the routed services
+	// are fetched.
+	DefaultRefreshInterval = 5 * time.Second
+	// DefaultFailTimeout is how long a task a request could not reach is
+	// left out of the rotation.
+	DefaultFailTimeout = 10 * time.Second
+)
+
+// Proxy is a reverse proxy balancing the requests of each route across the
+// tasks of its service, in turn. Tasks failing a request are left out for
+// FailTimeout, unless no other task is left.
+type Proxy struct {
+	FailTimeout time.Duration
+
+	mu     sync.RWMutex
+	routes []*route
+	// failed records until when each backend address is left out.
+	failed map[string]time.Time
+}
+
+type route struct {
+	Route
+	backends []*backend
+	next     atomic.Uint64
+}
+
+type backend struct {
+	task    task.Endpoint
+	address string
+	proxy   *httputil.ReverseProxy
+}
+
+// New returns a proxy for the routes, which have no tasks to send requests
+// to until the first Update.
+func New(routes []Route) (*Proxy, error) {
+	p := &Proxy{
+		FailTimeout: DefaultFailTimeout,
+		failed:      map[string]time.Time{},
+	}
+
+	seen := map[string]bool{}
+	for _, r := range routes {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		r.Host = strings.ToLower(r.Host)
+		if seen[r.String()] {
+			return nil, fmt.Errorf("duplicate route %s", r)
+		}
+		seen[r.String()] = true
+		p.routes = append(p.routes, &route{Route: r})
+	}
+
+	// Routes naming a host come first, then the longest paths.
+	sort.SliceStable(p.routes, func(i, j int) bool {
+		a, b := p.routes[i], p.routes[j]
+		if (a.Host == "") != (b.Host == "") {
+			return a.Host != ""
+		}
+		return len(a.path()) > len(b.path())
+	})
+	return p, nil
+}
+
+// Namespaces returns the namespaces of the services the routes send
+// requests to.
+func (p *Proxy) Namespaces() []string {
+	seen := map[string]bool{}
+	var namespaces []string
+	for _, r := range p.routes {
+		if !seen[r.namespace()] {
+			seen[r.namespace()] = true
+			namespaces = append(namespaces, r.namespace())
+		}
+	}
+	return namespaces
+}
+
+// Update replaces the tasks of every route with the running tasks among
+// endpoints.
+func (p *Proxy) Update(endpoints []task.Endpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range p.routes {
+		current := map[string]*backend{}
+		for _, b := range r.backends {
+			current[b.address] = b
+		}
+
+		var backends []*backend
+		for _, e := range endpoints {
+			if !strings.EqualFold(e.Service, r.Service) || namespace.OrDefault(e.Namespace) != r.namespace() {
+				continue
+			}
+			hostPort, ok := routePort(r.Route, e)
+			if !ok {
+				continue
+			}
+			address := net.JoinHostPort(e.Host, hostPort)
+			if b, ok := current[address]; ok && b.task.TaskID == e.TaskID {
+				backends = append(backends, b)
+				continue
+			}
+			backends = append(backends, p.backend(e, address, r.Route))
+		}
+
+		// A stable order keeps the rotation fair across updates.
+		sort.Slice(backends, func(i, j int) bool {
+			return backends[i].address < backends[j].address
+		})
+		if !sameBackends(r.backends, backends) {
+			log.Info().Msgf("Route %s now sends requests to %d tasks of service %s", r, len(backends), r.Service)
+		}
+		r.backends = backends
+	}
+
+	for address, until := range p.failed {
+		if time.Now().After(until) {
+			delete(p.failed, address)
+		}
+	}
+}
+
+// Sync updates the proxy with the tasks fetch returns every interval. The
+// last tasks are kept while fetch fails.
+func (p *Proxy) Sync(fetch func() ([]task.Endpoint, error), interval time.Duration) {
+	for {
+		endpoints, err := fetch()
+		if err != nil {
+			log.Info().Msgf("Unable to fetch the tasks of the routed services: %v\n", err)
+		} else {
+			p.Update(endpoints)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r := p.match(req)
+	if r == nil {
+		http.Error(w, "no route matches the request", http.StatusNotFound)
+		return
+	}
+
+	b := p.pick(r)
+	if b == nil {
+		http.Error(w, fmt.Sprintf("service %s has no running task", r.Service), http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.StripPath && r.Path != "" {
+		req.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(req.URL.Path, r.Path), "/")
+		req.URL.RawPath = ""
+	}
+	b.proxy.ServeHTTP(w, req)
+}
+
+// match returns the first route applying to the request.
+func (p *Proxy) match(req *http.Request) *route {
+	host := strings.ToLower(req.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	for _, r := range p.routes {
+		if (r.Host == "" || r.Host == host) && r.matches(req.URL.Path) {
+			return r
+		}
+	}
+	return nil
+}
+
+// pick returns the next backend of the route in turn, skipping those that
+// recently failed unless they all did.
+func (p *Proxy) pick(r *route) *backend {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(r.backends) == 0 {
+		return nil
+	}
+
+	start := r.next.Add(1)
+	now := time.Now()
+	for i := 0; i < len(r.backends); i++ {
+		b := r.backends[(start+uint64(i))%uint64(len(r.backends))]
+		if until, ok := p.failed[b.address]; !ok || now.After(until) {
+			return b
+		}
+	}
+	return r.backends[start%uint64(len(r.backends))]
+}
+
+// backend returns a reverse proxy to the task at address.
+func (p *Proxy) backend(e task.Endpoint, address string, r Route) *backend {
+	target := &url.URL{Scheme: "http", Host: address}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		req.Header.Set("X-Forwarded-Host", req.Host)
+		if req.TLS != nil {
+			req.Header.Set("X-Forwarded-Proto", "https")
+		} else {
+			req.Header.Set("X-Forwarded-Proto", "http")
+		}
+		director(req)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		log.Info().Msgf("Error proxying %s %s to task %s at %s: %v\n", req.Method, req.URL.Path, e.TaskID, address, err)
+		p.markFailed(address)
+		http.Error(w, fmt.Sprintf("task of service %s unreachable", r.Service), http.StatusBadGateway)
+	}
+
+	return &backend{task: e, address: address, proxy: proxy}
+}
+
+func (p *Proxy) markFailed(address string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failed[address] = time.Now().Add(p.FailTimeout)
+}
+
+// routePort returns the host port the endpoint publishes the route's
+// container port on, or its only one when the route names none.
+func routePort(r Route, e task.Endpoint) (string, bool) {
+	if port := r.port(); port != "" {
+		hostPort, ok := e.Ports[port]
+		return hostPort, ok
+	}
+	if len(e.Ports) != 1 {
+		return "", false
+	}
+	for _, hostPort := range e.Ports {
+		return hostPort, true
+	}
+	return "", false
+}
+
+func sameBackends(a, b []*backend) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].address != b[i].address {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package ingress
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+// Route sends the requests for a host and path prefix to the running tasks
+// of a service.
+type Route struct {
+	// Host is matched against the Host header, without its port. Empty
+	// matches every host.
+	Host string `json:"host,omitempty"`
+	// Path is a prefix of the request path, / when empty.
+	Path      string `json:"path,omitempty"`
+	Service   string `json:"service"`
+	Namespace string `json:"namespace,omitempty"`
+	// Port is the container port of the tasks the requests are sent to,
+	// such as 8080/tcp. It may be left out when the tasks publish a single
+	// port.
+	Port string `json:"port,omitempty"`
+	// StripPath removes the path prefix from the requests sent to the
+	// tasks.
+	StripPath bool `json:"strip_path,omitempty"`
+}
+
+func (r Route) Validate() error {
+	if r.Service == "" {
+		return fmt.Errorf("route %s: service is required", r)
+	}
+	if r.Path != "" && !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("route %s: path must start with /", r)
+	}
+	if r.Port != "" {
+		if _, err := nat.NewPort(nat.SplitProtoPort(r.Port)); err != nil {
+			return fmt.Errorf("route %s: invalid port %q: %w", r, r.Port, err)
+		}
+	}
+	return nil
+}
+
+func (r Route) String() string {
+	host := r.Host
+	if host == "" {
+		host = "*"
+	}
+	return host + r.path()
+}
+
+// ParseRoute parses a route written HOST/PATH=SERVICE[:PORT], HOST and
+// PATH being optional, e.g. shop.example.com/api=api:8080.
+func ParseRoute(s string) (Route, error) {
+	match, target, ok := strings.Cut(s, "=")
+	if !ok {
+		return Route{}, fmt.Errorf("invalid route %q, expected HOST/PATH=SERVICE[:PORT]", s)
+	}
+
+	var r Route
+	if i := strings.Index(match, "/"); i >= 0 {
+		r.Host, r.Path = match[:i], match[i:]
+	} else {
+		r.Host = match
+	}
+	r.Service, r.Port, _ = strings.Cut(target, ":")
+
+	return r, r.Validate()
+}
+
+func (r Route) path() string {
+	if r.Path == "" {
+		return "/"
+	}
+	return r.Path
+}
+
+// port returns the container port of the route with its protocol, tcp
+// when left out.
+func (r Route) port() string {
+	if r.Port == "" {
+		return ""
+	}
+	p, _ := nat.NewPort(nat.SplitProtoPort(r.Port))
+	return string(p)
+}
+
+func (r Route) namespace() string {
+	return namespace.OrDefault(r.Namespace)
+}
+
+// matches reports whether the route applies to a request path, the prefix
+// ending at a path segment boundary.
+func (r Route) matches(path string) bool {
+	prefix := r.path()
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return strings.HasSuffix(prefix, "/") || len(path) == len(prefix) || path[len(prefix)] == '/'
+}
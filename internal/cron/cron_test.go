@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-01-01 00:00:30", "2024-01-01 00:01:00"},
+		{"0 * * * *", "2024-01-01 00:00:00", "2024-01-01 01:00:00"},
+		{"*/15 * * * *", "2024-01-01 00:07:00", "2024-01-01 00:15:00"},
+		{"5/20 * * * *", "2024-01-01 00:26:00", "2024-01-01 00:45:00"},
+		{"@daily", "2024-01-01 10:00:00", "2024-01-02 00:00:00"},
+		{"@HOURLY", "2024-01-01 10:59:00", "2024-01-01 11:00:00"},
+		{"30 9 * * mon-fri", "2024-01-06 12:00:00", "2024-01-08 09:30:00"},
+		{"0 0 * * 7", "2024-01-01 00:00:00", "2024-01-07 00:00:00"},
+		{"0 0 13 * 5", "2024-01-01 00:00:00", "2024-01-05 00:00:00"},
+		{"0 12 1 jan,jul *", "2024-02-01 00:00:00", "2024-07-01 12:00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"0 0 31 12 *", "2024-12-31 23:59:00", "2025-12-31 00:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+				t.Fatalf("next after %s is %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextImpossible(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("February 30th fired at %s", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"* * * *", "must have 5 fields, got 4"},
+		{"@weekdays", "must have 5 fields, got 1"},
+		{"60 * * * *", "outside 0-59"},
+		{"* 24 * * *", "outside 0-23"},
+		{"* * 0 * *", "outside 1-31"},
+		{"* * * 13 *", "outside 1-12"},
+		{"* * * * 8", "outside 0-6"},
+		{"5-1 * * * *", "outside 0-59"},
+		{"*/0 * * * *", "invalid step"},
+		{"* * * foo *", "invalid value \"foo\""},
+		{"1,,2 * * * *", "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
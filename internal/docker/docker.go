@@ -1,14 +1,17 @@
 package docker
 
 import (
+	"bytes"
 	"context"
-	"io"
+	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
@@ -41,7 +44,16 @@ type Config struct {
 	Memory        int64
 	Disk          int64
 	Env           []string
+	Labels        map[string]string
 	RestartPolicy string
+	Mounts        []mount.Mount
+	PullPolicy    task.PullPolicy
+	// RegistryAuth is the encoded credentials to pull the image with.
+	RegistryAuth string
+	// DNS and DNSSearch replace the name servers and search domains the
+	// container inherits from the Docker daemon.
+	DNS       []string
+	DNSSearch []string
 }
 
 func NewConfig(t *task.Task) *Config {
@@ -50,14 +62,19 @@ func NewConfig(t *task.Task) *Config {
 		t.Name = namesgenerator.GetRandomName(1)
 	}
 
+	// Several tasks may share a name (e.g. replicas), so the container name
+	// carries a short task ID to keep it unique on the host.
 	return &Config{
-		Name:          t.Name,
+		Name:          fmt.Sprintf("%s-%s", t.Name, t.ID.String()[:8]),
 		ExposedPorts:  t.ExposedPorts,
 		Image:         t.Image,
 		Cpu:           t.Cpu,
 		Memory:        t.Memory,
 		Disk:          t.Disk,
+		Env:           t.Env,
+		Labels:        t.Labels,
 		RestartPolicy: t.RestartPolicy,
+		PullPolicy:    t.ImagePullPolicy,
 	}
 }
 
@@ -71,19 +88,20 @@ type DockerResult struct {
 	Action      string
 	Error       error
 	Result      string
+	ExitCode    int
+	// Progress records the steps of the image pull of a run.
+	Progress []string
 }
 
 func (d *Docker) Run(ctx context.Context) DockerResult {
 
-	reader, err := d.Client.ImagePull(ctx, d.Config.Image, types.ImagePullOptions{})
+	progress, err := d.PullImage(ctx)
 
 	if err != nil {
 		log.Info().Msgf("Error pulling image %s: %v\n", d.Config.Image, err)
-		return DockerResult{Error: err}
+		return DockerResult{Error: err, Progress: progress}
 	}
 
-	io.Copy(os.Stdout, reader)
-
 	rp := container.RestartPolicy{
 		Name: d.Config.RestartPolicy,
 	}
@@ -97,6 +115,7 @@ func (d *Docker) Run(ctx context.Context) DockerResult {
 		Env:          d.Config.Env,
 		ExposedPorts: d.Config.ExposedPorts,
 		Image:        d.Config.Image,
+		Labels:       d.Config.Labels,
 		Tty:          false,
 	}
 
@@ -104,20 +123,23 @@ func (d *Docker) Run(ctx context.Context) DockerResult {
 		RestartPolicy:   rp,
 		Resources:       r,
 		PublishAllPorts: true,
+		Mounts:          d.Config.Mounts,
+		DNS:             d.Config.DNS,
+		DNSSearch:       d.Config.DNSSearch,
 	}
 
 	resp, err := d.Client.ContainerCreate(ctx, &cc, &hc, nil, nil, d.Config.Name)
 
 	if err != nil {
 		log.Info().Msgf("Error creating container %s using image %s: %v\n", d.Config.Name, d.Config.Image, err)
-		return DockerResult{Error: err}
+		return DockerResult{Error: err, Progress: progress}
 	}
 
 	err = d.Client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{})
 
 	if err != nil {
 		log.Info().Msgf("Error starting container %s with ID %s: %v\n", d.Config.Name, resp.ID, err)
-		return DockerResult{Error: err}
+		return DockerResult{Error: err, Progress: progress}
 	}
 
 	out, err := d.Client.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{
@@ -127,7 +149,7 @@ func (d *Docker) Run(ctx context.Context) DockerResult {
 
 	if err != nil {
 		log.Info().Msgf("Error getting logs for container %s with ID %s: %v\n", d.Config.Name, resp.ID, err)
-		return DockerResult{Error: err}
+		return DockerResult{Error: err, Progress: progress}
 	}
 
 	stdcopy.StdCopy(os.Stdout, os.Stderr, out)
@@ -137,6 +159,7 @@ func (d *Docker) Run(ctx context.Context) DockerResult {
 		Action:      "start",
 		Error:       nil,
 		Result:      DockerResultSuccess,
+		Progress:    progress,
 	}
 }
 
@@ -150,6 +173,11 @@ func (d *Docker) Stop(ctx context.Context, id string) DockerResult {
 		return DockerResult{Error: err}
 	}
 
+	exitCode := 0
+	if resp, err := d.Client.ContainerInspect(ctx, id); err == nil && resp.State != nil {
+		exitCode = resp.State.ExitCode
+	}
+
 	err = d.Client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{
 		RemoveVolumes: true,
 		Force:         false,
@@ -166,5 +194,48 @@ func (d *Docker) Stop(ctx context.Context, id string) DockerResult {
 		Action:      "stop",
 		Error:       nil,
 		Result:      DockerResultSuccess,
+		ExitCode:    exitCode,
+	}
+}
+
+// IsNotFound reports whether err means the container does not exist.
+func IsNotFound(err error) bool {
+	return client.IsErrNotFound(err)
+}
+
+func (d *Docker) Inspect(ctx context.Context, id string) (types.ContainerJSON, error) {
+	resp, err := d.Client.ContainerInspect(ctx, id)
+	if err != nil {
+		log.Info().Msgf("Error inspecting container %s: %v\n", id, err)
+		return types.ContainerJSON{}, err
 	}
+
+	return resp, nil
+}
+
+// Logs returns the stdout and stderr output of a container. A tail of zero
+// returns the whole log.
+func (d *Docker) Logs(ctx context.Context, id string, tail int) (string, error) {
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	}
+	if tail > 0 {
+		options.Tail = strconv.Itoa(tail)
+	}
+
+	out, err := d.Client.ContainerLogs(ctx, id, options)
+	if err != nil {
+		log.Info().Msgf("Error getting logs for container %s: %v\n", id, err)
+		return "", err
+	}
+	defer out.Close()
+
+	var buf bytes.Buffer
+	if _, err := stdcopy.StdCopy(&buf, &buf, out); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
 }
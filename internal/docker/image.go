@@ -0,0 +1,178 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// DefaultRegistry is the host of images named without one.
+const DefaultRegistry = "docker.io"
+
+// PullImage makes the image of the container available according to its
+// pull policy, an empty one meaning if-not-present, and returns the steps
+// of the pull.
+func (d *Docker) PullImage(ctx context.Context) ([]string, error) {
+	if d.Config.PullPolicy != task.PullAlways {
+		_, _, err := d.Client.ImageInspectWithRaw(ctx, d.Config.Image)
+		switch {
+		case err == nil:
+			return []string{fmt.Sprintf("Image %s is present, not pulling", d.Config.Image)}, nil
+		case !client.IsErrNotFound(err):
+			return nil, err
+		case d.Config.PullPolicy == task.PullNever:
+			return nil, fmt.Errorf("image %s is not present and the pull policy is %s", d.Config.Image, task.PullNever)
+		}
+	}
+
+	reader, err := d.Client.ImagePull(ctx, d.Config.Image, types.ImagePullOptions{
+		RegistryAuth: d.Config.RegistryAuth,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return readPullProgress(reader)
+}
+
+// ImageID returns the ID of the image a reference names.
+func (d *Docker) ImageID(ctx context.Context, ref string) (string, error) {
+	img, _, err := d.Client.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return img.ID, nil
+}
+
+// ListImages returns the images of the Docker host.
+func (d *Docker) ListImages(ctx context.Context) ([]types.ImageSummary, error) {
+	return d.Client.ImageList(ctx, types.ImageListOptions{})
+}
+
+// ImagesInUse returns the IDs of the images of every container of the
+// Docker host, running or not.
+func (d *Docker) ImagesInUse(ctx context.Context) (map[string]bool, error) {
+	containers, err := d.Client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]bool{}
+	for _, c := range containers {
+		ids[c.ImageID] = true
+	}
+	return ids, nil
+}
+
+// RemoveImage removes an image with every tag it has. Docker keeps the
+// images of running containers.
+func (d *Docker) RemoveImage(ctx context.Context, id string) error {
+	_, err := d.Client.ImageRemove(ctx, id, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+	return err
+}
+
+// readPullProgress reads the message stream of an image pull until it
+// ends, keeping its steps but not the byte counts of layer downloads.
+func readPullProgress(r io.Reader) ([]string, error) {
+	var progress []string
+	dec := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return progress, nil
+		} else if err != nil {
+			return progress, err
+		}
+
+		if msg.Error != nil {
+			return progress, msg.Error
+		}
+		if msg.ErrorMessage != "" {
+			return progress, fmt.Errorf("%s", msg.ErrorMessage)
+		}
+
+		switch msg.Status {
+		case "", "Pulling fs layer", "Waiting", "Downloading", "Extracting", "Verifying Checksum", "Download complete":
+			continue
+		}
+		if msg.ID != "" {
+			progress = append(progress, msg.ID+": "+msg.Status)
+		} else {
+			progress = append(progress, msg.Status)
+		}
+	}
+}
+
+// RegistryHost returns the registry an image is pulled from: its first
+// path component if that names a host, the default registry otherwise.
+func RegistryHost(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host
+	}
+	return DefaultRegistry
+}
+
+// EncodeAuth encodes registry credentials as the Docker API expects them.
+func EncodeAuth(auth registry.AuthConfig) (string, error) {
+	return registry.EncodeAuthConfig(auth)
+}
+
+// LoadAuthFile reads the registry credentials of a Docker client config
+// file, such as ~/.docker/config.json, keyed by registry host. Only the
+// credentials stored in the file are read; credential helpers are not run.
+func LoadAuthFile(path string) (map[string]registry.AuthConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Auths map[string]registry.AuthConfig `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid registry auth file %s: %w", path, err)
+	}
+
+	auths := map[string]registry.AuthConfig{}
+	for server, auth := range file.Auths {
+		if auth.Username == "" && auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth of registry %s in %s: %w", server, path, err)
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+			auth.Auth = ""
+		}
+
+		host := registryServerHost(server)
+		auth.ServerAddress = server
+		auths[host] = auth
+	}
+	return auths, nil
+}
+
+// registryServerHost returns the host of a server as written in a Docker
+// config file, where Docker Hub appears as https://index.docker.io/v1/.
+func registryServerHost(server string) string {
+	host := server
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		return DefaultRegistry
+	}
+	return host
+}
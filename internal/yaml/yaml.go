@@ -0,0 +1,508 @@
+// Package yaml decodes the block-style subset of YAML used by pentagon
+// configuration and manifest files. Documents are parsed into generic
+// values and then decoded through encoding/json, so targets use json tags.
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type line struct {
+	number  int
+	indent  int
+	content string
+}
+
+type parser struct {
+	lines []line
+	pos   int
+}
+
+// Unmarshal parses data and stores the result in the value pointed to by v.
+func Unmarshal(data []byte, v interface{}) error {
+	value, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// Parse parses the first document in data into maps, slices and scalars.
+func Parse(data []byte) (interface{}, error) {
+	p := &parser{}
+	if err := p.split(string(data)); err != nil {
+		return nil, err
+	}
+
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	value, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		return nil, fmt.Errorf("yaml: line %d: unexpected content %q", l.number, l.content)
+	}
+
+	return value, nil
+}
+
+func (p *parser) split(data string) error {
+	raw := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(raw); i++ {
+		text := raw[i]
+		trimmed := strings.TrimSpace(text)
+
+		if trimmed == "---" {
+			if len(p.lines) > 0 {
+				return nil
+			}
+			continue
+		}
+		if trimmed == "..." {
+			return nil
+		}
+
+		content := strings.TrimRight(stripComment(text), " \t")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		if strings.Contains(content[:len(content)-len(strings.TrimLeft(content, " \t"))], "\t") {
+			return fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+		}
+
+		indent := len(content) - len(strings.TrimLeft(content, " "))
+		l := line{number: i + 1, indent: indent, content: content[indent:]}
+
+		if style := blockScalarStyle(l.content); style != "" {
+			block, next := collectBlockScalar(raw, i+1, indent, style)
+			l.content = l.content[:len(l.content)-len(style)] + strconv.Quote(block)
+			i = next - 1
+		}
+
+		p.lines = append(p.lines, l)
+	}
+
+	return nil
+}
+
+// blockScalarStyle returns the block scalar indicator ending the line, if any.
+func blockScalarStyle(content string) string {
+	for _, style := range []string{"|-", "|", ">-", ">"} {
+		if strings.HasSuffix(content, ": "+style) || strings.HasSuffix(content, "- "+style) || content == style {
+			return style
+		}
+	}
+	return ""
+}
+
+func collectBlockScalar(raw []string, start int, parentIndent int, style string) (string, int) {
+	var lines []string
+	blockIndent := -1
+	i := start
+
+	for ; i < len(raw); i++ {
+		text := strings.TrimRight(raw[i], " \t")
+		if text == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		if indent <= parentIndent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = indent
+		}
+		if indent < blockIndent {
+			break
+		}
+		lines = append(lines, text[blockIndent:])
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		i--
+	}
+	for i > start && strings.TrimSpace(raw[i-1]) == "" {
+		i--
+	}
+
+	var value string
+	if strings.HasPrefix(style, ">") {
+		value = strings.Join(lines, " ")
+	} else {
+		value = strings.Join(lines, "\n")
+	}
+	if !strings.HasSuffix(style, "-") && len(lines) > 0 {
+		value += "\n"
+	}
+
+	return value, i
+}
+
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func (p *parser) parseBlock(indent int) (interface{}, error) {
+	l := p.lines[p.pos]
+	if isSequenceItem(l.content) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitKey(l.content); ok {
+		return p.parseMapping(indent)
+	}
+
+	p.pos++
+	return parseScalar(l.content, l.number)
+}
+
+func (p *parser) parseMapping(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.number)
+		}
+
+		key, rest, ok := splitKey(l.content)
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected a mapping key", l.number)
+		}
+		if _, exists := m[key]; exists {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", l.number, key)
+		}
+		p.pos++
+
+		value, err := p.parseValue(rest, indent, l.number)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+
+	return m, nil
+}
+
+func (p *parser) parseSequence(indent int) (interface{}, error) {
+	s := []interface{}{}
+
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || !isSequenceItem(l.content) {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.number)
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(l.content, "-"))
+		if rest == "" {
+			p.pos++
+			value, err := p.parseNested(indent, true, l.number)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, value)
+			continue
+		}
+
+		// An inline item is re-parsed as if it started its own block, so
+		// "- name: x" followed by deeper keys forms a single mapping.
+		itemIndent := l.indent + len(l.content) - len(rest)
+		p.lines[p.pos] = line{number: l.number, indent: itemIndent, content: rest}
+
+		value, err := p.parseBlock(itemIndent)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, value)
+	}
+
+	return s, nil
+}
+
+func (p *parser) parseValue(rest string, indent int, number int) (interface{}, error) {
+	if rest != "" {
+		return parseScalar(rest, number)
+	}
+	return p.parseNested(indent, false, number)
+}
+
+func (p *parser) parseNested(indent int, inSequence bool, number int) (interface{}, error) {
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+
+	next := p.lines[p.pos]
+	if next.indent > indent {
+		return p.parseBlock(next.indent)
+	}
+	// Sequences may sit at the same indentation as their parent key.
+	if !inSequence && next.indent == indent && isSequenceItem(next.content) {
+		return p.parseSequence(indent)
+	}
+
+	return nil, nil
+}
+
+func isSequenceItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+func splitKey(content string) (string, string, bool) {
+	if content == "" || content[0] == '[' || content[0] == '{' {
+		return "", "", false
+	}
+
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i == len(content)-1 || content[i+1] == ' '):
+			key := strings.TrimSpace(content[:i])
+			if unquoted, err := unquote(key); err == nil {
+				key = unquoted
+			}
+			return key, strings.TrimSpace(content[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+func unquote(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strconv.Unquote(s)
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return "", fmt.Errorf("%q is not quoted", s)
+}
+
+func parseScalar(s string, number int) (interface{}, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+		f := &flow{s: s, number: number}
+		value, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		if f.skipSpace(); f.pos != len(f.s) {
+			return nil, fmt.Errorf("yaml: line %d: unexpected content after flow collection", number)
+		}
+		return value, nil
+	}
+
+	if strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") {
+		value, err := unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: invalid quoted string %s", number, s)
+		}
+		return value, nil
+	}
+
+	return plainScalar(s), nil
+}
+
+func plainScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+
+	return s
+}
+
+// flow parses flow collections such as [a, b] and {key: value}.
+type flow struct {
+	s      string
+	pos    int
+	number int
+}
+
+func (f *flow) skipSpace() {
+	for f.pos < len(f.s) && (f.s[f.pos] == ' ' || f.s[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *flow) parse() (interface{}, error) {
+	f.skipSpace()
+	if f.pos >= len(f.s) {
+		return nil, fmt.Errorf("yaml: line %d: unterminated flow collection", f.number)
+	}
+
+	switch f.s[f.pos] {
+	case '[':
+		return f.parseSequence()
+	case '{':
+		return f.parseMapping()
+	}
+
+	return f.parseScalar()
+}
+
+func (f *flow) parseSequence() (interface{}, error) {
+	f.pos++
+	s := []interface{}{}
+
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.s) {
+			return nil, fmt.Errorf("yaml: line %d: unterminated flow sequence", f.number)
+		}
+		if f.s[f.pos] == ']' {
+			f.pos++
+			return s, nil
+		}
+
+		value, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, value)
+
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *flow) parseMapping() (interface{}, error) {
+	f.pos++
+	m := map[string]interface{}{}
+
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.s) {
+			return nil, fmt.Errorf("yaml: line %d: unterminated flow mapping", f.number)
+		}
+		if f.s[f.pos] == '}' {
+			f.pos++
+			return m, nil
+		}
+
+		key, err := f.parseScalar()
+		if err != nil {
+			return nil, err
+		}
+		f.skipSpace()
+		if f.pos >= len(f.s) || f.s[f.pos] != ':' {
+			return nil, fmt.Errorf("yaml: line %d: expected ':' in flow mapping", f.number)
+		}
+		f.pos++
+
+		value, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(key)] = value
+
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *flow) separator(end byte) error {
+	f.skipSpace()
+	if f.pos < len(f.s) && f.s[f.pos] == ',' {
+		f.pos++
+		return nil
+	}
+	if f.pos < len(f.s) && f.s[f.pos] == end {
+		return nil
+	}
+	return fmt.Errorf("yaml: line %d: expected ',' or '%c' in flow collection", f.number, end)
+}
+
+func (f *flow) parseScalar() (interface{}, error) {
+	f.skipSpace()
+	start := f.pos
+
+	if f.pos < len(f.s) && (f.s[f.pos] == '"' || f.s[f.pos] == '\'') {
+		quote := f.s[f.pos]
+		f.pos++
+		for f.pos < len(f.s) {
+			if f.s[f.pos] == '\\' && quote == '"' {
+				f.pos += 2
+				continue
+			}
+			if f.s[f.pos] == quote {
+				if quote == '\'' && f.pos+1 < len(f.s) && f.s[f.pos+1] == '\'' {
+					f.pos += 2
+					continue
+				}
+				f.pos++
+				return parseScalar(f.s[start:f.pos], f.number)
+			}
+			f.pos++
+		}
+		return nil, fmt.Errorf("yaml: line %d: unterminated quoted string", f.number)
+	}
+
+	for f.pos < len(f.s) && !strings.ContainsRune(",]}", rune(f.s[f.pos])) {
+		if f.s[f.pos] == ':' && (f.pos+1 == len(f.s) || f.s[f.pos+1] == ' ') {
+			break
+		}
+		f.pos++
+	}
+
+	return plainScalar(strings.TrimSpace(f.s[start:f.pos])), nil
+}
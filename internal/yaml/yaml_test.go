@@ -0,0 +1,91 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type m = map[string]interface{}
+type s = []interface{}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{"empty", "", nil},
+		{"comments only", "# nothing\n\n", nil},
+		{"scalars", "a: 1\nb: 1.5\nc: true\nd: ~\ne: text\n", m{"a": int64(1), "b": 1.5, "c": true, "d": nil, "e": "text"}},
+		{"quoted", "a: \"x: # y\"\nb: 'it''s'\n\"c d\": e\n", m{"a": "x: # y", "b": "it's", "c d": "e"}},
+		{"comment after value", "a: b # note\n", m{"a": "b"}},
+		{"nested mapping", "a:\n  b:\n    c: d\n", m{"a": m{"b": m{"c": "d"}}}},
+		{"sequence", "- a\n- b\n", s{"a", "b"}},
+		{"sequence at key indent", "a:\n- x\n- y\nb: z\n", m{"a": s{"x", "y"}, "b": "z"}},
+		{"sequence of mappings", "tasks:\n  - name: web\n    image: nginx\n  - name: db\n", m{"tasks": s{m{"name": "web", "image": "nginx"}, m{"name": "db"}}}},
+		{"empty item", "-\n  a: b\n", s{m{"a": "b"}}},
+		{"flow collections", "a: [1, \"b, c\", {d: e}]\nb: {}\n", m{"a": s{int64(1), "b, c", m{"d": "e"}}, "b": m{}}},
+		{"literal block", "a: |\n  one\n  two\nb: c\n", m{"a": "one\ntwo\n", "b": "c"}},
+		{"folded block", "a: >-\n  one\n  two\n", m{"a": "one two"}},
+		{"first document only", "a: b\n---\nc: d\n", m{"a": "b"}},
+		{"leading separator", "---\na: b\n...\nc: d\n", m{"a": "b"}},
+		{"windows line endings", "a: b\r\nc: d\r\n", m{"a": "b", "c": "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"tab indentation", "a:\n\tb: c\n", "line 2: tabs are not allowed"},
+		{"duplicate key", "a: 1\na: 2\n", "line 2: duplicate key \"a\""},
+		{"unexpected indentation", "a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"missing key", "a: 1\nb\n", "line 2: expected a mapping key"},
+		{"unterminated flow", "a: [1, 2,\n", "line 1: unterminated flow sequence"},
+		{"content after flow", "a: [1] 2\n", "line 1: unexpected content after flow collection"},
+		{"bad quoted string", "a: \"b\\q\"\n", "line 1: invalid quoted string"},
+		{"content after block", "- a\nb: c\n", "line 2: unexpected content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v struct {
+		Name    string            `json:"name"`
+		Port    int               `json:"port"`
+		Enabled bool              `json:"enabled"`
+		Tags    []string          `json:"tags"`
+		Env     map[string]string `json:"env"`
+	}
+	input := "name: web\nport: 8080\nenabled: true\ntags: [a, b]\nenv:\n  A: \"1\"\n"
+
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "web" || v.Port != 8080 || !v.Enabled || !reflect.DeepEqual(v.Tags, []string{"a", "b"}) || v.Env["A"] != "1" {
+		t.Fatalf("got %+v", v)
+	}
+}
@@ -0,0 +1,160 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+const (
+	// LabelName is set on every task created for a job.
+	LabelName = "pentagon.io/job"
+	// LabelID holds the ID of the job a task was created for.
+	LabelID = "pentagon.io/job-id"
+)
+
+const (
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+const (
+	DefaultBackoffLimit = 6
+	baseBackoff         = 10 * time.Second
+	maxBackoff          = 6 * time.Minute
+)
+
+// Job runs its task template to successful completion Completions times,
+// with at most Parallelism tasks active at once.
+type Job struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Template     task.Task `json:"template"`
+	Completions  int       `json:"completions"`
+	Parallelism  int       `json:"parallelism"`
+	BackoffLimit *int      `json:"backoff_limit,omitempty"`
+	Status       Status    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Status struct {
+	State       string    `json:"state"`
+	Active      int       `json:"active"`
+	Succeeded   int       `json:"succeeded"`
+	Failed      int       `json:"failed"`
+	NextRetryAt time.Time `json:"next_retry_at,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+}
+
+func (j *Job) SetDefaults() {
+	if j.Completions == 0 {
+		j.Completions = 1
+	}
+	if j.Parallelism == 0 {
+		j.Parallelism = 1
+	}
+	if j.BackoffLimit == nil {
+		limit := DefaultBackoffLimit
+		j.BackoffLimit = &limit
+	}
+}
+
+func (j *Job) Validate() error {
+	if j.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+	if j.Template.Image == "" {
+		return fmt.Errorf("job %s: template image is required", j.Name)
+	}
+	if err := j.Template.ValidateScheduling(); err != nil {
+		return fmt.Errorf("job %s: %w", j.Name, err)
+	}
+	if j.Completions < 1 || j.Parallelism < 1 {
+		return fmt.Errorf("job %s: completions and parallelism must be at least 1", j.Name)
+	}
+	if j.BackoffLimit != nil && *j.BackoffLimit < 0 {
+		return fmt.Errorf("job %s: backoff_limit must not be negative", j.Name)
+	}
+	switch j.Template.RestartPolicy {
+	case "", "no", "on-failure":
+	default:
+		return fmt.Errorf("job %s: restart policy %q would prevent tasks from completing", j.Name, j.Template.RestartPolicy)
+	}
+	return nil
+}
+
+func (j *Job) Finished() bool {
+	return j.Status.State == Succeeded || j.Status.State == Failed
+}
+
+// NewTask returns a fresh task built from the job template.
+func (j *Job) NewTask() *task.Task {
+	t := j.Template
+	t.ID = uuid.New()
+	t.State = task.Pending
+	t.ContainerID = ""
+	t.StartTime = time.Time{}
+	t.FinishTime = time.Time{}
+
+	if t.Name == "" {
+		t.Name = j.Name
+	}
+
+	t.Namespace = j.Namespace
+
+	t.Labels = make(map[string]string, len(j.Template.Labels)+2)
+	for k, v := range j.Template.Labels {
+		t.Labels[k] = v
+	}
+	t.Labels[LabelName] = j.Name
+	t.Labels[LabelID] = j.ID.String()
+
+	return &t
+}
+
+// Owns reports whether the task was created for the job, and not for an
+// earlier job of the same name.
+func (j *Job) Owns(t *task.Task) bool {
+	return t.Labels[LabelID] == j.ID.String() && namespace.OrDefault(t.Namespace) == namespace.OrDefault(j.Namespace)
+}
+
+// Backoff returns the delay before retrying after the given number of
+// failures, doubling from ten seconds up to six minutes.
+func Backoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	d := baseBackoff
+	for i := 1; i < failures; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// TaskSucceeded reports whether a finished task ran to completion successfully.
+// Only the exit its worker reported counts, not a stop by the manager.
+func TaskSucceeded(t *task.Task) bool {
+	return t.State == task.Completed && t.Exited && t.ExitCode == 0
+}
+
+// TaskFailed reports whether a task failed or exited with a non-zero code.
+func TaskFailed(t *task.Task) bool {
+	return t.State == task.Failed || (t.State == task.Completed && t.Exited && t.ExitCode != 0)
+}
+
+// TaskCancelled reports whether the manager stopped a task before it
+// exited, which counts neither as a success nor as a failure.
+func TaskCancelled(t *task.Task) bool {
+	return t.State == task.Completed && !t.Exited
+}
@@ -0,0 +1,272 @@
+// Package labels implements label selectors over the string maps used to
+// label tasks, nodes and other resources.
+//
+// A selector is a comma-separated list of requirements, all of which must
+// match:
+//
+//	team=payments          equality (also team==payments)
+//	tier!=frontend         inequality, also true when the label is absent
+//	env in (prod,staging)  set membership
+//	env notin (dev)        set exclusion, also true when the label is absent
+//	canary                 the label exists
+//	!canary                the label does not exist
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+func (r Requirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+
+	switch r.Operator {
+	case Equals, In:
+		return ok && contains(r.Values, v)
+	case NotEquals, NotIn:
+		return !ok || !contains(r.Values, v)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Equals, NotEquals:
+		return r.Key + string(r.Operator) + r.Values[0]
+	case In, NotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	case DoesNotExist:
+		return "!" + r.Key
+	}
+	return r.Key
+}
+
+// Selector matches label sets satisfying all of its requirements. The
+// empty selector matches everything.
+type Selector []Requirement
+
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// SelectorFromMap returns a selector requiring every label in m.
+func SelectorFromMap(m map[string]string) Selector {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s := make(Selector, 0, len(keys))
+	for _, k := range keys {
+		s = append(s, Requirement{Key: k, Operator: Equals, Values: []string{m[k]}})
+	}
+	return s
+}
+
+// Parse parses a selector expression.
+func Parse(expr string) (Selector, error) {
+	var s Selector
+	for _, part := range splitRequirements(expr) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, err := parseRequirement(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", expr, err)
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+// splitRequirements splits on commas that are not inside a value set.
+func splitRequirements(expr string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	if strings.HasPrefix(part, "!") {
+		key := strings.TrimSpace(part[1:])
+		return Requirement{Key: key, Operator: DoesNotExist}, ValidateKey(key)
+	}
+
+	if i := strings.Index(part, "("); i >= 0 {
+		if !strings.HasSuffix(part, ")") {
+			return Requirement{}, fmt.Errorf("missing closing parenthesis in %q", part)
+		}
+
+		fields := strings.Fields(part[:i])
+		if len(fields) != 2 {
+			return Requirement{}, fmt.Errorf("expected \"key in (values)\" or \"key notin (values)\", got %q", part)
+		}
+
+		r := Requirement{Key: fields[0], Operator: Operator(fields[1])}
+		if r.Operator != In && r.Operator != NotIn {
+			return Requirement{}, fmt.Errorf("unknown operator %q", fields[1])
+		}
+		for _, v := range strings.Split(part[i+1:len(part)-1], ",") {
+			v = strings.TrimSpace(v)
+			if err := ValidateValue(v); err != nil {
+				return Requirement{}, err
+			}
+			r.Values = append(r.Values, v)
+		}
+		return r, ValidateKey(r.Key)
+	}
+
+	for _, op := range []string{"!=", "==", "="} {
+		if key, value, ok := strings.Cut(part, op); ok {
+			r := Requirement{
+				Key:      strings.TrimSpace(key),
+				Operator: Equals,
+				Values:   []string{strings.TrimSpace(value)},
+			}
+			if op == "!=" {
+				r.Operator = NotEquals
+			}
+			if err := ValidateValue(r.Values[0]); err != nil {
+				return Requirement{}, err
+			}
+			return r, ValidateKey(r.Key)
+		}
+	}
+
+	return Requirement{Key: part, Operator: Exists}, ValidateKey(part)
+}
+
+// ValidateKey checks a label key: an optional DNS-style prefix and a slash,
+// followed by a name of at most 63 alphanumerics, '-', '_' or '.', starting
+// and ending with an alphanumeric.
+func ValidateKey(key string) error {
+	prefix, name, hasPrefix := strings.Cut(key, "/")
+	if !hasPrefix {
+		name, prefix = prefix, ""
+	}
+
+	if hasPrefix && (prefix == "" || len(prefix) > 253 || !validChars(prefix, "-.")) {
+		return fmt.Errorf("invalid label key %q: bad prefix", key)
+	}
+	if name == "" || len(name) > 63 || !validChars(name, "-_.") || !alnum(name[0]) || !alnum(name[len(name)-1]) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+// ValidateValue checks a label value: empty, or at most 63 alphanumerics,
+// '-', '_' or '.', starting and ending with an alphanumeric.
+func ValidateValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > 63 || !validChars(value, "-_.") || !alnum(value[0]) || !alnum(value[len(value)-1]) {
+		return fmt.Errorf("invalid label value %q", value)
+	}
+	return nil
+}
+
+// ReservedPrefix is the prefix of the labels set on the tasks pentagon
+// creates, which users may not set themselves.
+const ReservedPrefix = "pentagon.io/"
+
+// ValidateUnreserved checks that no key of a label set uses the reserved
+// prefix.
+func ValidateUnreserved(labels map[string]string) error {
+	for k := range labels {
+		if strings.HasPrefix(k, ReservedPrefix) {
+			return fmt.Errorf("label %q uses the reserved %s prefix", k, ReservedPrefix)
+		}
+	}
+	return nil
+}
+
+// Validate checks every key and value of a label set.
+func Validate(labels map[string]string) error {
+	for k, v := range labels {
+		if err := ValidateKey(k); err != nil {
+			return err
+		}
+		if err := ValidateValue(v); err != nil {
+			return fmt.Errorf("label %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func validChars(s string, extra string) bool {
+	for i := 0; i < len(s); i++ {
+		if !alnum(s[i]) && !strings.ContainsRune(extra, rune(s[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func alnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package labels
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "prod", "canary": ""}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"team=payments", true},
+		{"team==payments", true},
+		{"team=search", false},
+		{"team!=search", true},
+		{"tier!=frontend", true},
+		{"env in (prod, staging)", true},
+		{"env in (dev)", false},
+		{"env notin (dev,staging)", true},
+		{"env notin (prod)", false},
+		{"tier notin (frontend)", true},
+		{"canary", true},
+		{"tier", false},
+		{"!tier", true},
+		{"!canary", false},
+		{"team=payments,env in (prod,staging),!tier", true},
+		{"team=payments, env=dev", false},
+		{"example.com/owner", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Matches(labels); got != tt.want {
+				t.Fatalf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{"team = payments", "team=payments"},
+		{"team==payments", "team=payments"},
+		{"tier != frontend", "tier!=frontend"},
+		{"env in ( prod , staging )", "env in (prod,staging)"},
+		{"canary,! legacy", "canary,!legacy"},
+		{",team=payments,", "team=payments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.String(); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		err      string
+	}{
+		{"env in (prod", "missing closing parenthesis"},
+		{"env (prod)", "expected \"key in (values)\""},
+		{"env among (prod)", "unknown operator \"among\""},
+		{"env in (-prod)", "invalid label value \"-prod\""},
+		{"team=pay ments", "invalid label value"},
+		{"=payments", "invalid label key \"\""},
+		{"/owner", "bad prefix"},
+		{"!", "invalid label key"},
+		{strings.Repeat("k", 64), "invalid label key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			_, err := Parse(tt.selector)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSelectorFromMap(t *testing.T) {
+	s := SelectorFromMap(map[string]string{"team": "payments", "env": "prod"})
+	if got := s.String(); got != "env=prod,team=payments" {
+		t.Fatalf("got %q", got)
+	}
+	if !s.Matches(map[string]string{"team": "payments", "env": "prod", "tier": "web"}) {
+		t.Fatal("selector does not match a superset of its labels")
+	}
+	if s.Matches(map[string]string{"team": "payments"}) {
+		t.Fatal("selector matches a label set missing one of its labels")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		ok     bool
+	}{
+		{"plain", map[string]string{"team": "payments"}, true},
+		{"prefixed key", map[string]string{"example.com/team": "payments"}, true},
+		{"empty value", map[string]string{"canary": ""}, true},
+		{"key ends with dash", map[string]string{"team-": "payments"}, false},
+		{"value with slash", map[string]string{"team": "a/b"}, false},
+		{"long value", map[string]string{"team": strings.Repeat("v", 64)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.labels); (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok %t", err, tt.ok)
+			}
+		})
+	}
+}
@@ -1,14 +1,23 @@
 package api
 
 import (
+	"crypto/tls"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/client"
+	"github.com/hugoleodev/pentagon/labels"
 	"github.com/hugoleodev/pentagon/manager"
+	"github.com/hugoleodev/pentagon/manifest"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/pki"
 	"github.com/hugoleodev/pentagon/task"
 )
 
@@ -17,13 +26,100 @@ type API struct {
 	Port    int
 	Manager *manager.Manager
 	Router  fiber.Router
+	// Auth authenticates callers. Nil leaves the API open.
+	Auth *auth.Authenticator
+	// TLS, if set, is the config the API serves with.
+	TLS *tls.Config
+	// CA issues the certificates of workers presenting JoinToken.
+	CA        *pki.CA
+	JoinToken string
 }
 
 func (a *API) initRouter(app *fiber.App) {
-	a.Router = app.Group("/api/tasks")
-	a.Router.Post("/", a.StartTaskHandler)
-	a.Router.Get("/", a.GetTasksHandler)
-	a.Router.Delete("/:taskId", a.StopTaskHandler)
+	if a.CA != nil && a.JoinToken != "" {
+		app.Post(pki.JoinPath, auth.RequireToken(a.JoinToken), a.JoinHandler)
+	}
+
+	a.Router = app.Group("/api", auth.Middleware(a.Auth))
+	a.Router.Get("/whoami", a.WhoAmIHandler)
+
+	taskScope := a.objectScope("taskId", func(id string) (string, error) {
+		tID, err := uuid.Parse(id)
+		if err != nil {
+			return "", err
+		}
+		t, err := a.Manager.GetTask(tID.String())
+		if err != nil {
+			return "", err
+		}
+		return t.Namespace, nil
+	})
+	a.Router.Post("/tasks", a.StartTaskHandler)
+	a.Router.Get("/tasks", a.queryScope, a.GetTasksHandler)
+	a.Router.Get("/tasks/:taskId", taskScope, a.GetTaskHandler)
+	a.Router.Get("/tasks/:taskId/logs", taskScope, a.GetTaskLogsHandler)
+	a.Router.Delete("/tasks", a.queryScope, a.StopTasksHandler)
+	a.Router.Delete("/tasks/:taskId", taskScope, a.StopTaskHandler)
+
+	a.Router.Post("/apply", a.ApplyHandler)
+
+	a.Router.Post("/services", a.CreateServiceHandler)
+	a.Router.Get("/services", a.queryScope, a.GetServicesHandler)
+	a.Router.Get("/services/:name", a.namedScope, a.GetServiceHandler)
+	a.Router.Put("/services/:name", a.namedScope, a.UpdateServiceHandler)
+	a.Router.Post("/services/:name/scale", a.namedScope, a.ScaleServiceHandler)
+	a.Router.Post("/services/:name/rollback", a.namedScope, a.RollbackServiceHandler)
+	a.Router.Delete("/services/:name", a.namedScope, a.DeleteServiceHandler)
+
+	a.Router.Post("/jobs", a.CreateJobHandler)
+	a.Router.Get("/jobs", a.queryScope, a.GetJobsHandler)
+	a.Router.Get("/jobs/:name", a.namedScope, a.GetJobHandler)
+	a.Router.Delete("/jobs/:name", a.namedScope, a.DeleteJobHandler)
+
+	a.Router.Post("/crontasks", a.CreateCronTaskHandler)
+	a.Router.Get("/crontasks", a.queryScope, a.GetCronTasksHandler)
+	a.Router.Get("/crontasks/:name", a.namedScope, a.GetCronTaskHandler)
+	a.Router.Post("/crontasks/:name/suspend", a.namedScope, a.SuspendCronTaskHandler)
+	a.Router.Delete("/crontasks/:name", a.namedScope, a.DeleteCronTaskHandler)
+
+	a.Router.Post("/workflows", a.CreateWorkflowHandler)
+	a.Router.Get("/workflows", a.queryScope, a.GetWorkflowsHandler)
+	a.Router.Get("/workflows/:name", a.namedScope, a.GetWorkflowHandler)
+	a.Router.Delete("/workflows/:name", a.namedScope, a.DeleteWorkflowHandler)
+
+	a.Router.Post("/groups", a.CreateGroupHandler)
+	a.Router.Get("/groups", a.queryScope, a.GetGroupsHandler)
+	a.Router.Get("/groups/:name", a.namedScope, a.GetGroupHandler)
+	a.Router.Delete("/groups/:name", a.namedScope, a.DeleteGroupHandler)
+
+	a.Router.Post("/secrets", a.CreateSecretHandler)
+	a.Router.Get("/secrets", a.queryScope, a.GetSecretsHandler)
+	a.Router.Get("/secrets/:name", a.namedScope, a.GetSecretHandler)
+	a.Router.Put("/secrets/:name", a.namedScope, a.RotateSecretHandler)
+	a.Router.Delete("/secrets/:name", a.namedScope, a.DeleteSecretHandler)
+
+	a.Router.Post("/configs", a.CreateConfigHandler)
+	a.Router.Get("/configs", a.queryScope, a.GetConfigsHandler)
+	a.Router.Get("/configs/:name", a.namedScope, a.GetConfigHandler)
+	a.Router.Put("/configs/:name", a.namedScope, a.UpdateConfigHandler)
+	a.Router.Delete("/configs/:name", a.namedScope, a.DeleteConfigHandler)
+
+	namespaceScope := a.objectScope("name", func(name string) (string, error) {
+		return name, nil
+	})
+	a.Router.Post("/namespaces", auth.Require(auth.Admin), a.CreateNamespaceHandler)
+	a.Router.Get("/namespaces", a.GetNamespacesHandler)
+	a.Router.Get("/namespaces/:name", namespaceScope, a.GetNamespaceHandler)
+	a.Router.Put("/namespaces/:name/quota", auth.Require(auth.Admin), namespaceScope, a.SetQuotaHandler)
+	a.Router.Delete("/namespaces/:name", auth.Require(auth.Admin), namespaceScope, a.DeleteNamespaceHandler)
+
+	a.Router.Get("/events", a.queryScope, a.GetEventsHandler)
+	a.Router.Get("/endpoints", a.queryScope, a.GetEndpointsHandler)
+	a.Router.Get("/nodes", a.GetNodesHandler)
+	a.Router.Post("/nodes/:name/cordon", auth.Require(auth.Admin), a.CordonNodeHandler)
+	a.Router.Get("/nodes/:name/images", a.GetNodeImagesHandler)
+	a.Router.Post("/nodes/:name/images/gc", auth.Require(auth.Admin), a.CollectNodeImagesHandler)
+	a.Router.Post("/images/pull", auth.Require(auth.Admin), a.PullImagesHandler)
 }
 
 func (a *API) Start() {
@@ -33,7 +129,17 @@ func (a *API) Start() {
 
 	a.initRouter(app)
 
-	log.Fatal().Err(app.Listen(fmt.Sprintf("%s:%d", a.Address, a.Port)))
+	address := fmt.Sprintf("%s:%d", a.Address, a.Port)
+	if a.TLS == nil {
+		log.Fatal().Err(app.Listen(address)).Msgf("Manager API on %s stopped", address)
+		return
+	}
+
+	ln, err := tls.Listen("tcp", address, a.TLS)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unable to listen on %s", address)
+	}
+	log.Fatal().Err(app.Listener(ln)).Msgf("Manager API on %s stopped", address)
 }
 
 func (a *API) StartTaskHandler(ctx *fiber.Ctx) error {
@@ -46,14 +152,223 @@ func (a *API) StartTaskHandler(ctx *fiber.Ctx) error {
 		})
 	}
 
-	a.Manager.AddTask(te)
-	log.Info().Msgf("Added task %s\n", te.Task.ID)
+	// Only new tasks are submitted here, stops going through the DELETE
+	// routes, so the event can never reach a task the caller was not
+	// authorized for.
+	if te.State != task.Scheduled {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": fmt.Sprintf("task events must be in state %s, stop tasks with DELETE /tasks/:taskId", task.Scheduled),
+		})
+	}
+	te.ID = uuid.New()
+	te.Task.ID = uuid.New()
+	if te.Timestamp.IsZero() {
+		te.Timestamp = time.Now().UTC()
+	}
+	if err := validateMetadata(te.Task.Labels, te.Task.Annotations); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err := te.Task.ValidateScheduling(); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(te.Task.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.SubmitTask(te); err != nil {
+		return ctx.Status(submitStatus(err)).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	te.Task.Namespace = namespace.OrDefault(te.Task.Namespace)
+	log.Info().Msgf("Added task %s in namespace %s\n", te.Task.ID, te.Task.Namespace)
 
 	return ctx.Status(fiber.StatusCreated).JSON(te.Task)
 }
 
 func (a *API) GetTasksHandler(ctx *fiber.Ctx) error {
-	return ctx.Status(fiber.StatusOK).JSON(a.Manager.GetTasks())
+	var states []task.State
+	for _, name := range strings.Split(ctx.Query("state"), ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		s, err := task.ParseState(name)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		states = append(states, s)
+	}
+
+	sel, err := labels.Parse(ctx.Query("selector"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(a.Manager.GetTasksMatching(ctx.Query("namespace"), sel, states...))
+}
+
+func (a *API) GetTaskHandler(ctx *fiber.Ctx) error {
+	t, err := a.findTask(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "task not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(t)
+}
+
+func (a *API) GetTaskLogsHandler(ctx *fiber.Ctx) error {
+	t, err := a.findTask(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "task not found",
+		})
+	}
+
+	logs, err := a.Manager.GetTaskLogs(t, ctx.QueryInt("tail"))
+	if client.IsNotFound(err) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).SendString(logs)
+}
+
+func (a *API) GetEventsHandler(ctx *fiber.Ctx) error {
+	sel, err := labels.Parse(ctx.Query("selector"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	events := []*task.TaskEvent{}
+	for _, e := range a.Manager.GetEvents() {
+		if namespace.Matches(ctx.Query("namespace"), e.Task.Namespace) && sel.Matches(e.Task.Labels) {
+			events = append(events, e)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(events)
+}
+
+func (a *API) GetNodesHandler(ctx *fiber.Ctx) error {
+	sel, err := labels.Parse(ctx.Query("selector"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	nodes := []*node.Node{}
+	for _, n := range a.Manager.GetNodes() {
+		if sel.Matches(n.Labels) {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(nodes)
+}
+
+type CordonRequest struct {
+	Cordon bool `json:"cordon"`
+}
+
+func (a *API) CordonNodeHandler(ctx *fiber.Ctx) error {
+	req := CordonRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	n, err := a.Manager.CordonNode(ctx.Params("name"), req.Cordon)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(n)
+}
+
+func (a *API) ApplyHandler(ctx *fiber.Ctx) error {
+	mf := manifest.Manifest{}
+	if err := ctx.BodyParser(&mf); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(mf.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	result, err := a.Manager.Apply(mf, ctx.QueryBool("dry_run"), ctx.QueryBool("prune"))
+	if err != nil {
+		return ctx.Status(submitStatus(err)).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(result)
+}
+
+// validateMetadata checks label keys and values, and annotation keys.
+// Labels under the reserved prefix are refused, as they would let a task
+// pass for one created by a service, job or other controller.
+// Annotation values are free-form.
+func validateMetadata(l map[string]string, annotations map[string]string) error {
+	if err := labels.Validate(l); err != nil {
+		return err
+	}
+	if err := labels.ValidateUnreserved(l); err != nil {
+		return err
+	}
+	for k := range annotations {
+		if err := labels.ValidateKey(k); err != nil {
+			return fmt.Errorf("annotation: %w", err)
+		}
+	}
+	return nil
+}
+
+// findTask looks up the task named by the taskId parameter. A task outside
+// the namespace given in the query is not found.
+func (a *API) findTask(ctx *fiber.Ctx) (*task.Task, error) {
+	tID, err := uuid.Parse(ctx.Params("taskId"))
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := a.Manager.GetTask(tID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !namespace.Matches(ctx.Query("namespace"), t.Namespace) {
+		return nil, fmt.Errorf("task %s not found in namespace %s", t.ID, ctx.Query("namespace"))
+	}
+	return t, nil
 }
 
 func (a *API) StopTaskHandler(ctx *fiber.Ctx) error {
@@ -65,24 +380,14 @@ func (a *API) StopTaskHandler(ctx *fiber.Ctx) error {
 		})
 	}
 
-	tID, err := uuid.Parse(taskID)
-	taskToStop, ok := a.Manager.TaskDb[tID.String()]
-	if !ok || err != nil {
+	taskToStop, err := a.findTask(ctx)
+	if err != nil {
 		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "task not found",
 		})
 	}
 
-	te := task.TaskEvent{
-		ID:        uuid.New(),
-		State:     task.Completed,
-		Timestamp: time.Now(),
-	}
-
-	tTSCopy := *taskToStop
-	tTSCopy.State = task.Completed
-	te.Task = tTSCopy
-	a.Manager.AddTask(te)
+	a.Manager.StopTask(taskToStop)
 
 	log.Info().Msgf("Added task %v to stop container %v\n", taskToStop.ID, taskToStop.ContainerID)
 
@@ -92,3 +397,24 @@ func (a *API) StopTaskHandler(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusNoContent).JSON(responseMessage)
 }
+
+// StopTasksHandler stops every active task matching the required selector
+// query parameter and returns the tasks it stopped.
+func (a *API) StopTasksHandler(ctx *fiber.Ctx) error {
+	sel, err := labels.Parse(ctx.Query("selector"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if sel.Empty() {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "a selector is required to stop tasks in bulk",
+		})
+	}
+
+	stopped := a.Manager.StopTasks(ctx.Query("namespace"), sel)
+	log.Info().Msgf("Stopping %d tasks matching %s\n", len(stopped), sel)
+
+	return ctx.Status(fiber.StatusOK).JSON(stopped)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/configs"
+	"github.com/hugoleodev/pentagon/manager"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestStartTaskHandlerCrossNamespace(t *testing.T) {
+	m, err := manager.New(nil, "", "memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := m.AddNamespace(&namespace.Namespace{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	victim := task.Task{ID: uuid.New(), Name: "victim", Image: "nginx", Namespace: "b"}
+	if err := m.SubmitTask(task.TaskEvent{ID: uuid.New(), State: task.Scheduled, Task: victim}); err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := auth.New([]auth.Token{
+		{Token: "team-a", Name: "team-a", Role: auth.Operator, Namespaces: []string{"a"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &API{Manager: m, Auth: authenticator}
+	app := fiber.New()
+	a.initRouter(app)
+
+	tests := []struct {
+		name      string
+		state     task.State
+		namespace string
+		status    int
+	}{
+		{"stop through submit", task.Completed, "a", fiber.StatusBadRequest},
+		{"resubmit in own namespace", task.Scheduled, "a", fiber.StatusCreated},
+		{"resubmit in other namespace", task.Scheduled, "b", fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := task.TaskEvent{
+				State: tt.state,
+				Task:  task.Task{ID: victim.ID, Name: "attacker", Image: "busybox", Namespace: tt.namespace},
+			}
+			body, err := json.Marshal(te)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer team-a")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			if resp.StatusCode == fiber.StatusCreated {
+				created := task.Task{}
+				if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+					t.Fatal(err)
+				}
+				if created.ID == victim.ID {
+					t.Fatalf("submitted task kept the ID %s of an existing task", victim.ID)
+				}
+			}
+
+			stored, err := m.GetTask(victim.ID.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Namespace != "b" || stored.Name != "victim" || stored.State != task.Pending {
+				t.Fatalf("victim changed to %s/%s in state %s", stored.Namespace, stored.Name, stored.State)
+			}
+		})
+	}
+}
+
+func TestNamedObjectsPerNamespace(t *testing.T) {
+	m, err := manager.New(nil, "", "memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := m.AddNamespace(&namespace.Namespace{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+		c := &configs.Config{Name: "app", Namespace: name, Data: map[string]string{"ns": name}}
+		if err := m.AddConfig(c); err != nil {
+			t.Fatalf("adding config app to namespace %s: %v", name, err)
+		}
+	}
+	if err := m.AddConfig(&configs.Config{Name: "app", Namespace: "a", Data: map[string]string{"ns": "a"}}); err == nil {
+		t.Fatal("added config app to namespace a twice")
+	}
+
+	authenticator, err := auth.New([]auth.Token{
+		{Token: "team-a", Name: "team-a", Role: auth.Operator, Namespaces: []string{"a"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &API{Manager: m, Auth: authenticator}
+	app := fiber.New()
+	a.initRouter(app)
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		status int
+	}{
+		{"get in own namespace", http.MethodGet, "?namespace=a", fiber.StatusOK},
+		{"get in other namespace", http.MethodGet, "?namespace=b", fiber.StatusForbidden},
+		{"get in default namespace", http.MethodGet, "", fiber.StatusForbidden},
+		{"delete in other namespace", http.MethodDelete, "?namespace=b", fiber.StatusForbidden},
+		{"delete in own namespace", http.MethodDelete, "?namespace=a", fiber.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/configs/app"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer team-a")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			if resp.StatusCode == fiber.StatusOK {
+				c := configs.Config{}
+				if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+					t.Fatal(err)
+				}
+				if c.Namespace != "a" || c.Data["ns"] != "a" {
+					t.Fatalf("got config app of namespace %s", c.Namespace)
+				}
+			}
+		})
+	}
+
+	if _, err := m.GetConfig("b", "app"); err != nil {
+		t.Fatalf("config app of namespace b: %v", err)
+	}
+}
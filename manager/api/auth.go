@@ -0,0 +1,66 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/namespace"
+)
+
+// authorize returns an error unless the caller may act in namespace ns. An
+// empty ns stands for every namespace.
+func authorize(ctx *fiber.Ctx, ns string) error {
+	return auth.FromContext(ctx).AuthorizeNamespace(ns)
+}
+
+// queryScope lets a request through only if the caller may act in the
+// namespace given in its query, or across every namespace when none is.
+func (a *API) queryScope(ctx *fiber.Ctx) error {
+	if err := authorize(ctx, ctx.Query("namespace")); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return ctx.Next()
+}
+
+// objectScope returns a handler that lets a request for one object through
+// only if the caller may act in the object's namespace. lookup finds the
+// namespace of the object named by the route parameter; objects it cannot
+// find are left to the route's handler to report.
+func (a *API) objectScope(param string, lookup func(id string) (string, error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ns, err := lookup(ctx.Params(param))
+		if err != nil {
+			return ctx.Next()
+		}
+		if err := authorize(ctx, namespace.OrDefault(ns)); err != nil {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return ctx.Next()
+	}
+}
+
+// namedScope lets a request for a named object through only if the caller
+// may act in the namespace given in its query, the default one when none
+// is. Names are unique within a namespace, so the query also picks the
+// object.
+func (a *API) namedScope(ctx *fiber.Ctx) error {
+	if err := authorize(ctx, objectNamespace(ctx)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return ctx.Next()
+}
+
+// objectNamespace returns the namespace of the named object a request is for.
+func objectNamespace(ctx *fiber.Ctx) string {
+	return namespace.OrDefault(ctx.Query("namespace"))
+}
+
+// WhoAmIHandler returns the caller's principal.
+func (a *API) WhoAmIHandler(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(auth.FromContext(ctx))
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/configs"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *API) CreateConfigHandler(ctx *fiber.Ctx) error {
+	c := configs.Config{}
+	if err := ctx.BodyParser(&c); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(c.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddConfig(&c); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added config %s with keys %v\n", c.Name, c.Keys())
+
+	return ctx.Status(fiber.StatusCreated).JSON(c)
+}
+
+func (a *API) GetConfigsHandler(ctx *fiber.Ctx) error {
+	filtered := []*configs.Config{}
+	for _, c := range a.Manager.GetConfigs() {
+		if namespace.Matches(ctx.Query("namespace"), c.Namespace) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
+
+func (a *API) GetConfigHandler(ctx *fiber.Ctx) error {
+	c, err := a.Manager.GetConfig(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "config not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(c)
+}
+
+// UpdateConfigHandler replaces the files of a config with the data of the
+// body.
+func (a *API) UpdateConfigHandler(ctx *fiber.Ctx) error {
+	body := configs.Config{}
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if _, err := a.Manager.GetConfig(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "config not found",
+		})
+	}
+
+	c, err := a.Manager.UpdateConfig(objectNamespace(ctx), ctx.Params("name"), body.Data)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Updated config %s to version %d\n", c.Name, c.Version)
+
+	return ctx.Status(fiber.StatusOK).JSON(c)
+}
+
+func (a *API) DeleteConfigHandler(ctx *fiber.Ctx) error {
+	if _, err := a.Manager.GetConfig(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "config not found",
+		})
+	}
+
+	if err := a.Manager.DeleteConfig(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
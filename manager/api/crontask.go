@@ -0,0 +1,86 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/crontask"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/rs/zerolog/log"
+)
+
+type SuspendRequest struct {
+	Suspend bool `json:"suspend"`
+}
+
+func (a *API) CreateCronTaskHandler(ctx *fiber.Ctx) error {
+	c := crontask.CronTask{}
+	if err := ctx.BodyParser(&c); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(c.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddCronTask(&c); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added cron task %s with schedule %q\n", c.Name, c.Schedule)
+
+	return ctx.Status(fiber.StatusCreated).JSON(c)
+}
+
+func (a *API) GetCronTasksHandler(ctx *fiber.Ctx) error {
+	filtered := []*crontask.CronTask{}
+	for _, c := range a.Manager.GetCronTasks() {
+		if namespace.Matches(ctx.Query("namespace"), c.Namespace) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
+
+func (a *API) GetCronTaskHandler(ctx *fiber.Ctx) error {
+	c, err := a.Manager.GetCronTask(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "cron task not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(c)
+}
+
+func (a *API) SuspendCronTaskHandler(ctx *fiber.Ctx) error {
+	req := SuspendRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	c, err := a.Manager.SuspendCronTask(objectNamespace(ctx), ctx.Params("name"), req.Suspend)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "cron task not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(c)
+}
+
+func (a *API) DeleteCronTaskHandler(ctx *fiber.Ctx) error {
+	if err := a.Manager.DeleteCronTask(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "cron task not found",
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
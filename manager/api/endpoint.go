@@ -0,0 +1,28 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// GetEndpointsHandler lists where the running tasks of a namespace are
+// reached, optionally only those of the service named in the query.
+func (a *API) GetEndpointsHandler(ctx *fiber.Ctx) error {
+	svc := ctx.Query("service")
+
+	filtered := []task.Endpoint{}
+	for _, e := range a.Manager.GetEndpoints() {
+		if !namespace.Matches(ctx.Query("namespace"), e.Namespace) {
+			continue
+		}
+		if svc != "" && !strings.EqualFold(e.Service, svc) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
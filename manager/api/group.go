@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/group"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *API) CreateGroupHandler(ctx *fiber.Ctx) error {
+	g := group.Group{}
+	if err := ctx.BodyParser(&g); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(g.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddGroup(&g); err != nil {
+		return ctx.Status(submitStatus(err)).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added group %s with %d tasks\n", g.Name, len(g.Tasks))
+
+	return ctx.Status(fiber.StatusCreated).JSON(g)
+}
+
+func (a *API) GetGroupsHandler(ctx *fiber.Ctx) error {
+	filtered := []*group.Group{}
+	for _, g := range a.Manager.GetGroups() {
+		if namespace.Matches(ctx.Query("namespace"), g.Namespace) {
+			filtered = append(filtered, g)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
+
+func (a *API) GetGroupHandler(ctx *fiber.Ctx) error {
+	g, err := a.Manager.GetGroup(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "group not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(g)
+}
+
+func (a *API) DeleteGroupHandler(ctx *fiber.Ctx) error {
+	if err := a.Manager.DeleteGroup(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "group not found",
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
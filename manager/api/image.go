@@ -0,0 +1,70 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/image"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *API) GetNodeImagesHandler(ctx *fiber.Ctx) error {
+	if _, err := a.Manager.GetNode(ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	images, err := a.Manager.GetNodeImages(ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(images)
+}
+
+func (a *API) CollectNodeImagesHandler(ctx *fiber.Ctx) error {
+	if _, err := a.Manager.GetNode(ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	removed, err := a.Manager.CollectNodeImages(ctx.Params("name"), ctx.QueryBool("all"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Removed %d images from node %s\n", len(removed), ctx.Params("name"))
+
+	return ctx.Status(fiber.StatusOK).JSON(removed)
+}
+
+// PullImagesHandler pre-pulls images on the matching nodes, which only
+// admins may do, the pulls filling the disks of nodes shared by every
+// namespace.
+func (a *API) PullImagesHandler(ctx *fiber.Ctx) error {
+	req := image.PullRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(req.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	results, err := a.Manager.PullImages(req)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(results)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/job"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *API) CreateJobHandler(ctx *fiber.Ctx) error {
+	j := job.Job{}
+	if err := ctx.BodyParser(&j); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(j.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddJob(&j); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added job %s with %d completions\n", j.Name, j.Completions)
+
+	return ctx.Status(fiber.StatusCreated).JSON(j)
+}
+
+func (a *API) GetJobsHandler(ctx *fiber.Ctx) error {
+	filtered := []*job.Job{}
+	for _, j := range a.Manager.GetJobs() {
+		if namespace.Matches(ctx.Query("namespace"), j.Namespace) {
+			filtered = append(filtered, j)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
+
+func (a *API) GetJobHandler(ctx *fiber.Ctx) error {
+	j, err := a.Manager.GetJob(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "job not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(j)
+}
+
+func (a *API) DeleteJobHandler(ctx *fiber.Ctx) error {
+	if err := a.Manager.DeleteJob(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "job not found",
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/pki"
+	"github.com/rs/zerolog/log"
+)
+
+// JoinHandler signs the certificate request of a joining worker with the
+// cluster CA.
+func (a *API) JoinHandler(ctx *fiber.Ctx) error {
+	req := pki.JoinRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	cert, err := a.CA.SignRequest([]byte(req.CSR), ctx.IP())
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Issued a certificate to worker joining from %s\n", ctx.IP())
+
+	return ctx.Status(fiber.StatusOK).JSON(pki.JoinResponse{
+		Certificate: string(cert),
+		CA:          string(a.CA.CertPEM),
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *API) CreateNamespaceHandler(ctx *fiber.Ctx) error {
+	ns := namespace.Namespace{}
+	if err := ctx.BodyParser(&ns); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(ns.Name)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddNamespace(&ns); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added namespace %s\n", ns.Name)
+
+	return ctx.Status(fiber.StatusCreated).JSON(ns)
+}
+
+func (a *API) GetNamespacesHandler(ctx *fiber.Ctx) error {
+	namespaces := []*namespace.Namespace{}
+	for _, ns := range a.Manager.GetNamespaces() {
+		if authorize(ctx, ns.Name) == nil {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(namespaces)
+}
+
+func (a *API) GetNamespaceHandler(ctx *fiber.Ctx) error {
+	ns, err := a.Manager.GetNamespace(ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "namespace not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(ns)
+}
+
+func (a *API) SetQuotaHandler(ctx *fiber.Ctx) error {
+	q := namespace.Quota{}
+	if err := ctx.BodyParser(&q); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if _, err := a.Manager.GetNamespace(ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "namespace not found",
+		})
+	}
+
+	ns, err := a.Manager.SetQuota(ctx.Params("name"), q)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Set quota of namespace %s\n", ns.Name)
+
+	return ctx.Status(fiber.StatusOK).JSON(ns)
+}
+
+func (a *API) DeleteNamespaceHandler(ctx *fiber.Ctx) error {
+	if _, err := a.Manager.GetNamespace(ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "namespace not found",
+		})
+	}
+
+	if err := a.Manager.DeleteNamespace(ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// submitStatus is the status of a rejected submission: forbidden when it
+// would exceed a namespace quota, a bad request otherwise.
+func submitStatus(err error) int {
+	if errors.Is(err, namespace.ErrQuotaExceeded) {
+		return fiber.StatusForbidden
+	}
+	return fiber.StatusBadRequest
+}
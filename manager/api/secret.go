@@ -0,0 +1,101 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/secret"
+	"github.com/rs/zerolog/log"
+)
+
+// Secret handlers never return secret values: responses carry the names
+// of the keys alone.
+
+func (a *API) CreateSecretHandler(ctx *fiber.Ctx) error {
+	s := secret.Secret{}
+	if err := ctx.BodyParser(&s); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(s.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	s.Sealed = nil
+	if err := a.Manager.AddSecret(&s); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added secret %s with keys %v\n", s.Name, s.Keys)
+
+	return ctx.Status(fiber.StatusCreated).JSON(s.Redacted())
+}
+
+func (a *API) GetSecretsHandler(ctx *fiber.Ctx) error {
+	secrets := []*secret.Secret{}
+	for _, s := range a.Manager.GetSecrets() {
+		if namespace.Matches(ctx.Query("namespace"), s.Namespace) {
+			secrets = append(secrets, s.Redacted())
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(secrets)
+}
+
+func (a *API) GetSecretHandler(ctx *fiber.Ctx) error {
+	s, err := a.Manager.GetSecret(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "secret not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(s.Redacted())
+}
+
+// RotateSecretHandler replaces the values of a secret with the data of the
+// body.
+func (a *API) RotateSecretHandler(ctx *fiber.Ctx) error {
+	body := secret.Secret{}
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if _, err := a.Manager.GetSecret(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "secret not found",
+		})
+	}
+
+	s, err := a.Manager.RotateSecret(objectNamespace(ctx), ctx.Params("name"), body.Data)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Rotated secret %s to version %d\n", s.Name, s.Version)
+
+	return ctx.Status(fiber.StatusOK).JSON(s.Redacted())
+}
+
+func (a *API) DeleteSecretHandler(ctx *fiber.Ctx) error {
+	if _, err := a.Manager.GetSecret(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "secret not found",
+		})
+	}
+
+	if err := a.Manager.DeleteSecret(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/service"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+type ScaleRequest struct {
+	Replicas int `json:"replicas"`
+}
+
+type UpdateRequest struct {
+	Template     task.Task             `json:"template"`
+	UpdateConfig *service.UpdateConfig `json:"update_config,omitempty"`
+}
+
+func (a *API) CreateServiceHandler(ctx *fiber.Ctx) error {
+	s := service.Service{}
+	if err := ctx.BodyParser(&s); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(s.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddService(&s); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added service %s with %d replicas\n", s.Name, s.Replicas)
+
+	return ctx.Status(fiber.StatusCreated).JSON(s)
+}
+
+func (a *API) GetServicesHandler(ctx *fiber.Ctx) error {
+	filtered := []*service.Service{}
+	for _, s := range a.Manager.GetServices() {
+		if namespace.Matches(ctx.Query("namespace"), s.Namespace) {
+			filtered = append(filtered, s)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
+
+func (a *API) GetServiceHandler(ctx *fiber.Ctx) error {
+	s, err := a.Manager.GetService(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "service not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(s)
+}
+
+func (a *API) ScaleServiceHandler(ctx *fiber.Ctx) error {
+	req := ScaleRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if _, err := a.Manager.GetService(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "service not found",
+		})
+	}
+
+	s, err := a.Manager.ScaleService(objectNamespace(ctx), ctx.Params("name"), req.Replicas)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(s)
+}
+
+func (a *API) UpdateServiceHandler(ctx *fiber.Ctx) error {
+	req := UpdateRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if _, err := a.Manager.GetService(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "service not found",
+		})
+	}
+
+	s, err := a.Manager.UpdateService(objectNamespace(ctx), ctx.Params("name"), req.Template, req.UpdateConfig)
+	if err != nil {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Started update of service %s to version %d\n", s.Name, s.Version)
+
+	return ctx.Status(fiber.StatusAccepted).JSON(s)
+}
+
+func (a *API) RollbackServiceHandler(ctx *fiber.Ctx) error {
+	if _, err := a.Manager.GetService(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "service not found",
+		})
+	}
+
+	s, err := a.Manager.RollbackService(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(s)
+}
+
+func (a *API) DeleteServiceHandler(ctx *fiber.Ctx) error {
+	if err := a.Manager.DeleteService(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "service not found",
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
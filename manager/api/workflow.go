@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/workflow"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *API) CreateWorkflowHandler(ctx *fiber.Ctx) error {
+	w := workflow.Workflow{}
+	if err := ctx.BodyParser(&w); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := authorize(ctx, namespace.OrDefault(w.Namespace)); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := a.Manager.AddWorkflow(&w); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	log.Info().Msgf("Added workflow %s with %d steps\n", w.Name, len(w.Steps))
+
+	return ctx.Status(fiber.StatusCreated).JSON(w)
+}
+
+func (a *API) GetWorkflowsHandler(ctx *fiber.Ctx) error {
+	filtered := []*workflow.Workflow{}
+	for _, w := range a.Manager.GetWorkflows() {
+		if namespace.Matches(ctx.Query("namespace"), w.Namespace) {
+			filtered = append(filtered, w)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(filtered)
+}
+
+func (a *API) GetWorkflowHandler(ctx *fiber.Ctx) error {
+	w, err := a.Manager.GetWorkflow(objectNamespace(ctx), ctx.Params("name"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "workflow not found",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(w)
+}
+
+func (a *API) DeleteWorkflowHandler(ctx *fiber.Ctx) error {
+	if err := a.Manager.DeleteWorkflow(objectNamespace(ctx), ctx.Params("name")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "workflow not found",
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
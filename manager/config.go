@@ -0,0 +1,213 @@
+package manager
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/configs"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddConfig(c *configs.Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&c.Namespace); err != nil {
+		return err
+	}
+
+	if _, err := m.ConfigDb.Get(objectKey(c.Namespace, c.Name)); err == nil {
+		return fmt.Errorf("config %s already exists in namespace %s", c.Name, c.Namespace)
+	}
+
+	c.Version = 1
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = c.CreatedAt
+	return m.ConfigDb.Put(objectKey(c.Namespace, c.Name), c)
+}
+
+func (m *Manager) GetConfigs() []*configs.Config {
+	cs, err := m.ConfigDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of configs: %v\n", err)
+		return []*configs.Config{}
+	}
+	return cs
+}
+
+func (m *Manager) GetConfig(ns, name string) (*configs.Config, error) {
+	return m.ConfigDb.Get(objectKey(ns, name))
+}
+
+// UpdateConfig replaces the files of a config and restarts the tasks that
+// asked to be restarted when it changes. Other tasks keep the files they
+// were started with until they are next started.
+func (m *Manager) UpdateConfig(ns, name string, data map[string]string) (*configs.Config, error) {
+	if err := configs.ValidateData(data); err != nil {
+		return nil, err
+	}
+
+	c, err := m.ConfigDb.Get(objectKey(ns, name))
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *c
+	updated.Data = data
+	updated.Version++
+	updated.UpdatedAt = time.Now().UTC()
+	if err := m.ConfigDb.Put(objectKey(updated.Namespace, updated.Name), &updated); err != nil {
+		return nil, err
+	}
+
+	m.restartForConfig(&updated)
+	return &updated, nil
+}
+
+// DeleteConfig removes a config no active task references.
+func (m *Manager) DeleteConfig(ns, name string) error {
+	c, err := m.ConfigDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	for _, t := range m.GetActiveTasks() {
+		if namespace.OrDefault(t.Namespace) != c.Namespace {
+			continue
+		}
+		for _, ref := range t.Configs {
+			if ref.Config == name {
+				return fmt.Errorf("config %s is used by active task %s", name, t.ID)
+			}
+		}
+	}
+	return m.ConfigDb.Delete(objectKey(ns, name))
+}
+
+// checkReferences returns an error unless every secret and config the
+// tasks reference, registry secrets included, exists in namespace ns.
+func (m *Manager) checkReferences(ns string, tasks ...task.Task) error {
+	if err := m.checkSecrets(ns, tasks...); err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		if t.RegistryAuth != "" {
+			if _, err := m.lookupRegistrySecret(ns, t.RegistryAuth); err != nil {
+				return err
+			}
+		}
+		for _, ref := range t.Configs {
+			if _, err := m.lookupConfig(ns, ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) lookupConfig(ns string, ref task.ConfigRef) (*configs.Config, error) {
+	c, err := m.ConfigDb.Get(objectKey(ns, ref.Config))
+	if err != nil {
+		return nil, fmt.Errorf("config %s not found in namespace %s", ref.Config, namespace.OrDefault(ns))
+	}
+	if _, ok := c.Data[ref.Key]; ref.Key != "" && !ok {
+		return nil, fmt.Errorf("config %s has no key %s", ref.Config, ref.Key)
+	}
+	return c, nil
+}
+
+// resolveReferences prepares an event for the worker that will start its
+// task: it sets the values of the task's secrets, its registry credentials
+// and the files of its configs on the event, and records the config
+// versions on the task.
+func (m *Manager) resolveReferences(te *task.TaskEvent) error {
+	var err error
+	if te.Secrets, err = m.resolveSecrets(te.Task); err != nil {
+		return err
+	}
+	if te.RegistryAuth, err = m.resolveRegistryAuth(te.Task); err != nil {
+		return err
+	}
+
+	te.Configs = nil
+	te.Task.ConfigVersions = nil
+	for _, ref := range te.Task.Configs {
+		c, err := m.lookupConfig(te.Task.Namespace, ref)
+		if err != nil {
+			return err
+		}
+
+		if ref.Key != "" {
+			te.Configs = append(te.Configs, task.ConfigFile{Path: ref.Path, Content: c.Data[ref.Key]})
+		} else {
+			for _, k := range c.Keys() {
+				te.Configs = append(te.Configs, task.ConfigFile{Path: path.Join(ref.Path, k), Content: c.Data[k]})
+			}
+		}
+
+		if te.Task.ConfigVersions == nil {
+			te.Task.ConfigVersions = map[string]int{}
+		}
+		te.Task.ConfigVersions[c.Name] = c.Version
+	}
+	return nil
+}
+
+// restartForConfig restarts the tasks started with an older version of
+// the config that asked to be restarted when it changes. Services roll
+// their tasks over; tasks not owned by a controller or a group are
+// replaced under a new ID.
+func (m *Manager) restartForConfig(c *configs.Config) {
+	reason := fmt.Sprintf("config %s changed to version %d", c.Name, c.Version)
+
+	for _, s := range m.GetServices() {
+		if namespace.OrDefault(s.Namespace) != c.Namespace || !restartsOn(&s.Template, c.Name) {
+			continue
+		}
+		if s.Update.InProgress() {
+			log.Info().Msgf("Service %s already has an update %s, its new tasks get %s", s.Name, s.Update.State, reason)
+			continue
+		}
+
+		log.Info().Msgf("Rolling service %s over as %s", s.Name, reason)
+		m.startUpdate(s, s.Template, reason)
+		m.reconcileService(s)
+	}
+
+	for _, t := range m.GetTasksByState(task.Scheduled, task.Running) {
+		if namespace.OrDefault(t.Namespace) != c.Namespace || !restartsOn(t, c.Name) || controlled(t) {
+			continue
+		}
+		if v, ok := t.ConfigVersions[c.Name]; ok && v >= c.Version {
+			continue
+		}
+
+		log.Info().Msgf("Restarting task %s as %s", t.ID, reason)
+		m.stopTaskFor(t, reason)
+
+		replacement := renewTask(*t)
+		m.AddTask(task.TaskEvent{
+			ID:        uuid.New(),
+			State:     task.Scheduled,
+			Timestamp: time.Now().UTC(),
+			Task:      replacement,
+			Reason:    fmt.Sprintf("replaces task %s, %s", t.ID, reason),
+		})
+	}
+}
+
+// restartsOn reports whether the task asks to be restarted when the named
+// config changes.
+func restartsOn(t *task.Task, name string) bool {
+	for _, ref := range t.Configs {
+		if ref.Config == name && ref.RestartOnChange {
+			return true
+		}
+	}
+	return false
+}
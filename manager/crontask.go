@@ -0,0 +1,232 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/crontask"
+	"github.com/hugoleodev/pentagon/job"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddCronTask(c *crontask.CronTask) error {
+	c.SetDefaults()
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&c.Namespace); err != nil {
+		return err
+	}
+	if err := m.checkReferences(c.Namespace, c.Template); err != nil {
+		return err
+	}
+
+	if _, err := m.CronTaskDb.Get(objectKey(c.Namespace, c.Name)); err == nil {
+		return fmt.Errorf("cron task %s already exists in namespace %s", c.Name, c.Namespace)
+	}
+
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now().UTC()
+	c.Status = crontask.Status{Active: []uuid.UUID{}}
+	c.Status.NextScheduleTime, _ = c.Next(c.CreatedAt)
+
+	return m.CronTaskDb.Put(objectKey(c.Namespace, c.Name), c)
+}
+
+func (m *Manager) GetCronTasks() []*crontask.CronTask {
+	cronTasks, err := m.CronTaskDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of cron tasks: %v\n", err)
+		return []*crontask.CronTask{}
+	}
+	return cronTasks
+}
+
+func (m *Manager) GetCronTask(ns, name string) (*crontask.CronTask, error) {
+	return m.CronTaskDb.Get(objectKey(ns, name))
+}
+
+// SuspendCronTask stops or resumes scheduling new runs. Runs already
+// started are left alone.
+func (m *Manager) SuspendCronTask(ns, name string, suspend bool) (*crontask.CronTask, error) {
+	c, err := m.CronTaskDb.Get(objectKey(ns, name))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Suspend = suspend
+	if !suspend {
+		// Resuming must not fire the runs missed while suspended.
+		c.Status.LastScheduleTime = time.Now().UTC()
+		c.Status.NextScheduleTime, _ = c.Next(c.Status.LastScheduleTime)
+	}
+
+	return c, m.CronTaskDb.Put(objectKey(c.Namespace, c.Name), c)
+}
+
+// DeleteCronTask removes the cron task and stops any of its runs still active.
+func (m *Manager) DeleteCronTask(ns, name string) error {
+	c, err := m.CronTaskDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	if err := m.CronTaskDb.Delete(objectKey(ns, name)); err != nil {
+		return err
+	}
+
+	for _, t := range m.GetActiveTasks() {
+		if c.Owns(t) {
+			m.StopTask(t)
+		}
+	}
+	return nil
+}
+
+// reconcileCronTask records the state of previous runs, trims the run
+// history and starts a new run when the schedule is due. The schedule time
+// is persisted before the task is submitted, so a manager restart never
+// fires the same time twice.
+func (m *Manager) reconcileCronTask(c *crontask.CronTask, now time.Time) {
+	var active, succeeded, failed []*task.Task
+	fired := make(map[int64]bool)
+
+	for _, t := range m.GetTasks() {
+		if !c.Owns(t) {
+			continue
+		}
+		fired[crontask.ScheduledTime(t).Unix()] = true
+
+		switch {
+		case job.TaskSucceeded(t):
+			succeeded = append(succeeded, t)
+			if st := crontask.ScheduledTime(t); st.After(c.Status.LastSuccessfulTime) {
+				c.Status.LastSuccessfulTime = st
+			}
+		case job.TaskFailed(t), job.TaskCancelled(t):
+			failed = append(failed, t)
+		case t.State == task.Pending || t.State == task.Scheduled || t.State == task.Running:
+			active = append(active, t)
+		}
+	}
+
+	m.pruneHistory(succeeded, *c.SuccessfulHistoryLimit)
+	m.pruneHistory(failed, *c.FailedHistoryLimit)
+
+	c.Status.Active = make([]uuid.UUID, 0, len(active))
+	for _, t := range active {
+		c.Status.Active = append(c.Status.Active, t.ID)
+	}
+
+	if c.Suspend {
+		c.Status.NextScheduleTime = time.Time{}
+		m.CronTaskDb.Put(objectKey(c.Namespace, c.Name), c)
+		return
+	}
+
+	after := c.Status.LastScheduleTime
+	if after.IsZero() {
+		after = c.CreatedAt
+	}
+
+	due, ok, err := c.Due(after, now)
+	if err != nil {
+		c.Status.Message = err.Error()
+		m.CronTaskDb.Put(objectKey(c.Namespace, c.Name), c)
+		return
+	}
+
+	if ok {
+		c.Status.LastScheduleTime = due
+		deadline := time.Duration(c.StartingDeadlineSeconds) * time.Second
+
+		switch {
+		case fired[due.Unix()]:
+			// Already submitted before a restart, nothing to do.
+		case deadline > 0 && now.Sub(due) > deadline:
+			c.Status.Message = fmt.Sprintf("missed run at %s: starting deadline exceeded", due.Format(time.RFC3339))
+			log.Info().Msgf("Cron task %s %s", c.Name, c.Status.Message)
+		case len(active) > 0 && c.ConcurrencyPolicy == crontask.Forbid:
+			c.Status.Message = fmt.Sprintf("skipped run at %s: previous run still active", due.Format(time.RFC3339))
+			log.Info().Msgf("Cron task %s %s", c.Name, c.Status.Message)
+		default:
+			if c.ConcurrencyPolicy == crontask.Replace {
+				for _, t := range active {
+					log.Info().Msgf("Replacing task %s of cron task %s", t.ID, c.Name)
+					m.StopTask(t)
+				}
+				c.Status.Active = c.Status.Active[:0]
+			}
+
+			t := c.NewTask(due)
+			c.Status.Message = ""
+			c.Status.Active = append(c.Status.Active, t.ID)
+			if err := m.CronTaskDb.Put(objectKey(c.Namespace, c.Name), c); err != nil {
+				log.Info().Msgf("Error recording schedule of cron task %s: %v\n", c.Name, err)
+				return
+			}
+
+			log.Info().Msgf("Starting task %s for cron task %s scheduled at %s", t.ID, c.Name, due.Format(time.RFC3339))
+			err := m.SubmitTask(task.TaskEvent{
+				ID:        uuid.New(),
+				State:     task.Scheduled,
+				Timestamp: time.Now().UTC(),
+				Task:      *t,
+			})
+			if err != nil {
+				c.Status.Active = c.Status.Active[:len(c.Status.Active)-1]
+				c.Status.Message = fmt.Sprintf("missed run at %s: %v", due.Format(time.RFC3339), err)
+				log.Info().Msgf("Cron task %s %s", c.Name, c.Status.Message)
+			}
+		}
+	}
+
+	c.Status.NextScheduleTime, _ = c.Next(now)
+	m.CronTaskDb.Put(objectKey(c.Namespace, c.Name), c)
+}
+
+// pruneHistory deletes all but the limit most recently finished tasks.
+func (m *Manager) pruneHistory(tasks []*task.Task, limit int) {
+	if len(tasks) <= limit {
+		return
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].FinishTime.After(tasks[j].FinishTime)
+	})
+
+	for _, t := range tasks[limit:] {
+		log.Info().Msgf("Removing finished task %s from history", t.ID)
+		m.deleteTask(t.ID)
+	}
+}
+
+// deleteTask forgets a finished task.
+func (m *Manager) deleteTask(id uuid.UUID) {
+	m.TaskDb.Delete(id.String())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if w, ok := m.TaskWorkerMap[id]; ok {
+		ids := m.WorkerTaskMap[w]
+		for i, tid := range ids {
+			if tid == id {
+				m.WorkerTaskMap[w] = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		delete(m.TaskWorkerMap, id)
+	}
+	delete(m.evicted, id)
+}
+
+func (m *Manager) reconcileCronTasks() {
+	now := time.Now().UTC()
+	for _, c := range m.GetCronTasks() {
+		m.reconcileCronTask(c, now)
+	}
+}
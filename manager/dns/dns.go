@@ -0,0 +1,460 @@
+// Package dns serves the names of the running tasks, so tasks find each
+// other without knowing which worker the others run on.
+//
+// Under the domain, NAME.NAMESPACE resolves to the workers running the
+// tasks of that namespace whose name, service or ID is NAME, and
+// _PORT._PROTO.NAME.NAMESPACE to SRV records of the host ports the tasks'
+// container port PORT/PROTO is published on. The targets of SRV records
+// are the task IDs, TASK_ID.NAMESPACE. Answers are built from the
+// manager's state at each query, so they follow tasks as they move.
+// Queries for names outside the domain are forwarded to upstream servers,
+// so containers using the server still resolve every other name.
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugoleodev/pentagon/manager"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	DefaultDomain = "pentagon.local"
+	// DefaultTTL is short, as tasks move between workers.
+	DefaultTTL = 5 * time.Second
+
+	// maxUDPSize is the largest response sent over UDP. Larger ones are
+	// truncated, and clients retry over TCP.
+	maxUDPSize = 512
+	// lookupTimeout bounds resolving the host names of workers.
+	lookupTimeout = 2 * time.Second
+	tcpTimeout    = 10 * time.Second
+	// forwardTimeout bounds each upstream a query is forwarded to.
+	forwardTimeout = 2 * time.Second
+
+	resolvConf = "/etc/resolv.conf"
+)
+
+type Server struct {
+	Address string
+	Port    int
+	Domain  string
+	TTL     time.Duration
+	Manager *manager.Manager
+	// Upstreams answer the names outside the domain, as IP or IP:port.
+	// Empty uses the name servers of the manager's host.
+	Upstreams []string
+}
+
+// Start serves DNS over UDP and TCP on the server's address until either
+// listener fails.
+func (s *Server) Start() {
+	address := net.JoinHostPort(s.Address, strconv.Itoa(s.Port))
+
+	if len(s.Upstreams) == 0 {
+		s.Upstreams = s.hostUpstreams()
+	}
+	for i, upstream := range s.Upstreams {
+		if net.ParseIP(upstream) != nil {
+			s.Upstreams[i] = net.JoinHostPort(upstream, "53")
+		}
+	}
+	if len(s.Upstreams) == 0 {
+		log.Warn().Msgf("No upstream DNS servers, names outside %s are refused", s.domain())
+	}
+
+	pc, err := net.ListenPacket("udp", address)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unable to listen on %s/udp", address)
+	}
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unable to listen on %s/tcp", address)
+	}
+
+	log.Info().Msgf("Serving DNS for %s on %s, forwarding other names to %v", s.domain(), address, s.Upstreams)
+	go func() {
+		log.Fatal().Err(s.serveTCP(ln)).Msgf("DNS server on %s/tcp stopped", address)
+	}()
+	log.Fatal().Err(s.serveUDP(pc)).Msgf("DNS server on %s/udp stopped", address)
+}
+
+// hostUpstreams returns the name servers of the manager's host, leaving
+// out those that would be this server itself.
+func (s *Server) hostUpstreams() []string {
+	data, err := os.ReadFile(resolvConf)
+	if err != nil {
+		log.Info().Msgf("Unable to read %s: %v\n", resolvConf, err)
+		return nil
+	}
+
+	var upstreams []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		ip := net.ParseIP(fields[1])
+		if ip == nil {
+			continue
+		}
+		if s.Port == 53 && (ip.IsLoopback() || ip.Equal(net.ParseIP(s.Address))) {
+			continue
+		}
+		upstreams = append(upstreams, ip.String())
+	}
+	return upstreams
+}
+
+func (s *Server) serveUDP(pc net.PacketConn) error {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		query := append([]byte{}, buf[:n]...)
+		go func() {
+			resp, err := s.handle(query, false)
+			if err != nil {
+				log.Info().Msgf("Invalid DNS query from %s: %v\n", addr, err)
+				return
+			}
+			pc.WriteTo(resp, addr)
+		}()
+	}
+}
+
+func (s *Server) serveTCP(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn answers the queries of a TCP connection, each prefixed with
+// its length, until the client closes it or goes idle.
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		conn.SetDeadline(time.Now().Add(tcpTimeout))
+
+		var length uint16
+		if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+			return
+		}
+		query := make([]byte, length)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+
+		resp, err := s.handle(query, true)
+		if err != nil {
+			log.Info().Msgf("Invalid DNS query from %s: %v\n", conn.RemoteAddr(), err)
+			return
+		}
+		if err := binary.Write(conn, binary.BigEndian, uint16(len(resp))); err != nil {
+			return
+		}
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers a query received over UDP, or over TCP when tcp is set.
+func (s *Server) handle(query []byte, tcp bool) ([]byte, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	if h.Response {
+		return nil, errors.New("not a query")
+	}
+
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 h.ID,
+			Response:           true,
+			Authoritative:      true,
+			RecursionDesired:   h.RecursionDesired,
+			RecursionAvailable: false,
+		},
+	}
+
+	q, err := p.Question()
+	switch {
+	case err != nil:
+		resp.RCode = dnsmessage.RCodeFormatError
+	case h.OpCode != 0:
+		resp.RCode = dnsmessage.RCodeNotImplemented
+		resp.Questions = []dnsmessage.Question{q}
+	case !s.inDomain(q.Name) && len(s.Upstreams) > 0:
+		forwarded, err := s.forward(query, tcp)
+		if err == nil {
+			return forwarded, nil
+		}
+		log.Info().Msgf("Unable to forward the DNS query for %s: %v\n", q.Name, err)
+		resp.Authoritative = false
+		resp.RecursionAvailable = true
+		resp.RCode = dnsmessage.RCodeServerFailure
+		resp.Questions = []dnsmessage.Question{q}
+	default:
+		resp.Questions = []dnsmessage.Question{q}
+		s.answer(&resp, q)
+	}
+
+	size := maxUDPSize
+	if tcp {
+		size = 65535
+	}
+	packed, err := resp.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if len(packed) > size {
+		resp.Truncated = true
+		resp.Answers = nil
+		resp.Additionals = nil
+		return resp.Pack()
+	}
+	return packed, nil
+}
+
+// forward relays a query to the upstreams in turn and returns the first
+// response, over the transport the query came in on.
+func (s *Server) forward(query []byte, tcp bool) ([]byte, error) {
+	var err error
+	for _, upstream := range s.Upstreams {
+		var resp []byte
+		if resp, err = exchange(upstream, query, tcp); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+func exchange(upstream string, query []byte, tcp bool) ([]byte, error) {
+	network := "udp"
+	if tcp {
+		network = "tcp"
+	}
+	conn, err := net.DialTimeout(network, upstream, forwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(forwardTimeout))
+
+	if !tcp {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 65535)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		if n < 2 || buf[0] != query[0] || buf[1] != query[1] {
+			return nil, fmt.Errorf("mismatched response from %s", upstream)
+		}
+		return buf[:n], nil
+	}
+
+	if err := binary.Write(conn, binary.BigEndian, uint16(len(query))); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, length)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Server) inDomain(name dnsmessage.Name) bool {
+	return strings.HasSuffix(strings.ToLower(name.String()), "."+s.domain()+".")
+}
+
+// answer fills the records answering q, or the error code when the name
+// is unknown. Names outside the domain are refused when there is no
+// upstream to forward them to.
+func (s *Server) answer(resp *dnsmessage.Message, q dnsmessage.Question) {
+	name := strings.ToLower(q.Name.String())
+	suffix := "." + s.domain() + "."
+	if !s.inDomain(q.Name) {
+		resp.Authoritative = false
+		resp.RCode = dnsmessage.RCodeRefused
+		return
+	}
+	if q.Class != dnsmessage.ClassINET && q.Class != dnsmessage.ClassANY {
+		return
+	}
+
+	labels := strings.Split(strings.TrimSuffix(name, suffix), ".")
+	var port string
+	if len(labels) == 4 && strings.HasPrefix(labels[0], "_") && strings.HasPrefix(labels[1], "_") {
+		port = strings.TrimPrefix(labels[0], "_") + "/" + strings.TrimPrefix(labels[1], "_")
+		labels = labels[2:]
+	}
+	if len(labels) != 2 {
+		resp.RCode = dnsmessage.RCodeNameError
+		return
+	}
+
+	endpoints := s.lookup(labels[0], labels[1])
+	if len(endpoints) == 0 {
+		resp.RCode = dnsmessage.RCodeNameError
+		return
+	}
+
+	if port != "" {
+		if q.Type == dnsmessage.TypeSRV || q.Type == dnsmessage.TypeALL {
+			s.answerSRV(resp, q.Name, port, labels[1], endpoints)
+		}
+		return
+	}
+
+	switch q.Type {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA, dnsmessage.TypeALL:
+		resp.Answers = s.addressRecords(q.Name, q.Type, endpoints)
+	}
+}
+
+// answerSRV answers with a record per endpoint publishing port, and the
+// addresses of their targets as additional records.
+func (s *Server) answerSRV(resp *dnsmessage.Message, name dnsmessage.Name, port, ns string, endpoints []task.Endpoint) {
+	for _, e := range endpoints {
+		hostPort, err := strconv.ParseUint(e.Ports[port], 10, 16)
+		if err != nil {
+			continue
+		}
+		target, err := dnsmessage.NewName(fmt.Sprintf("%s.%s.%s.", e.TaskID, ns, s.domain()))
+		if err != nil {
+			continue
+		}
+
+		resp.Answers = append(resp.Answers, dnsmessage.Resource{
+			Header: s.header(name, dnsmessage.TypeSRV),
+			Body: &dnsmessage.SRVResource{
+				Priority: 0,
+				Weight:   1,
+				Port:     uint16(hostPort),
+				Target:   target,
+			},
+		})
+		resp.Additionals = append(resp.Additionals, s.addressRecords(target, dnsmessage.TypeALL, []task.Endpoint{e})...)
+	}
+}
+
+// addressRecords returns the A or AAAA records, or both for TypeALL, of
+// the hosts of the endpoints, each address once.
+func (s *Server) addressRecords(name dnsmessage.Name, qtype dnsmessage.Type, endpoints []task.Endpoint) []dnsmessage.Resource {
+	var records []dnsmessage.Resource
+	seen := map[string]bool{}
+	for _, e := range endpoints {
+		for _, ip := range resolveHost(e.Host) {
+			if seen[ip.String()] {
+				continue
+			}
+			seen[ip.String()] = true
+
+			if ip4 := ip.To4(); ip4 != nil {
+				if qtype == dnsmessage.TypeA || qtype == dnsmessage.TypeALL {
+					records = append(records, dnsmessage.Resource{
+						Header: s.header(name, dnsmessage.TypeA),
+						Body:   &dnsmessage.AResource{A: [4]byte(ip4)},
+					})
+				}
+			} else if qtype == dnsmessage.TypeAAAA || qtype == dnsmessage.TypeALL {
+				records = append(records, dnsmessage.Resource{
+					Header: s.header(name, dnsmessage.TypeAAAA),
+					Body:   &dnsmessage.AAAAResource{AAAA: [16]byte(ip.To16())},
+				})
+			}
+		}
+	}
+	return records
+}
+
+// lookup returns the endpoints of the tasks of a namespace whose name,
+// service or ID is name.
+func (s *Server) lookup(name, ns string) []task.Endpoint {
+	var matching []task.Endpoint
+	for _, e := range s.Manager.GetEndpoints() {
+		if !strings.EqualFold(namespace.OrDefault(e.Namespace), ns) {
+			continue
+		}
+		if strings.EqualFold(e.Service, name) || strings.EqualFold(e.Name, name) || e.TaskID.String() == name {
+			matching = append(matching, e)
+		}
+	}
+	return matching
+}
+
+func (s *Server) header(name dnsmessage.Name, rtype dnsmessage.Type) dnsmessage.ResourceHeader {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return dnsmessage.ResourceHeader{
+		Name:  name,
+		Type:  rtype,
+		Class: dnsmessage.ClassINET,
+		TTL:   uint32(ttl / time.Second),
+	}
+}
+
+func (s *Server) domain() string {
+	domain := strings.Trim(strings.ToLower(s.Domain), ".")
+	if domain == "" {
+		return DefaultDomain
+	}
+	return domain
+}
+
+// resolveHost returns the addresses of a worker host, which is either an
+// IP address or a name resolved by the manager's host.
+func resolveHost(host string) []net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		log.Info().Msgf("Unable to resolve worker host %s: %v\n", host, err)
+		return nil
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+	return ips
+}
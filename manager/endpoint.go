@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"net"
+
+	"github.com/hugoleodev/pentagon/service"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// GetEndpoints returns where the running tasks are reached, as last
+// reported by their workers. Tasks without a worker, and tasks with a
+// health check that have not passed it yet, are left out.
+func (m *Manager) GetEndpoints() []task.Endpoint {
+	endpoints := []task.Endpoint{}
+	for _, t := range m.GetTasksByState(task.Running) {
+		if !taskHealthy(t) {
+			continue
+		}
+		w, ok := m.taskWorker(t.ID)
+		if !ok {
+			continue
+		}
+		host, _, err := net.SplitHostPort(w)
+		if err != nil {
+			continue
+		}
+
+		e := task.Endpoint{
+			TaskID:    t.ID,
+			Name:      t.Name,
+			Namespace: t.Namespace,
+			Service:   t.Labels[service.LabelName],
+			Worker:    w,
+			Host:      host,
+			Ports:     map[string]string{},
+			Health:    t.Health,
+		}
+		for port, bindings := range t.HostPorts {
+			for _, b := range bindings {
+				if b.HostPort != "" {
+					e.Ports[string(port)] = b.HostPort
+					break
+				}
+			}
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
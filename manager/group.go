@@ -0,0 +1,276 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/client"
+	"github.com/hugoleodev/pentagon/group"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/scheduler"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddGroup(g *group.Group) error {
+	g.SetDefaults()
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&g.Namespace); err != nil {
+		return err
+	}
+	if err := m.checkReferences(g.Namespace, g.Tasks...); err != nil {
+		return err
+	}
+
+	if _, err := m.GroupDb.Get(objectKey(g.Namespace, g.Name)); err == nil {
+		return fmt.Errorf("group %s already exists in namespace %s", g.Name, g.Namespace)
+	}
+
+	g.ID = uuid.New()
+	g.CreatedAt = time.Now().UTC()
+	g.Status = group.Status{State: group.Pending}
+
+	m.quotaMu.Lock()
+	defer m.quotaMu.Unlock()
+
+	tasks := g.NewTasks()
+	if err := m.CheckQuota(g.Namespace, tasks, nil); err != nil {
+		return err
+	}
+
+	if err := m.GroupDb.Put(objectKey(g.Namespace, g.Name), g); err != nil {
+		return err
+	}
+
+	var events []task.TaskEvent
+	for _, t := range tasks {
+		t := t
+		m.TaskDb.Put(t.ID.String(), &t)
+		events = append(events, task.TaskEvent{
+			ID:        uuid.New(),
+			State:     task.Scheduled,
+			Timestamp: time.Now().UTC(),
+			Task:      t,
+		})
+	}
+	log.Info().Msgf("Queueing %d tasks of group %s", len(events), g.Name)
+	m.enqueueGang(events, time.Time{})
+
+	return nil
+}
+
+func (m *Manager) GetGroups() []*group.Group {
+	groups, err := m.GroupDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of groups: %v\n", err)
+		return []*group.Group{}
+	}
+	return groups
+}
+
+func (m *Manager) GetGroup(ns, name string) (*group.Group, error) {
+	return m.GroupDb.Get(objectKey(ns, name))
+}
+
+// DeleteGroup removes the group and stops any of its tasks still active.
+func (m *Manager) DeleteGroup(ns, name string) error {
+	g, err := m.GroupDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	if err := m.GroupDb.Delete(objectKey(ns, name)); err != nil {
+		return err
+	}
+
+	for _, t := range m.GetActiveTasks() {
+		if g.Owns(t) {
+			m.StopTask(t)
+		}
+	}
+	return nil
+}
+
+// sendGroup places the tasks of a group together and dispatches them. When
+// any of them does not fit, none is dispatched and the group waits for
+// capacity to change, until its deadline passes.
+func (m *Manager) sendGroup(name string, events []task.TaskEvent) {
+	g, err := m.GroupDb.Get(objectKey(events[0].Task.Namespace, name))
+	if err != nil {
+		log.Info().Msgf("Group %s no longer exists, dropping its tasks\n", name)
+		return
+	}
+	if !g.Owns(&events[0].Task) {
+		log.Info().Msgf("Group %s was recreated, dropping the tasks of the earlier one\n", name)
+		return
+	}
+
+	tasks := make([]task.Task, len(events))
+	for i := range events {
+		te := events[i]
+		m.EventDb.Put(te.ID.String(), &te)
+
+		persisted, err := m.TaskDb.Get(te.Task.ID.String())
+		if err == nil && (persisted.State == task.Completed || persisted.State == task.Failed) {
+			log.Info().Msgf("Task %s of group %s was stopped before being scheduled, dropping the group\n", te.Task.ID, name)
+			return
+		}
+		tasks[i] = te.Task
+	}
+
+	dispatched := make([]task.TaskEvent, len(events))
+	for i := range events {
+		dispatched[i] = events[i]
+		if err := m.resolveReferences(&dispatched[i]); err != nil {
+			m.failGroup(g, tasks, err.Error())
+			return
+		}
+		tasks[i].ConfigVersions = dispatched[i].Task.ConfigVersions
+	}
+
+	nodes := m.availableNodes()
+	m.fillNodeTasks(nodes)
+
+	placed, err := scheduler.PlaceGang(m.Scheduler, tasks, nodes)
+	if err != nil {
+		log.Info().Msgf("Error placing group %s: %v\n", name, err)
+
+		if time.Now().After(g.Deadline()) {
+			m.failGroup(g, tasks, err.Error())
+			return
+		}
+
+		reason := task.ReasonNoMatchingNode
+		var u *scheduler.Unschedulable
+		if errors.As(err, &u) {
+			reason = u.Reason
+		}
+		for _, t := range tasks {
+			m.markPending(t.ID, reason, fmt.Sprintf("group %s: %v", name, err))
+		}
+		g.Status.Message = err.Error()
+		m.GroupDb.Put(objectKey(g.Namespace, g.Name), g)
+
+		m.enqueueGang(events, time.Now().Add(m.UpdateInterval))
+		return
+	}
+
+	for i := range tasks {
+		t := tasks[i]
+		m.assignTask(placed[i].Api, t.ID)
+		if _, scheduled := m.modifyTask(t.ID, func(stored *task.Task) bool {
+			if stored.State != task.Pending && stored.State != task.Scheduled {
+				return false
+			}
+			stored.State = task.Scheduled
+			stored.PendingReason = ""
+			stored.PendingMessage = ""
+			stored.ConfigVersions = t.ConfigVersions
+			return true
+		}); !scheduled {
+			log.Info().Msgf("Task %s of group %s was stopped before being sent, dropping the group\n", t.ID, name)
+			for j := 0; j <= i; j++ {
+				m.unassignTask(placed[j].Api, tasks[j].ID)
+				if j < i {
+					m.markPending(tasks[j].ID, "", fmt.Sprintf("group %s: task %s was stopped", name, t.ID))
+				}
+			}
+			return
+		}
+	}
+
+	for i, te := range dispatched {
+		w := placed[i].Api
+
+		// Starts pull the image first, see SendWork.
+		ctx, cancel := context.WithTimeout(context.Background(), ImageRequestTimeout)
+		log.Info().Msgf("Sending task %s of group %s to worker %v", te.Task.ID, name, w)
+		started, err := m.WorkerClients[w].StartTask(ctx, te)
+		cancel()
+
+		if err != nil {
+			log.Info().Msgf("Error sending task %s of group %s to worker %v: %v\n", te.Task.ID, name, w, err)
+			m.rollbackGroup(g, events, placed, i, err)
+			return
+		}
+		m.recordStart(started)
+	}
+
+	log.Info().Msgf("Dispatched all %d tasks of group %s", len(events), name)
+	g.Status = group.Status{
+		State:       group.Scheduled,
+		Message:     fmt.Sprintf("all %d tasks dispatched", len(events)),
+		ScheduledAt: time.Now().UTC(),
+	}
+	m.GroupDb.Put(objectKey(g.Namespace, g.Name), g)
+}
+
+// rollbackGroup undoes a dispatch that failed at the task of index failed.
+// The tasks already started are stopped and replaced by new ones, the
+// others go back to pending, and the group is queued again as a whole.
+func (m *Manager) rollbackGroup(g *group.Group, events []task.TaskEvent, placed []*node.Node, failed int, cause error) {
+	message := fmt.Sprintf("group %s: dispatch to node %s failed: %v", g.Name, placed[failed].Name, cause)
+	reason := ""
+	var apiErr *client.Error
+	if errors.As(cause, &apiErr) {
+		reason = apiErr.Reason
+	}
+
+	retry := make([]task.TaskEvent, len(events))
+	for i, te := range events {
+		if i >= failed {
+			m.unassignTask(placed[i].Api, te.Task.ID)
+			m.markPending(te.Task.ID, reason, message)
+			retry[i] = te
+			continue
+		}
+
+		log.Info().Msgf("Stopping task %s of group %s to roll back the dispatch", te.Task.ID, g.Name)
+		m.stopTask(placed[i].Api, te.Task.ID.String())
+
+		t := renewTask(te.Task)
+		t.PendingReason = reason
+		t.PendingMessage = message
+		m.TaskDb.Put(t.ID.String(), &t)
+		retry[i] = task.TaskEvent{
+			ID:        uuid.New(),
+			State:     task.Scheduled,
+			Timestamp: time.Now().UTC(),
+			Task:      t,
+			Reason:    fmt.Sprintf("replaces task %s of group %s after a failed dispatch", te.Task.ID, g.Name),
+		}
+	}
+
+	g.Status.Message = message
+	m.GroupDb.Put(objectKey(g.Namespace, g.Name), g)
+
+	m.enqueueGang(retry, time.Now().Add(m.UpdateInterval))
+}
+
+// failGroup reports a group that could not be placed before its deadline,
+// or whose secrets or configs could not be resolved, as unschedulable,
+// failing its tasks.
+func (m *Manager) failGroup(g *group.Group, tasks []task.Task, message string) {
+	log.Info().Msgf("Group %s is unschedulable: %s", g.Name, message)
+
+	for _, t := range tasks {
+		m.modifyTask(t.ID, func(persisted *task.Task) bool {
+			if persisted.State != task.Pending {
+				return false
+			}
+			persisted.State = task.Failed
+			persisted.Error = "group unschedulable: " + message
+			persisted.FinishTime = time.Now().UTC()
+			return true
+		})
+	}
+
+	g.Status.State = group.Unschedulable
+	g.Status.Message = message
+	m.GroupDb.Put(objectKey(g.Namespace, g.Name), g)
+}
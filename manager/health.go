@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"sync"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// checkHealth probes the health check of every running task that has one,
+// in parallel. Rolling updates, the DNS server and the ingress rely on the
+// health recorded here.
+func (m *Manager) checkHealth() {
+	var wg sync.WaitGroup
+	for _, t := range m.GetTasksByState(task.Running) {
+		if t.HealthCheck == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(t *task.Task) {
+			defer wg.Done()
+			m.checkTaskHealth(t)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// taskHealthy reports whether a task passed its last health check. Tasks
+// without a health check are healthy as soon as they run, those with one
+// only once it has been probed.
+func taskHealthy(t *task.Task) bool {
+	return t.HealthCheck == "" || t.Health == task.Healthy
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hugoleodev/pentagon/image"
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/hugoleodev/pentagon/worker"
+	"github.com/rs/zerolog/log"
+)
+
+// ImageRequestTimeout bounds task starts, pre-pulls and image collections
+// on a node, which take far longer than other worker requests with large
+// images.
+const ImageRequestTimeout = 30 * time.Minute
+
+// GetNodeImages lists the images cached on a node.
+func (m *Manager) GetNodeImages(name string) ([]image.Image, error) {
+	n, err := m.GetNode(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.RequestTimeout)
+	defer cancel()
+	return m.WorkerClients[n.Api].GetImages(ctx)
+}
+
+// CollectNodeImages has a node remove its unused images and returns them.
+func (m *Manager) CollectNodeImages(name string, all bool) ([]image.Image, error) {
+	n, err := m.GetNode(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ImageRequestTimeout)
+	defer cancel()
+	removed, err := m.WorkerClients[n.Api].CollectImages(ctx, all)
+	if err == nil {
+		m.updateNode(n.Api)
+	}
+	return removed, err
+}
+
+// PullImages pulls images on every node that is up and matches the
+// request's selector, the nodes in parallel, and waits for the pulls to
+// end.
+func (m *Manager) PullImages(req image.PullRequest) ([]image.NodePull, error) {
+	if len(req.Images) == 0 {
+		return nil, fmt.Errorf("at least one image is required")
+	}
+	sel, err := labels.Parse(req.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds *task.RegistryCredentials
+	if req.RegistryAuth != "" {
+		if creds, err = m.resolveRegistryAuth(task.Task{Namespace: req.Namespace, RegistryAuth: req.RegistryAuth}); err != nil {
+			return nil, err
+		}
+	}
+
+	var nodes []*node.Node
+	for _, n := range m.availableNodes() {
+		if sel.Matches(n.Labels) {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no node is up and matches %q", req.Selector)
+	}
+
+	results := make([]image.NodePull, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n *node.Node) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), ImageRequestTimeout)
+			defer cancel()
+
+			log.Info().Msgf("Pre-pulling images %v on node %s", req.Images, n.Name)
+			results[i].Node = n.Name
+			pulls, err := m.WorkerClients[n.Api].PullImages(ctx, worker.ImagePullRequest{Images: req.Images, RegistryAuth: creds})
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Pulls = pulls
+			m.updateNode(n.Api)
+		}(i, n)
+	}
+	wg.Wait()
+
+	return results, nil
+}
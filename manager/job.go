@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/job"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddJob(j *job.Job) error {
+	j.SetDefaults()
+	if err := j.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&j.Namespace); err != nil {
+		return err
+	}
+	if err := m.checkReferences(j.Namespace, j.Template); err != nil {
+		return err
+	}
+
+	if _, err := m.JobDb.Get(objectKey(j.Namespace, j.Name)); err == nil {
+		return fmt.Errorf("job %s already exists in namespace %s", j.Name, j.Namespace)
+	}
+
+	j.ID = uuid.New()
+	j.CreatedAt = time.Now().UTC()
+	j.Status = job.Status{State: job.Running, StartedAt: j.CreatedAt}
+
+	if err := m.JobDb.Put(objectKey(j.Namespace, j.Name), j); err != nil {
+		return err
+	}
+
+	m.reconcileJob(j)
+	return nil
+}
+
+func (m *Manager) GetJobs() []*job.Job {
+	jobs, err := m.JobDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of jobs: %v\n", err)
+		return []*job.Job{}
+	}
+	return jobs
+}
+
+func (m *Manager) GetJob(ns, name string) (*job.Job, error) {
+	return m.JobDb.Get(objectKey(ns, name))
+}
+
+// DeleteJob removes the job and stops any of its tasks still active.
+func (m *Manager) DeleteJob(ns, name string) error {
+	j, err := m.JobDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	if err := m.JobDb.Delete(objectKey(ns, name)); err != nil {
+		return err
+	}
+
+	for _, t := range m.GetActiveTasks() {
+		if j.Owns(t) {
+			m.StopTask(t)
+		}
+	}
+	return nil
+}
+
+// reconcileJob counts the finished tasks of a job and starts new ones
+// until the required completions succeed, or the job runs out of retries.
+// Tasks stopped before exiting, e.g. by an eviction, are simply replaced.
+func (m *Manager) reconcileJob(j *job.Job) {
+	if j.Finished() {
+		return
+	}
+
+	var active []*task.Task
+	var lastFailure time.Time
+	succeeded, failed := 0, 0
+
+	for _, t := range m.GetTasks() {
+		if !j.Owns(t) {
+			continue
+		}
+
+		switch {
+		case job.TaskSucceeded(t):
+			succeeded++
+		case job.TaskFailed(t):
+			failed++
+			if t.FinishTime.After(lastFailure) {
+				lastFailure = t.FinishTime
+			}
+		case t.State == task.Pending || t.State == task.Scheduled || t.State == task.Running:
+			active = append(active, t)
+		}
+	}
+
+	j.Status.Succeeded = succeeded
+	j.Status.Failed = failed
+	j.Status.Active = len(active)
+	j.Status.NextRetryAt = time.Time{}
+
+	switch {
+	case succeeded >= j.Completions:
+		m.finishJob(j, job.Succeeded, fmt.Sprintf("%d of %d completions succeeded", succeeded, j.Completions), active)
+	case failed > *j.BackoffLimit:
+		m.finishJob(j, job.Failed, fmt.Sprintf("%d tasks failed, exceeding the backoff limit of %d", failed, *j.BackoffLimit), active)
+	default:
+		want := j.Parallelism
+		if remaining := j.Completions - succeeded; remaining < want {
+			want = remaining
+		}
+		want -= len(active)
+
+		if want > 0 && failed > 0 {
+			retryAt := lastFailure.Add(job.Backoff(failed))
+			if time.Now().Before(retryAt) {
+				j.Status.NextRetryAt = retryAt
+				want = 0
+			}
+		}
+
+		for i := 0; i < want; i++ {
+			t := j.NewTask()
+			log.Info().Msgf("Starting task %s for job %s", t.ID, j.Name)
+			err := m.SubmitTask(task.TaskEvent{
+				ID:        uuid.New(),
+				State:     task.Scheduled,
+				Timestamp: time.Now().UTC(),
+				Task:      *t,
+			})
+			if err != nil {
+				log.Info().Msgf("Unable to start a task for job %s: %v", j.Name, err)
+				j.Status.Message = err.Error()
+				break
+			}
+			j.Status.Message = ""
+			j.Status.Active++
+		}
+	}
+
+	m.JobDb.Put(objectKey(j.Namespace, j.Name), j)
+}
+
+func (m *Manager) finishJob(j *job.Job, state string, message string, active []*task.Task) {
+	log.Info().Msgf("Job %s %s: %s", j.Name, state, message)
+
+	for _, t := range active {
+		m.StopTask(t)
+	}
+
+	j.Status.State = state
+	j.Status.Message = message
+	j.Status.Active = 0
+	j.Status.CompletedAt = time.Now().UTC()
+}
+
+func (m *Manager) reconcileJobs() {
+	for _, j := range m.GetJobs() {
+		m.reconcileJob(j)
+	}
+}
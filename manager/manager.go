@@ -1,180 +1,1130 @@
 package manager
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
-	"github.com/golang-collections/collections/queue"
 	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/client"
+	"github.com/hugoleodev/pentagon/configs"
+	"github.com/hugoleodev/pentagon/crontask"
+	"github.com/hugoleodev/pentagon/group"
+	"github.com/hugoleodev/pentagon/job"
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/manifest"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/scheduler"
+	"github.com/hugoleodev/pentagon/secret"
+	"github.com/hugoleodev/pentagon/service"
+	"github.com/hugoleodev/pentagon/store"
 	"github.com/hugoleodev/pentagon/task"
-	"github.com/hugoleodev/pentagon/worker"
+	"github.com/hugoleodev/pentagon/workflow"
+)
+
+const (
+	DefaultProcessInterval = 10 * time.Second
+	DefaultUpdateInterval  = 15 * time.Second
+	DefaultRequestTimeout  = 10 * time.Second
+
+	DefaultReconcileInterval      = 10 * time.Second
+	DefaultWorkerFailureThreshold = 3
 )
 
 type Manager struct {
-	Pending       queue.Queue
-	TaskDb        map[string]*task.Task
-	EventDb       map[string]*task.TaskEvent
+	Pending       *TaskQueue
+	TaskDb        store.Store[*task.Task]
+	EventDb       store.Store[*task.TaskEvent]
+	ServiceDb     store.Store[*service.Service]
+	JobDb         store.Store[*job.Job]
+	CronTaskDb    store.Store[*crontask.CronTask]
+	WorkflowDb    store.Store[*workflow.Workflow]
+	GroupDb       store.Store[*group.Group]
+	NamespaceDb   store.Store[*namespace.Namespace]
+	SecretDb      store.Store[*secret.Secret]
+	ConfigDb      store.Store[*configs.Config]
 	Workers       []string
+	WorkerNodes   []*node.Node
+	WorkerClients map[string]*client.Worker
 	WorkerTaskMap map[string][]uuid.UUID
 	TaskWorkerMap map[uuid.UUID]string
-	LastWorker    int
+	// mu guards the pending queue, the worker/task maps and the nodes,
+	// which are shared by the API handlers and the manager's control loops.
+	mu              sync.RWMutex
+	Scheduler       scheduler.Scheduler
+	ProcessInterval time.Duration
+	UpdateInterval  time.Duration
+	RequestTimeout  time.Duration
+
+	ReconcileInterval time.Duration
+	// WorkerFailureThreshold is the number of consecutive failed polls
+	// after which a worker is considered lost and its tasks failed.
+	WorkerFailureThreshold int
+	workerFailures         map[string]int
+	// evicted records the tasks already asked to stop by evictions, so
+	// they are evicted only once.
+	evicted map[uuid.UUID]bool
+	// preempting records the tasks preempted to make room for each task,
+	// which is held back until they have stopped.
+	preempting map[uuid.UUID][]uuid.UUID
+	// SecretCipher seals the values of secrets at rest. Nil disables
+	// secrets.
+	SecretCipher *secret.Cipher
+
+	// quotaMu serialises quota checks with the submissions they allow.
+	quotaMu sync.Mutex
+	// tasksMu serialises the changes to stored tasks.
+	tasksMu sync.Mutex
 }
 
-func New(workers []string) *Manager {
-	taskDb := make(map[string]*task.Task)
-	eventDb := make(map[string]*task.TaskEvent)
+// New creates a manager for the given workers. The options configure the
+// clients it talks to the workers with, e.g. the credential they require.
+func New(workers []string, schedulerType string, dbType string, dbPath string, opts ...client.Option) (*Manager, error) {
 	workerTaskMap := make(map[string][]uuid.UUID)
 	taskWorkerMap := make(map[uuid.UUID]string)
+	workerClients := make(map[string]*client.Worker)
 
+	// Every request to a worker carries its own deadline, image pulls
+	// needing far longer than the client's default timeout.
+	opts = append([]client.Option{client.WithTimeout(0)}, opts...)
+
+	var nodes []*node.Node
 	for worker := range workers {
 		workerTaskMap[workers[worker]] = []uuid.UUID{}
+		workerClients[workers[worker]] = client.NewWorker(workers[worker], opts...)
+		nodes = append(nodes, node.New(workers[worker], workers[worker], "worker"))
+	}
+
+	s, err := scheduler.New(schedulerType)
+	if err != nil {
+		return nil, err
+	}
+
+	taskDb, err := store.New[*task.Task](dbType, dbPath, "tasks")
+	if err != nil {
+		return nil, err
+	}
+
+	eventDb, err := store.New[*task.TaskEvent](dbType, dbPath, "events")
+	if err != nil {
+		return nil, err
+	}
+
+	serviceDb, err := store.New[*service.Service](dbType, dbPath, "services")
+	if err != nil {
+		return nil, err
+	}
+
+	jobDb, err := store.New[*job.Job](dbType, dbPath, "jobs")
+	if err != nil {
+		return nil, err
+	}
+
+	cronTaskDb, err := store.New[*crontask.CronTask](dbType, dbPath, "crontasks")
+	if err != nil {
+		return nil, err
 	}
 
-	return &Manager{
-		Pending:       *queue.New(),
-		TaskDb:        taskDb,
-		EventDb:       eventDb,
-		Workers:       workers,
-		WorkerTaskMap: workerTaskMap,
-		TaskWorkerMap: taskWorkerMap,
+	workflowDb, err := store.New[*workflow.Workflow](dbType, dbPath, "workflows")
+	if err != nil {
+		return nil, err
+	}
+
+	groupDb, err := store.New[*group.Group](dbType, dbPath, "groups")
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceDb, err := store.New[*namespace.Namespace](dbType, dbPath, "namespaces")
+	if err != nil {
+		return nil, err
+	}
+
+	secretDb, err := store.New[*secret.Secret](dbType, dbPath, "secrets")
+	if err != nil {
+		return nil, err
+	}
+
+	configDb, err := store.New[*configs.Config](dbType, dbPath, "configs")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		Pending:         NewTaskQueue(),
+		TaskDb:          taskDb,
+		EventDb:         eventDb,
+		ServiceDb:       serviceDb,
+		JobDb:           jobDb,
+		CronTaskDb:      cronTaskDb,
+		WorkflowDb:      workflowDb,
+		GroupDb:         groupDb,
+		NamespaceDb:     namespaceDb,
+		SecretDb:        secretDb,
+		ConfigDb:        configDb,
+		Workers:         workers,
+		WorkerNodes:     nodes,
+		WorkerClients:   workerClients,
+		WorkerTaskMap:   workerTaskMap,
+		TaskWorkerMap:   taskWorkerMap,
+		Scheduler:       s,
+		ProcessInterval: DefaultProcessInterval,
+		UpdateInterval:  DefaultUpdateInterval,
+		RequestTimeout:  DefaultRequestTimeout,
+
+		ReconcileInterval:      DefaultReconcileInterval,
+		WorkerFailureThreshold: DefaultWorkerFailureThreshold,
+		workerFailures:         make(map[string]int),
+		evicted:                make(map[uuid.UUID]bool),
+		preempting:             make(map[uuid.UUID][]uuid.UUID),
+	}
+
+	if err := m.rekeyObjects(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// rekeyObjects moves the named objects of earlier versions to the keys
+// they are looked up by.
+func (m *Manager) rekeyObjects() error {
+	if err := rekey(m.ServiceDb, func(s *service.Service) (string, string) { return s.Namespace, s.Name }); err != nil {
+		return err
+	}
+	if err := rekey(m.JobDb, func(j *job.Job) (string, string) { return j.Namespace, j.Name }); err != nil {
+		return err
+	}
+	if err := rekey(m.CronTaskDb, func(c *crontask.CronTask) (string, string) { return c.Namespace, c.Name }); err != nil {
+		return err
 	}
+	if err := rekey(m.WorkflowDb, func(w *workflow.Workflow) (string, string) { return w.Namespace, w.Name }); err != nil {
+		return err
+	}
+	if err := rekey(m.GroupDb, func(g *group.Group) (string, string) { return g.Namespace, g.Name }); err != nil {
+		return err
+	}
+	if err := rekey(m.SecretDb, func(s *secret.Secret) (string, string) { return s.Namespace, s.Name }); err != nil {
+		return err
+	}
+	return rekey(m.ConfigDb, func(c *configs.Config) (string, string) { return c.Namespace, c.Name })
 }
 
-func (m *Manager) SelectWorker() string {
-	var newWorker int
+func (m *Manager) SelectWorker(t task.Task) (*node.Node, error) {
+	nodes := m.availableNodes()
+	m.fillNodeTasks(nodes)
 
-	if m.LastWorker+1 < len(m.Workers) {
-		newWorker = m.LastWorker + 1
-		m.LastWorker++
-	} else {
-		newWorker = 0
-		m.LastWorker = 0
+	candidates := m.Scheduler.SelectCandidateNodes(t, nodes)
+	if len(candidates) == 0 {
+		if n, victims := scheduler.Preempt(t, nodes); n != nil {
+			m.preempt(t, n, victims)
+			return nil, &scheduler.Unschedulable{
+				Reason:  task.ReasonPreempting,
+				Message: fmt.Sprintf("waiting for %d preempted tasks to stop on node %s", len(victims), n.Name),
+			}
+		}
+		return nil, scheduler.Diagnose(t, nodes)
+	}
+
+	scores := m.Scheduler.Score(t, candidates)
+	selectedNode := m.Scheduler.Pick(scores, candidates)
+	if selectedNode == nil {
+		return nil, fmt.Errorf("no node selected for task %v", t.ID)
 	}
 
-	return m.Workers[newWorker]
+	return selectedNode, nil
 }
 
 func (m *Manager) updateTasks() {
 	for _, w := range m.Workers {
-		log.Info().Msgf("Worker: %v", w)
 		log.Info().Msgf("Checking worker %v for task updates\n", w)
-		url := fmt.Sprintf("http://%s/api/tasks", w)
-		resp, err := http.Get(url)
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.RequestTimeout)
+		tasks, err := m.WorkerClients[w].GetTasks(ctx)
+		cancel()
 
 		if err != nil {
 			log.Info().Msgf("Unable to get tasks from worker %v: %v\n", w, err)
+			m.workerUnreachable(w)
+			continue
 		}
+		m.workerReachable(w)
+		m.updateNode(w)
 
-		if resp.StatusCode != http.StatusOK {
-			log.Info().Msgf("Error sending request: %v\n", err)
+		active := 0
+		for _, t := range tasks {
+			if t.State == task.Scheduled || t.State == task.Running {
+				active++
+			}
 		}
-
-		d := json.NewDecoder(resp.Body)
-		var tasks []*task.Task
-		err = d.Decode(&tasks)
-		if err != nil {
-			log.Info().Msgf("Unable unmarshaling tasks: %s\n", err.Error())
+		m.mu.Lock()
+		n := m.getNode(w)
+		if n != nil {
+			n.TaskCount = active
+		}
+		m.mu.Unlock()
+		if n != nil {
+			m.fillNodeTasks([]*node.Node{n})
 		}
 
 		for _, t := range tasks {
 			log.Info().Msgf("Attempting to update task %s\n", t.ID)
 
-			_, ok := m.TaskDb[t.ID.String()]
-			if !ok {
+			finished := false
+			persisted, _ := m.modifyTask(t.ID, func(taskPersisted *task.Task) bool {
+				if taskPersisted.State != t.State {
+					taskPersisted.State = t.State
+					log.Info().Msgf("Task %s state updated\n", t.ID)
+					finished = t.State == task.Completed || t.State == task.Failed
+				}
+
+				taskPersisted.StartTime = t.StartTime
+				taskPersisted.FinishTime = t.FinishTime
+				taskPersisted.ExitCode = t.ExitCode
+				taskPersisted.OOMKilled = t.OOMKilled
+				taskPersisted.Error = t.Error
+				taskPersisted.Exited = t.Exited
+				taskPersisted.ContainerID = t.ContainerID
+				taskPersisted.HostPorts = t.HostPorts
+				return true
+			})
+			if persisted == nil {
 				log.Info().Msgf("Task %s not found\n", t.ID)
+				continue
+			}
+
+			if t.State == task.Completed || t.State == task.Failed {
+				m.forgetEviction(t.ID)
+			}
+			if finished {
+				m.wake()
+			}
+		}
+	}
+}
+
+// modifyTask applies fn to a copy of a stored task and stores the copy in
+// its place, so the tasks handed out by the store never change under their
+// readers. fn returns false to leave the task as it is. The task is nil
+// when it does not exist, and the boolean reports whether it changed.
+func (m *Manager) modifyTask(id uuid.UUID, fn func(t *task.Task) bool) (*task.Task, bool) {
+	m.tasksMu.Lock()
+	defer m.tasksMu.Unlock()
+
+	stored, err := m.TaskDb.Get(id.String())
+	if err != nil {
+		return nil, false
+	}
+
+	t := *stored
+	if !fn(&t) {
+		return stored, false
+	}
+	if err := m.TaskDb.Put(t.ID.String(), &t); err != nil {
+		log.Info().Msgf("Unable to store task %s: %v\n", t.ID, err)
+		return stored, false
+	}
+	return &t, true
+}
+
+// forgetEviction drops the record of a task's eviction once it finished.
+func (m *Manager) forgetEviction(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.evicted, id)
+}
+
+func (m *Manager) workerReachable(w string) {
+	m.workerFailures[w] = 0
+
+	m.mu.Lock()
+	n := m.getNode(w)
+	recovered := n != nil && n.Status != node.Ready
+	if recovered {
+		n.Status = node.Ready
+	}
+	m.mu.Unlock()
+
+	if recovered {
+		log.Info().Msgf("Worker %v is reachable again", w)
+		m.wake()
+	}
+}
+
+// workerUnreachable records a failed poll of a worker. Once the failure
+// threshold is reached the worker is marked down and the tasks assigned to
+// it are failed, so controllers can replace them elsewhere.
+func (m *Manager) workerUnreachable(w string) {
+	m.workerFailures[w]++
+	if m.workerFailures[w] < m.WorkerFailureThreshold {
+		return
+	}
+
+	m.mu.Lock()
+	n := m.getNode(w)
+	if n == nil || n.Status == node.Down {
+		m.mu.Unlock()
+		return
+	}
+	n.Status = node.Down
+	ids := append([]uuid.UUID{}, m.WorkerTaskMap[w]...)
+	m.mu.Unlock()
+
+	log.Info().Msgf("Worker %v missed %d updates, marking it down", w, m.workerFailures[w])
+
+	for _, id := range ids {
+		_, failed := m.modifyTask(id, func(t *task.Task) bool {
+			if t.State != task.Scheduled && t.State != task.Running {
+				return false
+			}
+			t.State = task.Failed
+			t.FinishTime = time.Now().UTC()
+			return true
+		})
+		if failed {
+			log.Info().Msgf("Marked task %s on lost worker %v as failed", id, w)
+			m.unassignTask(w, id)
+			m.forgetEviction(id)
+		}
+	}
+}
+
+// getNode returns the node of a worker. Its fields are shared, callers
+// hold m.mu.
+func (m *Manager) getNode(api string) *node.Node {
+	for _, n := range m.WorkerNodes {
+		if n.Api == api {
+			return n
+		}
+	}
+	return nil
+}
+
+// availableNodes returns copies of the nodes that can currently receive
+// tasks, for the scheduler to fill in.
+func (m *Manager) availableNodes() []*node.Node {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nodes := []*node.Node{}
+	for _, n := range m.WorkerNodes {
+		if n.Status != node.Down {
+			c := *n
+			nodes = append(nodes, &c)
+		}
+	}
+	return nodes
+}
+
+// fillNodeTasks records on each node the active tasks assigned to it and
+// the resources they request, for the scheduler's affinity rules, capacity
+// checks and preemption. Tasks already being evicted are left out.
+func (m *Manager) fillNodeTasks(nodes []*node.Node) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, n := range nodes {
+		n.Tasks = nil
+		n.CpuAllocated, n.MemoryAllocated, n.DiskAllocated = 0, 0, 0
+		for _, id := range m.WorkerTaskMap[n.Api] {
+			t, err := m.TaskDb.Get(id.String())
+			if err != nil || (t.State != task.Scheduled && t.State != task.Running) || m.evicted[t.ID] {
+				continue
 			}
+			n.Tasks = append(n.Tasks, node.Resident{
+				ID:       t.ID.String(),
+				Labels:   t.Labels,
+				Priority: t.Priority,
+				Cpu:      t.Cpu,
+				Memory:   t.Memory,
+				Disk:     t.Disk,
+			})
+			n.CpuAllocated += t.Cpu
+			n.MemoryAllocated += int(t.Memory)
+			n.DiskAllocated += int(t.Disk)
+		}
+	}
+}
+
+// preempt stops the victims chosen to make room for t on a node, t being
+// held back until their workers report them stopped. Victims not managed
+// by a controller or a group are queued again under a new ID; controllers
+// replace their own.
+func (m *Manager) preempt(t task.Task, n *node.Node, victims []node.Resident) {
+	for _, v := range victims {
+		victim, err := m.TaskDb.Get(v.ID)
+		if err != nil {
+			continue
+		}
+
+		m.mu.Lock()
+		m.evicted[victim.ID] = true
+		m.preempting[t.ID] = append(m.preempting[t.ID], victim.ID)
+		m.mu.Unlock()
+
+		reason := fmt.Sprintf("preempted on node %s by task %s with priority %d", n.Name, t.ID, t.Priority)
+		log.Info().Msgf("Task %s %s", victim.ID, reason)
+		m.stopTaskFor(victim, reason)
+
+		if controlled(victim) {
+			continue
+		}
+
+		replacement := renewTask(*victim)
+		log.Info().Msgf("Requeueing preempted task %s as %s", victim.ID, replacement.ID)
+		m.AddTask(task.TaskEvent{
+			ID:        uuid.New(),
+			State:     task.Scheduled,
+			Timestamp: time.Now().UTC(),
+			Task:      replacement,
+			Reason:    fmt.Sprintf("replaces task %s, %s", victim.ID, reason),
+		})
+	}
+}
 
-			if m.TaskDb[t.ID.String()].State != t.State {
-				m.TaskDb[t.ID.String()].State = t.State
-				log.Info().Msgf("Task %s state updated\n", t.ID)
+// victimsStopping reports whether the tasks preempted for a task have yet
+// to stop. The record of the victims is dropped once they all have.
+func (m *Manager) victimsStopping(id uuid.UUID) bool {
+	m.mu.RLock()
+	victims, ok := m.preempting[id]
+	m.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	for _, v := range victims {
+		t, err := m.TaskDb.Get(v.String())
+		if err == nil && t.State != task.Completed && t.State != task.Failed {
+			return true
+		}
+	}
+
+	m.mu.Lock()
+	delete(m.preempting, id)
+	m.mu.Unlock()
+	return false
+}
+
+// renewTask returns a copy of a task to run again under a new ID.
+func renewTask(t task.Task) task.Task {
+	t.ID = uuid.New()
+	t.State = task.Pending
+	t.ContainerID = ""
+	t.StartTime = time.Time{}
+	t.FinishTime = time.Time{}
+	t.ExitCode = 0
+	t.OOMKilled = false
+	t.Error = ""
+	t.Exited = false
+	t.StopReason = ""
+	t.ConfigVersions = nil
+	return t
+}
+
+// controlled reports whether a task is managed by a controller, or is part
+// of a group, and must not be requeued on its own.
+func controlled(t *task.Task) bool {
+	for _, key := range []string{service.LabelName, job.LabelName, crontask.LabelName, workflow.LabelName, group.LabelName} {
+		if _, ok := t.Labels[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// updateNode refreshes what the manager knows about a worker's node.
+func (m *Manager) updateNode(w string) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.RequestTimeout)
+	defer cancel()
+
+	info, err := m.WorkerClients[w].GetNode(ctx)
+	if err != nil {
+		log.Info().Msgf("Error getting node information from worker %v: %v\n", w, err)
+		return
+	}
+
+	m.mu.Lock()
+	n := m.getNode(w)
+	if n == nil {
+		m.mu.Unlock()
+		return
+	}
+	n.Labels = info.Labels
+	n.Taints = info.Taints
+	n.Images = info.Images
+	resized := n.Cores != info.Cores || n.Memory != info.Memory || n.Disk != info.Disk
+	n.Cores = info.Cores
+	n.Memory = info.Memory
+	n.Disk = info.Disk
+	updated := *n
+	m.mu.Unlock()
+
+	if resized {
+		m.wake()
+	}
+	m.evictUntolerated(&updated)
+}
+
+// evictUntolerated stops the active tasks on a node that do not tolerate
+// one of its NoExecute taints.
+func (m *Manager) evictUntolerated(n *node.Node) {
+	m.mu.RLock()
+	ids := append([]uuid.UUID{}, m.WorkerTaskMap[n.Api]...)
+	m.mu.RUnlock()
+
+	for _, id := range ids {
+		t, err := m.TaskDb.Get(id.String())
+		if err != nil || (t.State != task.Scheduled && t.State != task.Running) {
+			continue
+		}
+
+		for _, taint := range n.Taints {
+			if taint.Effect != node.NoExecute || t.Tolerates(taint) {
+				continue
 			}
 
-			m.TaskDb[t.ID.String()].StartTime = t.StartTime
-			m.TaskDb[t.ID.String()].FinishTime = t.FinishTime
-			m.TaskDb[t.ID.String()].ContainerID = t.ContainerID
+			m.mu.Lock()
+			evicted := m.evicted[t.ID]
+			m.evicted[t.ID] = true
+			m.mu.Unlock()
+
+			if !evicted {
+				reason := fmt.Sprintf("evicted from node %s: taint %s is not tolerated", n.Name, taint)
+				log.Info().Msgf("Task %s %s", t.ID, reason)
+				m.stopTaskFor(t, reason)
+			}
+			break
 		}
 	}
 }
 
 func (m *Manager) SendWork() {
-	if m.Pending.Len() > 0 {
-		w := m.SelectWorker()
+	if events, ok := m.dequeue(); ok {
+		if name := events[0].Task.Labels[group.LabelName]; name != "" && events[0].State != task.Completed {
+			m.sendGroup(name, events)
+			return
+		}
 
-		e := m.Pending.Dequeue()
-		te := e.(task.TaskEvent)
+		te := events[0]
 		t := te.Task
 		log.Info().Msgf("Pulled %v of pending queue\n", t)
 
-		m.EventDb[te.ID.String()] = &te
-		m.WorkerTaskMap[w] = append(m.WorkerTaskMap[w], te.Task.ID)
+		m.EventDb.Put(te.ID.String(), &te)
 
-		m.TaskWorkerMap[t.ID] = w
+		persistedTask, err := m.TaskDb.Get(t.ID.String())
+		if err == nil && te.State != task.Completed && (persistedTask.State == task.Completed || persistedTask.State == task.Failed) {
+			log.Info().Msgf("Task %s was stopped before being scheduled, skipping\n", t.ID)
+			m.mu.Lock()
+			delete(m.preempting, t.ID)
+			m.mu.Unlock()
+			return
+		}
 
-		t.State = task.Scheduled
-		m.TaskDb[t.ID.String()] = &t
+		if taskWorker, ok := m.taskWorker(t.ID); ok {
+			persistedTask, err := m.TaskDb.Get(t.ID.String())
+			if err != nil {
+				log.Info().Msgf("Unable to find task %s: %v\n", t.ID, err)
+				return
+			}
 
-		data, err := json.Marshal(te)
-		if err != nil {
-			log.Info().Msgf("Unable to marshal task event: %v\n", err)
+			if te.State == task.Completed && task.ValidStateTransition(persistedTask.State, te.State) {
+				m.stopTask(taskWorker, t.ID.String())
+				return
+			}
+
+			log.Info().Msgf("Invalid request: existing task %s is in state %v and cannot transition to %v\n", persistedTask.ID, persistedTask.State, te.State)
+			return
+		}
+
+		if te.State == task.Completed {
+			log.Info().Msgf("Task %s is not assigned to a worker, nothing to stop\n", t.ID)
+			return
 		}
 
-		url := fmt.Sprintf("http://%s/api/tasks", w)
-		log.Info().Msgf("sending request to %v", url)
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+		if m.victimsStopping(t.ID) {
+			log.Info().Msgf("Task %s waits for the tasks it preempted to stop\n", t.ID)
+			m.enqueueAfter(te, time.Now().Add(m.UpdateInterval))
+			return
+		}
+
+		// The secret values and config files travel with the event sent
+		// to the worker only, the event requeued on failure goes without
+		// them.
+		dispatched := te
+		if err := m.resolveReferences(&dispatched); err != nil {
+			log.Info().Msgf("Unable to resolve the references of task %s: %v\n", t.ID, err)
+			m.failTask(t.ID, err.Error())
+			return
+		}
+		t.ConfigVersions = dispatched.Task.ConfigVersions
 
+		n, err := m.SelectWorker(t)
 		if err != nil {
-			log.Info().Msgf("Error connecting to %v: %v\n", w, err)
-			m.Pending.Enqueue(te)
+			log.Info().Msgf("Error selecting worker for task %s: %v\n", t.ID, err)
+			var u *scheduler.Unschedulable
+			if errors.As(err, &u) {
+				m.markPending(t.ID, u.Reason, u.Message)
+			}
+			m.enqueueAfter(te, time.Now().Add(m.UpdateInterval))
 			return
 		}
+		w := n.Api
 
-		d := json.NewDecoder(resp.Body)
-		if resp.StatusCode != http.StatusCreated {
-			e := worker.ErrResponse{}
-			err := d.Decode(&e)
-			if err != nil {
-				log.Info().Msgf("Error decoding response: %s\n", err.Error())
-				return
+		m.assignTask(w, t.ID)
+
+		// The stored task is updated rather than replaced by the copy in
+		// the event, which misses any stop or update since it was queued.
+		if _, scheduled := m.modifyTask(t.ID, func(stored *task.Task) bool {
+			if stored.State != task.Pending && stored.State != task.Scheduled {
+				return false
 			}
-			log.Info().Msgf("Response error (%d): %s", e.HTTPStatusCode, e.Message)
+			stored.State = task.Scheduled
+			stored.PendingReason = ""
+			stored.PendingMessage = ""
+			stored.ConfigVersions = t.ConfigVersions
+			return true
+		}); !scheduled {
+			log.Info().Msgf("Task %s was stopped before being sent, skipping\n", t.ID)
+			m.unassignTask(w, t.ID)
 			return
 		}
 
-		t = task.Task{}
-		err = d.Decode(&t)
+		// The worker pulls the image before answering, so the start gets
+		// the deadline of image requests rather than RequestTimeout.
+		ctx, cancel := context.WithTimeout(context.Background(), ImageRequestTimeout)
+		defer cancel()
+
+		log.Info().Msgf("Sending task %s to worker %v", t.ID, w)
+		started, err := m.WorkerClients[w].StartTask(ctx, dispatched)
 		if err != nil {
-			log.Info().Msgf("Error decoding response: %s\n", err.Error())
+			// The task goes back to pending, so it is never left scheduled
+			// without a worker.
+			reason, message := "", fmt.Sprintf("dispatch to node %s failed: %v", n.Name, err)
+			var apiErr *client.Error
+			if errors.As(err, &apiErr) {
+				log.Info().Msgf("Response error (%d): %s", apiErr.HTTPStatusCode, apiErr.Message)
+				reason = apiErr.Reason
+				if apiErr.HTTPStatusCode == http.StatusConflict {
+					log.Info().Msgf("Worker %v has no room for task %s, keeping it pending", w, t.ID)
+					message = fmt.Sprintf("rejected by node %s: %s", n.Name, apiErr.Message)
+				}
+			} else {
+				log.Info().Msgf("Error connecting to %v: %v\n", w, err)
+			}
+
+			m.unassignTask(w, t.ID)
+			m.markPending(t.ID, reason, message)
+			m.enqueueAfter(te, time.Now().Add(m.UpdateInterval))
 			return
 		}
-		log.Info().Msgf("%#v\n", t)
+
+		m.recordStart(started)
 	} else {
 		log.Info().Msgf("Pending queue is empty\n")
 	}
 }
 
+func (m *Manager) enqueue(te task.TaskEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Pending.Push(te)
+}
+
+// enqueueAfter queues an event that is not sent before notBefore, letting
+// the tasks behind it through in the meantime.
+func (m *Manager) enqueueAfter(te task.TaskEvent, notBefore time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Pending.PushAfter(te, notBefore)
+}
+
+// wake retries the events held back because their task could not be
+// placed, once capacity or the nodes have changed.
+func (m *Manager) wake() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Pending.Wake()
+}
+
+// enqueueGang queues the events of a group, handed out together and not
+// before notBefore.
+func (m *Manager) enqueueGang(events []task.TaskEvent, notBefore time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Pending.PushGang(events, notBefore)
+}
+
+func (m *Manager) dequeue() ([]task.TaskEvent, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.Pending.Pop(time.Now())
+}
+
+func (m *Manager) taskWorker(taskID uuid.UUID) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w, ok := m.TaskWorkerMap[taskID]
+	return w, ok
+}
+
+func (m *Manager) assignTask(w string, taskID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.WorkerTaskMap[w] = append(m.WorkerTaskMap[w], taskID)
+	m.TaskWorkerMap[taskID] = w
+	if n := m.getNode(w); n != nil {
+		n.TaskCount++
+	}
+}
+
+func (m *Manager) unassignTask(w string, taskID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := m.WorkerTaskMap[w]
+	for i, id := range ids {
+		if id == taskID {
+			m.WorkerTaskMap[w] = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	delete(m.TaskWorkerMap, taskID)
+	if n := m.getNode(w); n != nil {
+		n.TaskCount--
+	}
+}
+
+func (m *Manager) stopTask(worker string, taskID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.RequestTimeout)
+	defer cancel()
+
+	if err := m.WorkerClients[worker].StopTask(ctx, taskID); err != nil {
+		log.Info().Msgf("Error sending request to stop task %s on worker %s: %v\n", taskID, worker, err)
+		return
+	}
+
+	log.Info().Msgf("Task %s has been scheduled to be stopped", taskID)
+}
+
+// markPending records why a task that has not reached a worker is kept
+// pending.
+func (m *Manager) markPending(id uuid.UUID, reason string, message string) {
+	m.modifyTask(id, func(t *task.Task) bool {
+		if t.State != task.Pending && t.State != task.Scheduled {
+			return false
+		}
+		if t.State == task.Pending && t.PendingReason == reason && t.PendingMessage == message {
+			return false
+		}
+
+		t.State = task.Pending
+		t.PendingReason = reason
+		t.PendingMessage = message
+		return true
+	})
+}
+
+// AddTask queues a task event. Tasks seen for the first time are recorded
+// as pending right away, so they are visible before being scheduled.
 func (m *Manager) AddTask(te task.TaskEvent) {
-	m.Pending.Enqueue(te)
+	if te.State != task.Completed {
+		if _, err := m.TaskDb.Get(te.Task.ID.String()); err != nil {
+			t := te.Task
+			t.State = task.Pending
+			m.TaskDb.Put(t.ID.String(), &t)
+		}
+	}
+
+	m.enqueue(te)
+}
+
+// StopTask queues a request to stop a task. Tasks that have not reached a
+// worker yet are marked completed directly.
+func (m *Manager) StopTask(t *task.Task) {
+	m.stopTaskFor(t, task.ReasonCancelled)
+}
+
+// stopTaskFor stops a task, recording why on the task and the stop event.
+func (m *Manager) stopTaskFor(t *task.Task, reason string) {
+	if stopped, _ := m.modifyTask(t.ID, func(stored *task.Task) bool {
+		stored.StopReason = reason
+		if stored.State == task.Pending {
+			stored.State = task.Completed
+			stored.FinishTime = time.Now().UTC()
+		}
+		return true
+	}); stopped != nil {
+		t = stopped
+	}
+
+	te := task.TaskEvent{
+		ID:        uuid.New(),
+		State:     task.Completed,
+		Timestamp: time.Now().UTC(),
+		Task:      *t,
+		Reason:    reason,
+	}
+	te.Task.State = task.Completed
+
+	m.AddTask(te)
+}
+
+// GetActiveTasks returns the tasks that are pending, scheduled or running.
+func (m *Manager) GetActiveTasks() []*task.Task {
+	return m.GetTasksByState(task.Pending, task.Scheduled, task.Running)
+}
+
+// Apply converges the namespace of the manifest towards it. The changes
+// are refused as a whole if they would exceed the namespace's quota. With
+// dryRun set the changes are computed but not carried out.
+func (m *Manager) Apply(mf manifest.Manifest, dryRun bool, prune bool) (*manifest.Result, error) {
+	if err := mf.Validate(); err != nil {
+		return nil, err
+	}
+	if err := m.resolveNamespace(&mf.Namespace); err != nil {
+		return nil, err
+	}
+
+	m.quotaMu.Lock()
+	defer m.quotaMu.Unlock()
+
+	var active []*task.Task
+	for _, t := range m.GetActiveTasks() {
+		if namespace.OrDefault(t.Namespace) == mf.Namespace {
+			active = append(active, t)
+		}
+	}
+
+	changes := manifest.Diff(mf, active, prune)
+	var added, removed []task.Task
+	for _, c := range changes {
+		if c.Desired != nil {
+			c.Desired.Namespace = mf.Namespace
+			added = append(added, *c.Desired)
+		}
+		if c.Current != nil && (c.Action == manifest.ActionUpdate || c.Action == manifest.ActionDelete) {
+			removed = append(removed, *c.Current)
+		}
+	}
+	if err := m.CheckQuota(mf.Namespace, added, removed); err != nil {
+		return nil, err
+	}
+	if err := m.checkReferences(mf.Namespace, added...); err != nil {
+		return nil, err
+	}
+
+	result := &manifest.Result{DryRun: dryRun, Changes: changes}
+	if dryRun {
+		return result, nil
+	}
+
+	for _, c := range changes {
+		if c.Current != nil && (c.Action == manifest.ActionUpdate || c.Action == manifest.ActionDelete) {
+			log.Info().Msgf("Stopping task %s (%s replica %d) for manifest %s", c.Current.ID, c.Name, c.Replica, c.Action)
+			m.StopTask(c.Current)
+		}
+
+		if c.Desired != nil {
+			log.Info().Msgf("Submitting task %s (%s replica %d) for manifest %s", c.Desired.ID, c.Name, c.Replica, c.Action)
+			m.AddTask(task.TaskEvent{
+				ID:        uuid.New(),
+				State:     task.Scheduled,
+				Timestamp: time.Now().UTC(),
+				Task:      *c.Desired,
+			})
+		}
+	}
+
+	return result, nil
 }
 
 func (m *Manager) GetTasks() []*task.Task {
-	tasks := []*task.Task{}
-	for _, t := range m.TaskDb {
-		tasks = append(tasks, t)
+	tasks, err := m.TaskDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of tasks: %v\n", err)
+		return []*task.Task{}
+	}
+	return tasks
+}
+
+// GetTasksByState returns the tasks in any of the given states, or every
+// task when no state is given.
+func (m *Manager) GetTasksByState(states ...task.State) []*task.Task {
+	tasks := m.GetTasks()
+	if len(states) == 0 {
+		return tasks
+	}
+
+	filtered := []*task.Task{}
+	for _, t := range tasks {
+		if task.Contains(states, t.State) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// GetTasksMatching returns the tasks of a namespace whose labels match the
+// selector, optionally restricted to the given states. An empty namespace
+// matches tasks of every namespace.
+func (m *Manager) GetTasksMatching(ns string, sel labels.Selector, states ...task.State) []*task.Task {
+	filtered := []*task.Task{}
+	for _, t := range m.GetTasksByState(states...) {
+		if namespace.Matches(ns, t.Namespace) && sel.Matches(t.Labels) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// StopTasks stops every active task of a namespace matching the selector
+// and returns them.
+func (m *Manager) StopTasks(ns string, sel labels.Selector) []*task.Task {
+	tasks := m.GetTasksMatching(ns, sel, task.Pending, task.Scheduled, task.Running)
+	for _, t := range tasks {
+		m.StopTask(t)
 	}
 	return tasks
 }
 
+func (m *Manager) GetTask(id string) (*task.Task, error) {
+	return m.TaskDb.Get(id)
+}
+
+// GetTaskLogs fetches the container logs of a task from the worker running it.
+func (m *Manager) GetTaskLogs(t *task.Task, tail int) (string, error) {
+	w, ok := m.taskWorker(t.ID)
+	if !ok {
+		return "", fmt.Errorf("task %v is not assigned to a worker", t.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.RequestTimeout)
+	defer cancel()
+
+	logs, err := m.WorkerClients[w].GetTaskLogs(ctx, t.ID.String(), tail)
+	if err != nil {
+		return "", fmt.Errorf("unable to get logs from worker %v: %w", w, err)
+	}
+	return logs, nil
+}
+
+// recordStart records the event a worker returned for the start of a task,
+// which carries the progress of its image pull.
+func (m *Manager) recordStart(started *task.TaskEvent) {
+	if started.ID == uuid.Nil {
+		return
+	}
+	log.Info().Msgf("Task %s %s", started.Task.ID, started.Reason)
+	m.EventDb.Put(started.ID.String(), started)
+}
+
+// GetEvents returns every task event recorded by the manager, oldest first.
+func (m *Manager) GetEvents() []*task.TaskEvent {
+	events, err := m.EventDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of events: %v\n", err)
+		return []*task.TaskEvent{}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events
+}
+
+// GetNodes returns copies of the nodes of the workers.
+func (m *Manager) GetNodes() []*node.Node {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nodes := make([]*node.Node, 0, len(m.WorkerNodes))
+	for _, n := range m.WorkerNodes {
+		c := *n
+		nodes = append(nodes, &c)
+	}
+	return nodes
+}
+
+// CordonNode marks a node unschedulable, or schedulable again. Tasks
+// already on a cordoned node keep running.
+func (m *Manager) CordonNode(name string, cordon bool) (*node.Node, error) {
+	m.mu.Lock()
+	n := m.findNode(name)
+	if n == nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("node %s not found", name)
+	}
+	changed := n.Unschedulable != cordon
+	n.Unschedulable = cordon
+	c := *n
+	m.mu.Unlock()
+
+	if changed {
+		log.Info().Msgf("Node %s unschedulable: %v", n.Name, cordon)
+		if !cordon {
+			m.wake()
+		}
+	}
+	return &c, nil
+}
+
+// GetNode returns a copy of the node of a worker by name or API address.
+func (m *Manager) GetNode(name string) (*node.Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := m.findNode(name)
+	if n == nil {
+		return nil, fmt.Errorf("node %s not found", name)
+	}
+	c := *n
+	return &c, nil
+}
+
+func (m *Manager) findNode(name string) *node.Node {
+	for _, n := range m.WorkerNodes {
+		if n.Name == name || n.Api == name {
+			return n
+		}
+	}
+	return nil
+}
+
 func (m *Manager) ProcessTasks() {
 	for {
 		log.Info().Msg("Processing any tasks in the queue")
 		m.SendWork()
-		log.Info().Msg("Sleeping for 10 seconds")
-		time.Sleep(10 * time.Second)
+		log.Info().Msgf("Sleeping for %v", m.ProcessInterval)
+		time.Sleep(m.ProcessInterval)
+	}
+}
+
+// Reconcile probes the health checks of the running tasks, then runs the
+// controllers that converge services and jobs towards their desired state.
+func (m *Manager) Reconcile() {
+	for {
+		log.Info().Msg("Reconciling services, jobs, cron tasks and workflows")
+		m.checkHealth()
+		m.reconcileServices()
+		m.reconcileJobs()
+		m.reconcileCronTasks()
+		m.reconcileWorkflows()
+		log.Info().Msgf("Sleeping for %v", m.ReconcileInterval)
+		time.Sleep(m.ReconcileInterval)
 	}
 }
 
@@ -183,7 +1133,7 @@ func (m *Manager) UpdateTasks() {
 		log.Info().Msg("Checking for task updates from workers")
 		m.updateTasks()
 		log.Info().Msg("Task updates completed")
-		log.Info().Msg("Sleeping for 15 seconds")
-		time.Sleep(15 * time.Second)
+		log.Info().Msgf("Sleeping for %v", m.UpdateInterval)
+		time.Sleep(m.UpdateInterval)
 	}
 }
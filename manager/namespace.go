@@ -0,0 +1,200 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/store"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddNamespace(ns *namespace.Namespace) error {
+	if err := namespace.ValidateName(ns.Name); err != nil {
+		return err
+	}
+	if err := ns.Quota.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := m.NamespaceDb.Get(ns.Name); err == nil || ns.Name == namespace.Default {
+		return fmt.Errorf("namespace %s already exists", ns.Name)
+	}
+
+	ns.CreatedAt = time.Now().UTC()
+	ns.Usage = namespace.Usage{}
+	return m.NamespaceDb.Put(ns.Name, ns)
+}
+
+// GetNamespaces returns copies of every namespace, including the default
+// one, with the current usage of each.
+func (m *Manager) GetNamespaces() []*namespace.Namespace {
+	stored, err := m.NamespaceDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of namespaces: %v\n", err)
+	}
+
+	namespaces := []*namespace.Namespace{}
+	if _, err := m.NamespaceDb.Get(namespace.Default); err != nil {
+		namespaces = append(namespaces, &namespace.Namespace{Name: namespace.Default})
+	}
+	for _, ns := range stored {
+		c := *ns
+		namespaces = append(namespaces, &c)
+	}
+
+	for _, ns := range namespaces {
+		ns.Usage = m.namespaceUsage(ns.Name)
+	}
+	return namespaces
+}
+
+// GetNamespace returns a copy of a namespace with its current usage. The
+// default namespace exists even when it has never been stored.
+func (m *Manager) GetNamespace(name string) (*namespace.Namespace, error) {
+	ns := &namespace.Namespace{Name: namespace.Default}
+	stored, err := m.NamespaceDb.Get(name)
+	if err == nil {
+		c := *stored
+		ns = &c
+	} else if name != namespace.Default {
+		return nil, err
+	}
+
+	ns.Usage = m.namespaceUsage(name)
+	return ns, nil
+}
+
+// SetQuota replaces the quota of a namespace. Tasks already running are
+// not affected, only new submissions are checked against it.
+func (m *Manager) SetQuota(name string, q namespace.Quota) (*namespace.Namespace, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	ns, err := m.GetNamespace(name)
+	if err != nil {
+		return nil, err
+	}
+	if ns.CreatedAt.IsZero() {
+		ns.CreatedAt = time.Now().UTC()
+	}
+
+	ns.Quota = q
+	if err := m.NamespaceDb.Put(ns.Name, ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// DeleteNamespace removes an empty namespace.
+func (m *Manager) DeleteNamespace(name string) error {
+	if name == namespace.Default {
+		return fmt.Errorf("the default namespace cannot be deleted")
+	}
+	if _, err := m.NamespaceDb.Get(name); err != nil {
+		return err
+	}
+
+	if active := m.namespaceUsage(name).Tasks; active > 0 {
+		return fmt.Errorf("namespace %s still has %d active tasks", name, active)
+	}
+	return m.NamespaceDb.Delete(name)
+}
+
+// resolveNamespace defaults an empty namespace and checks that it exists.
+func (m *Manager) resolveNamespace(name *string) error {
+	*name = namespace.OrDefault(*name)
+	if _, err := m.GetNamespace(*name); err != nil {
+		return fmt.Errorf("namespace %s not found", *name)
+	}
+	return nil
+}
+
+// objectKey is the key of a named object in its store. Names are unique
+// within a namespace, so the same name can be used in several of them.
+func objectKey(ns, name string) string {
+	return namespace.OrDefault(ns) + "/" + name
+}
+
+// rekey moves the objects stored under their name alone, as they were
+// before names were scoped to a namespace, to their objectKey.
+func rekey[T any](db store.Store[T], id func(T) (string, string)) error {
+	objects, err := db.List()
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		ns, name := id(o)
+		if _, err := db.Get(name); err != nil {
+			continue
+		}
+		if err := db.Put(objectKey(ns, name), o); err != nil {
+			return err
+		}
+		if err := db.Delete(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// namespaceUsage sums the requests of the active tasks of a namespace.
+// Tasks being evicted are left out, as they are on their way out.
+func (m *Manager) namespaceUsage(name string) namespace.Usage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u := namespace.Usage{}
+	for _, t := range m.GetActiveTasks() {
+		if namespace.OrDefault(t.Namespace) == name && !m.evicted[t.ID] {
+			u.Add(*t)
+		}
+	}
+	return u
+}
+
+// CheckQuota returns an error if adding the tasks, and removing the
+// replaced ones, would take their namespace over its quota. All tasks
+// must belong to the same namespace.
+func (m *Manager) CheckQuota(name string, tasks []task.Task, replaced []task.Task) error {
+	ns, err := m.GetNamespace(name)
+	if err != nil {
+		return fmt.Errorf("namespace %s not found", name)
+	}
+
+	u := ns.Usage
+	for _, t := range tasks {
+		u.Add(t)
+	}
+	for _, t := range replaced {
+		u.Remove(t)
+	}
+
+	if err := ns.Quota.Check(u); err != nil {
+		return fmt.Errorf("namespace %s: %w", name, err)
+	}
+	return nil
+}
+
+// SubmitTask queues a new task after checking it against the quota of its
+// namespace.
+func (m *Manager) SubmitTask(te task.TaskEvent) error {
+	m.quotaMu.Lock()
+	defer m.quotaMu.Unlock()
+
+	if err := m.resolveNamespace(&te.Task.Namespace); err != nil {
+		return err
+	}
+	if err := m.checkReferences(te.Task.Namespace, te.Task); err != nil {
+		return err
+	}
+	if err := m.CheckQuota(te.Task.Namespace, []task.Task{te.Task}, nil); err != nil {
+		return err
+	}
+
+	m.AddTask(te)
+	return nil
+}
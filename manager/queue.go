@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// TaskQueue is the manager's queue of pending task events. Stop events come
+// first, since they free capacity, then events of higher priority tasks,
+// and events of equal priority in the order they were queued. The events
+// of a gang are queued and handed out together, at the priority of their
+// most important task.
+//
+// An event can be held back until a given time, which keeps a task that
+// cannot be scheduled yet from blocking the tasks queued behind it.
+type TaskQueue struct {
+	items taskHeap
+	seq   uint64
+}
+
+type queueItem struct {
+	events    []task.TaskEvent
+	stop      bool
+	priority  int
+	seq       uint64
+	notBefore time.Time
+}
+
+func NewTaskQueue() *TaskQueue {
+	return &TaskQueue{}
+}
+
+func (q *TaskQueue) Len() int {
+	return q.items.Len()
+}
+
+// Push queues an event, ready right away.
+func (q *TaskQueue) Push(te task.TaskEvent) {
+	q.PushAfter(te, time.Time{})
+}
+
+// PushAfter queues an event that is not handed out before notBefore.
+func (q *TaskQueue) PushAfter(te task.TaskEvent, notBefore time.Time) {
+	q.PushGang([]task.TaskEvent{te}, notBefore)
+}
+
+// PushGang queues events that are handed out together, not before
+// notBefore.
+func (q *TaskQueue) PushGang(events []task.TaskEvent, notBefore time.Time) {
+	it := &queueItem{events: events, stop: events[0].State == task.Completed, notBefore: notBefore}
+	for i, te := range events {
+		if i == 0 || te.Task.Priority > it.priority {
+			it.priority = te.Task.Priority
+		}
+	}
+
+	q.seq++
+	it.seq = q.seq
+	heap.Push(&q.items, it)
+}
+
+// Wake makes every held event ready, for when whatever held it back may
+// have changed.
+func (q *TaskQueue) Wake() {
+	for _, it := range q.items {
+		it.notBefore = time.Time{}
+	}
+}
+
+// Pop removes and returns the first event, or gang of events, that is
+// ready at now.
+func (q *TaskQueue) Pop(now time.Time) ([]task.TaskEvent, bool) {
+	var held []*queueItem
+	defer func() {
+		for _, it := range held {
+			heap.Push(&q.items, it)
+		}
+	}()
+
+	for q.items.Len() > 0 {
+		it := heap.Pop(&q.items).(*queueItem)
+		if it.notBefore.After(now) {
+			held = append(held, it)
+			continue
+		}
+		return it.events, true
+	}
+	return nil, false
+}
+
+type taskHeap []*queueItem
+
+func (h taskHeap) Len() int { return len(h) }
+
+func (h taskHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if a.stop != b.stop {
+		return a.stop
+	}
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+func (h taskHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *taskHeap) Push(x any) { *h = append(*h, x.(*queueItem)) }
+
+func (h *taskHeap) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestTaskQueue(t *testing.T) {
+	now := time.Now()
+
+	type push struct {
+		names    []string
+		priority []int
+		stop     bool
+		after    time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		pushes []push
+		want   []string
+	}{
+		{
+			name:   "fifo at equal priority",
+			pushes: []push{{names: []string{"a"}}, {names: []string{"b"}}, {names: []string{"c"}}},
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name: "higher priority first",
+			pushes: []push{
+				{names: []string{"low"}, priority: []int{-1}},
+				{names: []string{"normal"}},
+				{names: []string{"high"}, priority: []int{10}},
+				{names: []string{"high2"}, priority: []int{10}},
+			},
+			want: []string{"high", "high2", "normal", "low"},
+		},
+		{
+			name: "stops before starts",
+			pushes: []push{
+				{names: []string{"start"}, priority: []int{100}},
+				{names: []string{"stop"}, priority: []int{-5}, stop: true},
+			},
+			want: []string{"stop", "start"},
+		},
+		{
+			name: "held events wait",
+			pushes: []push{
+				{names: []string{"held"}, priority: []int{10}, after: time.Minute},
+				{names: []string{"ready"}},
+				{names: []string{"past"}, after: -time.Minute},
+			},
+			want: []string{"ready", "past"},
+		},
+		{
+			name: "gang at the priority of its most important task",
+			pushes: []push{
+				{names: []string{"single"}, priority: []int{5}},
+				{names: []string{"g0", "g1"}, priority: []int{0, 7}},
+			},
+			want: []string{"g0+g1", "single"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewTaskQueue()
+			for _, p := range tt.pushes {
+				events := []task.TaskEvent{}
+				for i, name := range p.names {
+					te := task.TaskEvent{State: task.Scheduled, Task: task.Task{Name: name}}
+					if p.stop {
+						te.State = task.Completed
+					}
+					if p.priority != nil {
+						te.Task.Priority = p.priority[i]
+					}
+					events = append(events, te)
+				}
+				var notBefore time.Time
+				if p.after != 0 {
+					notBefore = now.Add(p.after)
+				}
+				q.PushGang(events, notBefore)
+			}
+
+			if got := drain(q, now); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskQueueWake(t *testing.T) {
+	now := time.Now()
+	q := NewTaskQueue()
+	q.PushAfter(task.TaskEvent{Task: task.Task{Name: "held", Priority: 1}}, now.Add(time.Hour))
+	q.Push(task.TaskEvent{Task: task.Task{Name: "ready"}})
+
+	if got := drain(q, now); !reflect.DeepEqual(got, []string{"ready"}) {
+		t.Fatalf("got %v before waking", got)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("queue holds %d events, want the held one", q.Len())
+	}
+
+	q.Wake()
+	if got := drain(q, now); !reflect.DeepEqual(got, []string{"held"}) {
+		t.Fatalf("got %v after waking", got)
+	}
+	if _, ok := q.Pop(now); ok {
+		t.Fatal("popped from an empty queue")
+	}
+}
+
+// drain pops every event ready at now, naming gangs by their joined task
+// names.
+func drain(q *TaskQueue, now time.Time) []string {
+	names := []string{}
+	for {
+		events, ok := q.Pop(now)
+		if !ok {
+			return names
+		}
+		name := events[0].Task.Name
+		for _, te := range events[1:] {
+			name += "+" + te.Task.Name
+		}
+		names = append(names, name)
+	}
+}
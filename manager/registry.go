@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/hugoleodev/pentagon/secret"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// The keys a registry secret holds its credentials under.
+const (
+	registryUsernameKey = "username"
+	registryPasswordKey = "password"
+)
+
+// lookupRegistrySecret returns the secret named as the registry auth of a
+// task in namespace ns.
+func (m *Manager) lookupRegistrySecret(ns, name string) (*secret.Secret, error) {
+	s, err := m.lookupSecret(ns, task.SecretRef{Secret: name})
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range []string{registryUsernameKey, registryPasswordKey} {
+		if !hasKey(s, k) {
+			return nil, fmt.Errorf("registry secret %s has no key %s", name, k)
+		}
+	}
+	return s, nil
+}
+
+// resolveRegistryAuth opens the registry secret of a task, if it names
+// one, and returns the credentials to hand to the worker starting it.
+func (m *Manager) resolveRegistryAuth(t task.Task) (*task.RegistryCredentials, error) {
+	if t.RegistryAuth == "" {
+		return nil, nil
+	}
+
+	s, err := m.lookupRegistrySecret(t.Namespace, t.RegistryAuth)
+	if err != nil {
+		return nil, err
+	}
+	data, err := m.SecretCipher.Open(s)
+	if err != nil {
+		return nil, err
+	}
+	return &task.RegistryCredentials{Username: data[registryUsernameKey], Password: data[registryPasswordKey]}, nil
+}
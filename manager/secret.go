@@ -0,0 +1,184 @@
+package manager
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/secret"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+// AddSecret seals and stores a new secret.
+func (m *Manager) AddSecret(s *secret.Secret) error {
+	if m.SecretCipher == nil {
+		return secret.ErrDisabled
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&s.Namespace); err != nil {
+		return err
+	}
+
+	if _, err := m.SecretDb.Get(objectKey(s.Namespace, s.Name)); err == nil {
+		return fmt.Errorf("secret %s already exists in namespace %s", s.Name, s.Namespace)
+	}
+
+	s.Version = 1
+	s.CreatedAt = time.Now().UTC()
+	s.UpdatedAt = s.CreatedAt
+	if err := m.SecretCipher.Seal(s); err != nil {
+		return err
+	}
+	return m.SecretDb.Put(objectKey(s.Namespace, s.Name), s)
+}
+
+func (m *Manager) GetSecrets() []*secret.Secret {
+	secrets, err := m.SecretDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of secrets: %v\n", err)
+		return []*secret.Secret{}
+	}
+	return secrets
+}
+
+func (m *Manager) GetSecret(ns, name string) (*secret.Secret, error) {
+	return m.SecretDb.Get(objectKey(ns, name))
+}
+
+// RotateSecret replaces the values of a secret. Tasks already running keep
+// the values they were started with; tasks started from now on get the
+// new ones.
+func (m *Manager) RotateSecret(ns, name string, data map[string]string) (*secret.Secret, error) {
+	if m.SecretCipher == nil {
+		return nil, secret.ErrDisabled
+	}
+	if err := secret.ValidateData(data); err != nil {
+		return nil, err
+	}
+
+	s, err := m.SecretDb.Get(objectKey(ns, name))
+	if err != nil {
+		return nil, err
+	}
+
+	rotated := *s
+	rotated.Data = data
+	rotated.Version++
+	rotated.UpdatedAt = time.Now().UTC()
+	if err := m.SecretCipher.Seal(&rotated); err != nil {
+		return nil, err
+	}
+	if err := m.SecretDb.Put(objectKey(rotated.Namespace, rotated.Name), &rotated); err != nil {
+		return nil, err
+	}
+	return &rotated, nil
+}
+
+// DeleteSecret removes a secret no active task references.
+func (m *Manager) DeleteSecret(ns, name string) error {
+	s, err := m.SecretDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	for _, t := range m.GetActiveTasks() {
+		if namespace.OrDefault(t.Namespace) != s.Namespace {
+			continue
+		}
+		if t.RegistryAuth == name {
+			return fmt.Errorf("secret %s is the registry auth of active task %s", name, t.ID)
+		}
+		for _, ref := range t.Secrets {
+			if ref.Secret == name {
+				return fmt.Errorf("secret %s is used by active task %s", name, t.ID)
+			}
+		}
+	}
+	return m.SecretDb.Delete(objectKey(ns, name))
+}
+
+// checkSecrets returns an error unless every secret reference of the tasks
+// names a secret of namespace ns, and a key it holds.
+func (m *Manager) checkSecrets(ns string, tasks ...task.Task) error {
+	for _, t := range tasks {
+		for _, ref := range t.Secrets {
+			if _, err := m.lookupSecret(ns, ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) lookupSecret(ns string, ref task.SecretRef) (*secret.Secret, error) {
+	if m.SecretCipher == nil {
+		return nil, secret.ErrDisabled
+	}
+
+	s, err := m.SecretDb.Get(objectKey(ns, ref.Secret))
+	if err != nil {
+		return nil, fmt.Errorf("secret %s not found in namespace %s", ref.Secret, namespace.OrDefault(ns))
+	}
+	if ref.Key != "" && !hasKey(s, ref.Key) {
+		return nil, fmt.Errorf("secret %s has no key %s", ref.Secret, ref.Key)
+	}
+	return s, nil
+}
+
+func hasKey(s *secret.Secret, key string) bool {
+	for _, k := range s.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSecrets opens the secrets a task references and returns the
+// values to hand to the worker starting it.
+func (m *Manager) resolveSecrets(t task.Task) ([]task.SecretValue, error) {
+	var values []task.SecretValue
+	for _, ref := range t.Secrets {
+		s, err := m.lookupSecret(t.Namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		data, err := m.SecretCipher.Open(s)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case ref.Env != "":
+			values = append(values, task.SecretValue{Env: ref.Env, Value: data[ref.Key]})
+		case ref.Key != "":
+			values = append(values, task.SecretValue{Path: ref.Path, Value: data[ref.Key]})
+		default:
+			for _, k := range s.Keys {
+				values = append(values, task.SecretValue{Path: path.Join(ref.Path, k), Value: data[k]})
+			}
+		}
+	}
+	return values, nil
+}
+
+// failTask fails a task that cannot be dispatched.
+func (m *Manager) failTask(id uuid.UUID, message string) {
+	m.modifyTask(id, func(t *task.Task) bool {
+		if t.State != task.Pending && t.State != task.Scheduled {
+			return false
+		}
+
+		t.State = task.Failed
+		t.Error = message
+		t.PendingReason = ""
+		t.PendingMessage = ""
+		t.FinishTime = time.Now().UTC()
+		return true
+	})
+}
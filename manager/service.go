@@ -0,0 +1,197 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/service"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddService(s *service.Service) error {
+	if s.UpdateConfig == (service.UpdateConfig{}) {
+		s.UpdateConfig = service.DefaultUpdateConfig()
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&s.Namespace); err != nil {
+		return err
+	}
+	if err := m.checkReferences(s.Namespace, s.Template); err != nil {
+		return err
+	}
+
+	if _, err := m.ServiceDb.Get(objectKey(s.Namespace, s.Name)); err == nil {
+		return fmt.Errorf("service %s already exists in namespace %s", s.Name, s.Namespace)
+	}
+
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now().UTC()
+	s.UpdatedAt = s.CreatedAt
+	s.Status = service.Status{}
+	s.Version = 1
+	s.Update = nil
+
+	if err := m.ServiceDb.Put(objectKey(s.Namespace, s.Name), s); err != nil {
+		return err
+	}
+
+	m.reconcileService(s)
+	return nil
+}
+
+func (m *Manager) GetServices() []*service.Service {
+	services, err := m.ServiceDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of services: %v\n", err)
+		return []*service.Service{}
+	}
+	return services
+}
+
+func (m *Manager) GetService(ns, name string) (*service.Service, error) {
+	return m.ServiceDb.Get(objectKey(ns, name))
+}
+
+func (m *Manager) ScaleService(ns, name string, replicas int) (*service.Service, error) {
+	s, err := m.ServiceDb.Get(objectKey(ns, name))
+	if err != nil {
+		return nil, err
+	}
+	if replicas < 0 {
+		return nil, fmt.Errorf("replicas must not be negative")
+	}
+
+	s.Replicas = replicas
+	s.UpdatedAt = time.Now().UTC()
+	if err := m.ServiceDb.Put(objectKey(s.Namespace, s.Name), s); err != nil {
+		return nil, err
+	}
+
+	m.reconcileService(s)
+	return s, nil
+}
+
+// DeleteService removes the service and stops all of its tasks.
+func (m *Manager) DeleteService(ns, name string) error {
+	s, err := m.ServiceDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	if err := m.ServiceDb.Delete(objectKey(ns, name)); err != nil {
+		return err
+	}
+
+	for _, t := range m.serviceTasks(s) {
+		m.StopTask(t)
+	}
+	return nil
+}
+
+// serviceTasks returns the active tasks of a service, oldest first.
+func (m *Manager) serviceTasks(s *service.Service) []*task.Task {
+	tasks := []*task.Task{}
+	for _, t := range m.GetActiveTasks() {
+		if s.Owns(t) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].StartTime.Before(tasks[j].StartTime)
+	})
+	return tasks
+}
+
+// reconcileService converges the tasks of a service: it carries on a
+// rolling update when one is in progress, otherwise it starts or stops
+// tasks until the number of active tasks matches the replica count.
+func (m *Manager) reconcileService(s *service.Service) {
+	tasks := m.serviceTasks(s)
+	s.Status.Message = ""
+
+	if s.Update.InProgress() {
+		tasks = m.rollingUpdate(s, tasks)
+	} else {
+		tasks = m.scaleService(s, tasks)
+	}
+
+	s.Status = service.Status{Message: s.Status.Message, LastReconciled: time.Now().UTC()}
+	for _, t := range tasks {
+		if t.State == task.Running {
+			s.Status.Running++
+		} else {
+			s.Status.Pending++
+		}
+	}
+	m.ServiceDb.Put(objectKey(s.Namespace, s.Name), s)
+}
+
+func (m *Manager) scaleService(s *service.Service, tasks []*task.Task) []*task.Task {
+	switch {
+	case len(tasks) < s.Replicas:
+		missing := s.Replicas - len(tasks)
+		log.Info().Msgf("Service %s has %d of %d replicas, starting %d", s.Name, len(tasks), s.Replicas, missing)
+		for i := 0; i < missing; i++ {
+			t := m.startServiceTask(s)
+			if t == nil {
+				break
+			}
+			tasks = append(tasks, t)
+		}
+	case len(tasks) > s.Replicas:
+		extra := len(tasks) - s.Replicas
+		log.Info().Msgf("Service %s has %d of %d replicas, stopping %d", s.Name, len(tasks), s.Replicas, extra)
+
+		sortForScaleDown(tasks)
+		for _, t := range tasks[:extra] {
+			m.StopTask(t)
+		}
+		tasks = tasks[extra:]
+	}
+
+	return tasks
+}
+
+// sortForScaleDown orders the tasks of a service in the order they are
+// stopped when scaling down: tasks that have not started yet first, then
+// the most recently started ones.
+func sortForScaleDown(tasks []*task.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if (a.State == task.Running) != (b.State == task.Running) {
+			return a.State != task.Running
+		}
+		return a.StartTime.After(b.StartTime)
+	})
+}
+
+// startServiceTask submits a new task for the service. It returns nil,
+// recording why on the service status, when the quota of the service's
+// namespace does not allow it.
+func (m *Manager) startServiceTask(s *service.Service) *task.Task {
+	t := s.NewTask()
+	err := m.SubmitTask(task.TaskEvent{
+		ID:        uuid.New(),
+		State:     task.Scheduled,
+		Timestamp: time.Now().UTC(),
+		Task:      *t,
+	})
+	if err != nil {
+		log.Info().Msgf("Unable to start a task for service %s: %v", s.Name, err)
+		s.Status.Message = err.Error()
+		return nil
+	}
+	return t
+}
+
+func (m *Manager) reconcileServices() {
+	for _, s := range m.GetServices() {
+		m.reconcileService(s)
+	}
+}
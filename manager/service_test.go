@@ -0,0 +1,27 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestSortForScaleDown(t *testing.T) {
+	now := time.Now()
+	oldest := &task.Task{Name: "oldest", State: task.Running, StartTime: now.Add(-3 * time.Hour)}
+	middle := &task.Task{Name: "middle", State: task.Running, StartTime: now.Add(-2 * time.Hour)}
+	newest := &task.Task{Name: "newest", State: task.Running, StartTime: now.Add(-1 * time.Hour)}
+	pending := &task.Task{Name: "pending", State: task.Pending}
+	scheduled := &task.Task{Name: "scheduled", State: task.Scheduled}
+
+	tasks := []*task.Task{oldest, newest, pending, middle, scheduled}
+	sortForScaleDown(tasks)
+
+	want := []string{"pending", "scheduled", "newest", "middle", "oldest"}
+	for i, name := range want {
+		if tasks[i].Name != name {
+			t.Fatalf("task %d is %s, want %s", i, tasks[i].Name, name)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package manager
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/hugoleodev/pentagon/service"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// UpdateService starts a rolling update of a service to a new task
+// template. A nil config keeps the service's current update config.
+func (m *Manager) UpdateService(ns, name string, template task.Task, cfg *service.UpdateConfig) (*service.Service, error) {
+	s, err := m.ServiceDb.Get(objectKey(ns, name))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Update.InProgress() {
+		return nil, fmt.Errorf("service %s already has an update %s", s.Name, s.Update.State)
+	}
+	if template.Image == "" {
+		return nil, fmt.Errorf("service %s: template image is required", s.Name)
+	}
+	if err := template.ValidateScheduling(); err != nil {
+		return nil, fmt.Errorf("service %s: %w", s.Name, err)
+	}
+	if err := m.checkReferences(s.Namespace, template); err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		s.UpdateConfig = *cfg
+	}
+
+	log.Info().Msgf("Starting rolling update of service %s from version %d", s.Name, s.Version)
+	m.startUpdate(s, template, "")
+	m.reconcileService(s)
+
+	return s, nil
+}
+
+// RollbackService rolls a service back to the template it had before its
+// last update.
+func (m *Manager) RollbackService(ns, name string) (*service.Service, error) {
+	s, err := m.ServiceDb.Get(objectKey(ns, name))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Update == nil {
+		return nil, fmt.Errorf("service %s has no previous version to roll back to", s.Name)
+	}
+	if s.Update.State == service.UpdateRollingBack {
+		return nil, fmt.Errorf("service %s is already rolling back", s.Name)
+	}
+	if s.Update.State == service.UpdateRolledBack {
+		return nil, fmt.Errorf("service %s was already rolled back", s.Name)
+	}
+
+	m.startRollback(s, "manual rollback")
+	m.reconcileService(s)
+
+	return s, nil
+}
+
+// startUpdate moves the service to a new template. The new version is
+// above any the service had, so the tasks of an update that was rolled
+// back are never taken for tasks of this one.
+func (m *Manager) startUpdate(s *service.Service, template task.Task, message string) {
+	version := s.Version
+	if s.Update != nil {
+		version = max(version, s.Update.FromVersion, s.Update.ToVersion)
+	}
+
+	s.Update = &service.Update{
+		State:            service.UpdateInProgress,
+		FromVersion:      s.Version,
+		ToVersion:        version + 1,
+		PreviousTemplate: s.Template,
+		Total:            s.Replicas,
+		Message:          message,
+		StartedAt:        time.Now().UTC(),
+	}
+	s.Template = template
+	s.Version = version + 1
+	s.UpdatedAt = s.Update.StartedAt
+}
+
+// startRollback moves the service back to the template and version it had
+// before its last update, so the tasks still running them are kept and
+// only those of the update are replaced. The template rolled back from is
+// not recorded, leaving nothing further to roll back to.
+func (m *Manager) startRollback(s *service.Service, message string) {
+	u := s.Update
+	s.Update = &service.Update{
+		State:            service.UpdateRollingBack,
+		FromVersion:      s.Version,
+		ToVersion:        u.FromVersion,
+		PreviousTemplate: u.PreviousTemplate,
+		Total:            s.Replicas,
+		Message:          message,
+		StartedAt:        time.Now().UTC(),
+	}
+	s.Template = u.PreviousTemplate
+	s.Version = u.FromVersion
+	s.UpdatedAt = s.Update.StartedAt
+}
+
+// rollingUpdate replaces tasks of older versions with tasks of the current
+// version in batches bounded by the service's max surge and max
+// unavailable settings. New tasks must be running and healthy before old
+// ones are stopped. Too many failed new tasks roll the update back.
+func (m *Manager) rollingUpdate(s *service.Service, tasks []*task.Task) []*task.Task {
+	u := s.Update
+	cfg := s.UpdateConfig
+
+	var old, current []*task.Task
+	for _, t := range tasks {
+		if service.TaskVersion(t) == s.Version {
+			current = append(current, t)
+		} else {
+			old = append(old, t)
+		}
+	}
+
+	m.recordUpdateFailures(s)
+
+	healthy := 0
+	kept := []*task.Task{}
+	for _, t := range current {
+		if t.State == task.Running && taskHealthy(t) {
+			healthy++
+			kept = append(kept, t)
+			continue
+		}
+
+		if t.State == task.Running && time.Since(t.StartTime) > cfg.HealthTimeout() {
+			log.Info().Msgf("Task %s of service %s did not become healthy within %v", t.ID, s.Name, cfg.HealthTimeout())
+			m.recordUpdateFailure(u, t)
+			m.StopTask(t)
+			continue
+		}
+
+		kept = append(kept, t)
+	}
+	current = kept
+
+	if u.Failed > cfg.FailureThreshold {
+		message := fmt.Sprintf("%d new tasks failed, exceeding the threshold of %d", u.Failed, cfg.FailureThreshold)
+		if u.State == service.UpdateInProgress {
+			log.Info().Msgf("Rolling back service %s: %s", s.Name, message)
+			m.startRollback(s, message)
+			return append(old, current...)
+		}
+
+		log.Info().Msgf("Rollback of service %s is failing: %s", s.Name, message)
+		u.Message = "rollback failed: " + message
+		return append(old, current...)
+	}
+
+	available := healthy
+	for _, t := range old {
+		if t.State == task.Running {
+			available++
+		}
+	}
+
+	toCreate := s.Replicas - len(current)
+	if surge := s.Replicas + cfg.MaxSurge - len(old) - len(current); surge < toCreate {
+		toCreate = surge
+	}
+	for i := 0; i < toCreate; i++ {
+		t := m.startServiceTask(s)
+		if t == nil {
+			break
+		}
+		current = append(current, t)
+	}
+
+	// Old tasks that are not running cost no availability and go first.
+	sort.SliceStable(old, func(i, j int) bool {
+		return old[i].State != task.Running && old[j].State == task.Running
+	})
+
+	minAvailable := s.Replicas - cfg.MaxUnavailable
+	remaining := []*task.Task{}
+	for _, t := range old {
+		if t.State == task.Running {
+			if available-1 < minAvailable {
+				remaining = append(remaining, t)
+				continue
+			}
+			available--
+		}
+		log.Info().Msgf("Replacing task %s of service %s (version %d)", t.ID, s.Name, service.TaskVersion(t))
+		m.StopTask(t)
+	}
+
+	u.Updated = healthy
+	u.Total = s.Replicas
+
+	if len(remaining) == 0 && healthy >= s.Replicas {
+		if u.State == service.UpdateRollingBack {
+			u.State = service.UpdateRolledBack
+		} else {
+			u.State = service.UpdateCompleted
+		}
+		u.CompletedAt = time.Now().UTC()
+		log.Info().Msgf("Update of service %s to version %d %s", s.Name, s.Version, u.State)
+	}
+
+	return append(remaining, current...)
+}
+
+// recordUpdateFailures counts the tasks of the current version that failed
+// since the update started.
+func (m *Manager) recordUpdateFailures(s *service.Service) {
+	for _, t := range m.GetTasksByState(task.Failed) {
+		if s.Owns(t) && service.TaskVersion(t) == s.Version && !t.FinishTime.Before(s.Update.StartedAt) {
+			m.recordUpdateFailure(s.Update, t)
+		}
+	}
+}
+
+func (m *Manager) recordUpdateFailure(u *service.Update, t *task.Task) {
+	for _, id := range u.FailedTasks {
+		if id == t.ID {
+			return
+		}
+	}
+	u.FailedTasks = append(u.FailedTasks, t.ID)
+	u.Failed++
+}
+
+// checkTaskHealth probes the task's health check endpoint through the
+// first host port published for it. Tasks without a health check are
+// healthy as soon as they run.
+func (m *Manager) checkTaskHealth(t *task.Task) bool {
+	if t.HealthCheck == "" {
+		return true
+	}
+
+	health := task.Unhealthy
+	defer func() {
+		m.modifyTask(t.ID, func(stored *task.Task) bool {
+			if stored.Health == health {
+				return false
+			}
+			stored.Health = health
+			return true
+		})
+	}()
+
+	w, ok := m.taskWorker(t.ID)
+	if !ok {
+		return false
+	}
+	host, _, err := net.SplitHostPort(w)
+	if err != nil {
+		return false
+	}
+
+	hostPort := firstHostPort(t.HostPorts)
+	if hostPort == "" {
+		log.Info().Msgf("Task %s has a health check but no published port", t.ID)
+		return false
+	}
+
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(host, hostPort), t.HealthCheck)
+	hc := http.Client{Timeout: healthCheckTimeout}
+	resp, err := hc.Get(url)
+	if err != nil {
+		log.Info().Msgf("Health check of task %s at %s failed: %v", t.ID, url, err)
+		return false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 399 {
+		log.Info().Msgf("Health check of task %s at %s returned %d", t.ID, url, resp.StatusCode)
+		return false
+	}
+
+	health = task.Healthy
+	return true
+}
+
+func firstHostPort(ports nat.PortMap) string {
+	keys := make([]string, 0, len(ports))
+	for p := range ports {
+		keys = append(keys, string(p))
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, binding := range ports[nat.Port(k)] {
+			if binding.HostPort != "" {
+				return binding.HostPort
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/job"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/hugoleodev/pentagon/workflow"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) AddWorkflow(w *workflow.Workflow) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if err := m.resolveNamespace(&w.Namespace); err != nil {
+		return err
+	}
+	for _, s := range w.Steps {
+		if err := m.checkReferences(w.Namespace, s.Template); err != nil {
+			return fmt.Errorf("workflow %s: step %s: %w", w.Name, s.Name, err)
+		}
+	}
+
+	if _, err := m.WorkflowDb.Get(objectKey(w.Namespace, w.Name)); err == nil {
+		return fmt.Errorf("workflow %s already exists in namespace %s", w.Name, w.Namespace)
+	}
+
+	w.ID = uuid.New()
+	w.CreatedAt = time.Now().UTC()
+	w.Status = workflow.Status{
+		State:     workflow.Running,
+		Steps:     make(map[string]workflow.StepStatus, len(w.Steps)),
+		StartedAt: w.CreatedAt,
+	}
+	for _, s := range w.Steps {
+		w.Status.Steps[s.Name] = workflow.StepStatus{State: workflow.Waiting}
+	}
+
+	if err := m.WorkflowDb.Put(objectKey(w.Namespace, w.Name), w); err != nil {
+		return err
+	}
+
+	m.reconcileWorkflow(w)
+	return nil
+}
+
+func (m *Manager) GetWorkflows() []*workflow.Workflow {
+	workflows, err := m.WorkflowDb.List()
+	if err != nil {
+		log.Info().Msgf("Error getting list of workflows: %v\n", err)
+		return []*workflow.Workflow{}
+	}
+	return workflows
+}
+
+func (m *Manager) GetWorkflow(ns, name string) (*workflow.Workflow, error) {
+	return m.WorkflowDb.Get(objectKey(ns, name))
+}
+
+// DeleteWorkflow removes the workflow and stops any of its tasks still active.
+func (m *Manager) DeleteWorkflow(ns, name string) error {
+	w, err := m.WorkflowDb.Get(objectKey(ns, name))
+	if err != nil {
+		return err
+	}
+
+	if err := m.WorkflowDb.Delete(objectKey(ns, name)); err != nil {
+		return err
+	}
+
+	for _, t := range m.GetActiveTasks() {
+		if w.Owns(t) {
+			m.StopTask(t)
+		}
+	}
+	return nil
+}
+
+// reconcileWorkflow records the outcome of running steps, skips the steps
+// downstream of a failure and starts the steps whose dependencies have all
+// succeeded. It keeps going until a pass changes nothing, so a failure is
+// propagated through the whole graph at once.
+func (m *Manager) reconcileWorkflow(w *workflow.Workflow) {
+	if w.Finished() {
+		return
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, s := range w.Steps {
+			ss := w.Status.Steps[s.Name]
+
+			switch ss.State {
+			case workflow.Running:
+				t, err := m.TaskDb.Get(ss.TaskID.String())
+				switch {
+				case err != nil:
+					ss.State = workflow.Failed
+					ss.Message = "task not found"
+				case job.TaskSucceeded(t):
+					ss.State = workflow.Succeeded
+				case job.TaskFailed(t):
+					ss.State = workflow.Failed
+					ss.ExitCode = t.ExitCode
+					ss.Message = t.Error
+				case job.TaskCancelled(t):
+					ss.State = workflow.Failed
+					ss.Message = "task stopped: " + t.StopReason
+				default:
+					continue
+				}
+				ss.CompletedAt = time.Now().UTC()
+				log.Info().Msgf("Step %s of workflow %s %s", s.Name, w.Name, ss.State)
+
+			case workflow.Waiting:
+				blocked, ready := "", true
+				for _, dep := range s.DependsOn {
+					switch w.Status.Steps[dep].State {
+					case workflow.Failed, workflow.Skipped:
+						blocked = dep
+					case workflow.Succeeded:
+					default:
+						ready = false
+					}
+				}
+
+				switch {
+				case blocked != "":
+					ss.State = workflow.Skipped
+					ss.Message = fmt.Sprintf("dependency %s did not succeed", blocked)
+					ss.CompletedAt = time.Now().UTC()
+					log.Info().Msgf("Skipping step %s of workflow %s: %s", s.Name, w.Name, ss.Message)
+				case ready:
+					t := w.NewTask(s)
+					err := m.SubmitTask(task.TaskEvent{
+						ID:        uuid.New(),
+						State:     task.Scheduled,
+						Timestamp: time.Now().UTC(),
+						Task:      *t,
+					})
+					if err != nil {
+						// Retried on the next reconciliation.
+						if ss.Message != err.Error() {
+							log.Info().Msgf("Unable to start step %s of workflow %s: %v", s.Name, w.Name, err)
+							ss.Message = err.Error()
+							w.Status.Steps[s.Name] = ss
+						}
+						continue
+					}
+					ss.State = workflow.Running
+					ss.TaskID = t.ID
+					ss.Message = ""
+					ss.StartedAt = time.Now().UTC()
+					log.Info().Msgf("Starting task %s for step %s of workflow %s", t.ID, s.Name, w.Name)
+				default:
+					continue
+				}
+
+			default:
+				continue
+			}
+
+			w.Status.Steps[s.Name] = ss
+			changed = true
+		}
+	}
+
+	var failed []string
+	for _, s := range w.Steps {
+		ss := w.Status.Steps[s.Name]
+		if !workflow.StepFinished(ss.State) {
+			m.WorkflowDb.Put(objectKey(w.Namespace, w.Name), w)
+			return
+		}
+		if ss.State == workflow.Failed {
+			failed = append(failed, s.Name)
+		}
+	}
+
+	w.Status.CompletedAt = time.Now().UTC()
+	if len(failed) == 0 {
+		w.Status.State = workflow.Succeeded
+		w.Status.Message = fmt.Sprintf("all %d steps succeeded", len(w.Steps))
+	} else {
+		w.Status.State = workflow.Failed
+		w.Status.Message = fmt.Sprintf("failed steps: %s", strings.Join(failed, ", "))
+	}
+	log.Info().Msgf("Workflow %s %s: %s", w.Name, w.Status.State, w.Status.Message)
+
+	m.WorkflowDb.Put(objectKey(w.Namespace, w.Name), w)
+}
+
+func (m *Manager) reconcileWorkflows() {
+	for _, w := range m.GetWorkflows() {
+		m.reconcileWorkflow(w)
+	}
+}
@@ -0,0 +1,275 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Labels set on every task created from a manifest, used to find the tasks
+// belonging to the manifest and to each of its specs on later applies.
+const (
+	LabelManifest = "pentagon.io/manifest"
+	LabelName     = "pentagon.io/manifest-name"
+	LabelReplica  = "pentagon.io/manifest-replica"
+	LabelHash     = "pentagon.io/manifest-hash"
+)
+
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+)
+
+type Manifest struct {
+	// Name identifies the manifest. Applying it only considers the tasks
+	// created from a manifest of the same name, so several manifests can
+	// share a namespace without pruning each other's tasks.
+	Name string `json:"name"`
+	// Namespace holds every task of the manifest. Applying it only
+	// considers the tasks already in that namespace.
+	Namespace string `json:"namespace,omitempty"`
+	Tasks     []Spec `json:"tasks"`
+}
+
+type Spec struct {
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	PullPolicy    task.PullPolicy   `json:"image_pull_policy,omitempty"`
+	RegistryAuth  string            `json:"registry_auth,omitempty"`
+	Cpu           float64           `json:"cpu,omitempty"`
+	Memory        int64             `json:"memory,omitempty"`
+	Disk          int64             `json:"disk,omitempty"`
+	Ports         []string          `json:"ports,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	Secrets       []task.SecretRef  `json:"secrets,omitempty"`
+	Configs       []task.ConfigRef  `json:"configs,omitempty"`
+	Replicas      *int              `json:"replicas,omitempty"`
+	RestartPolicy string            `json:"restart_policy,omitempty"`
+	Priority      int               `json:"priority,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Placement     *task.Placement   `json:"placement,omitempty"`
+	Tolerations   []task.Toleration `json:"tolerations,omitempty"`
+}
+
+type Change struct {
+	Action  string     `json:"action"`
+	Name    string     `json:"name"`
+	Replica int        `json:"replica"`
+	Current *task.Task `json:"current,omitempty"`
+	Desired *task.Task `json:"desired,omitempty"`
+}
+
+type Result struct {
+	DryRun  bool     `json:"dry_run"`
+	Changes []Change `json:"changes"`
+}
+
+func (m Manifest) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("manifest name is required")
+	}
+	if err := labels.ValidateValue(m.Name); err != nil {
+		return fmt.Errorf("manifest name: %w", err)
+	}
+
+	names := make(map[string]bool)
+
+	for i, s := range m.Tasks {
+		if s.Name == "" {
+			return fmt.Errorf("tasks[%d]: name is required", i)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("tasks[%d]: duplicate name %q", i, s.Name)
+		}
+		names[s.Name] = true
+
+		if s.Image == "" {
+			return fmt.Errorf("task %s: image is required", s.Name)
+		}
+		if err := task.ValidatePullPolicy(s.PullPolicy); err != nil {
+			return fmt.Errorf("task %s: %w", s.Name, err)
+		}
+		if err := s.Placement.Validate(); err != nil {
+			return fmt.Errorf("task %s: %w", s.Name, err)
+		}
+		for _, tol := range s.Tolerations {
+			if err := tol.Validate(); err != nil {
+				return fmt.Errorf("task %s: %w", s.Name, err)
+			}
+		}
+		for _, ref := range s.Secrets {
+			if err := ref.Validate(); err != nil {
+				return fmt.Errorf("task %s: %w", s.Name, err)
+			}
+		}
+		for _, ref := range s.Configs {
+			if err := ref.Validate(); err != nil {
+				return fmt.Errorf("task %s: %w", s.Name, err)
+			}
+		}
+		if s.Replicas != nil && *s.Replicas < 0 {
+			return fmt.Errorf("task %s: replicas must not be negative", s.Name)
+		}
+		for _, p := range s.Ports {
+			if _, err := nat.NewPort(nat.SplitProtoPort(p)); err != nil {
+				return fmt.Errorf("task %s: invalid port %q: %w", s.Name, p, err)
+			}
+		}
+		if err := labels.ValidateUnreserved(s.Labels); err != nil {
+			return fmt.Errorf("task %s: %w", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s Spec) replicas() int {
+	if s.Replicas == nil {
+		return 1
+	}
+	return *s.Replicas
+}
+
+// Hash identifies the task template of a spec; replica count changes do
+// not alter it.
+func (s Spec) Hash() string {
+	s.Replicas = nil
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// NewTask builds the task for one replica of the spec in the named
+// manifest.
+func (s Spec) NewTask(manifest string, replica int) *task.Task {
+	labels := map[string]string{}
+	for k, v := range s.Labels {
+		labels[k] = v
+	}
+	labels[LabelManifest] = manifest
+	labels[LabelName] = s.Name
+	labels[LabelReplica] = strconv.Itoa(replica)
+	labels[LabelHash] = s.Hash()
+
+	var env []string
+	for k, v := range s.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	var ports nat.PortSet
+	if len(s.Ports) > 0 {
+		ports = nat.PortSet{}
+		for _, p := range s.Ports {
+			port, _ := nat.NewPort(nat.SplitProtoPort(p))
+			ports[port] = struct{}{}
+		}
+	}
+
+	t := &task.Task{
+		ID:            uuid.New(),
+		Name:          s.Name,
+		State:         task.Pending,
+		Image:         s.Image,
+		Cpu:           s.Cpu,
+		Memory:        s.Memory,
+		Disk:          s.Disk,
+		ExposedPorts:  ports,
+		Env:           env,
+		Secrets:       s.Secrets,
+		Configs:       s.Configs,
+		RestartPolicy: s.RestartPolicy,
+		Priority:      s.Priority,
+		Labels:        labels,
+		Placement:     s.Placement,
+		Tolerations:   s.Tolerations,
+	}
+	t.ImagePullPolicy = s.PullPolicy
+	t.RegistryAuth = s.RegistryAuth
+	return t
+}
+
+// Diff compares the manifest with the active tasks of the cluster and
+// returns the changes needed to converge. Only the tasks created from this
+// manifest are considered; those of specs that are no longer in it are
+// only deleted when prune is set.
+func Diff(m Manifest, active []*task.Task, prune bool) []Change {
+	existing := make(map[string]map[int]*task.Task)
+	for _, t := range active {
+		if t.Labels[LabelManifest] != m.Name {
+			continue
+		}
+		name, ok := t.Labels[LabelName]
+		if !ok {
+			continue
+		}
+		replica, err := strconv.Atoi(t.Labels[LabelReplica])
+		if err != nil {
+			continue
+		}
+		if existing[name] == nil {
+			existing[name] = make(map[int]*task.Task)
+		}
+		existing[name][replica] = t
+	}
+
+	changes := []Change{}
+	for _, s := range m.Tasks {
+		current := existing[s.Name]
+		delete(existing, s.Name)
+		hash := s.Hash()
+
+		for i := 0; i < s.replicas(); i++ {
+			t, ok := current[i]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Action: ActionCreate, Name: s.Name, Replica: i, Desired: s.NewTask(m.Name, i)})
+			case t.Labels[LabelHash] != hash:
+				changes = append(changes, Change{Action: ActionUpdate, Name: s.Name, Replica: i, Current: t, Desired: s.NewTask(m.Name, i)})
+			default:
+				changes = append(changes, Change{Action: ActionUnchanged, Name: s.Name, Replica: i, Current: t})
+			}
+			delete(current, i)
+		}
+
+		changes = append(changes, deletions(s.Name, current)...)
+	}
+
+	if prune {
+		names := make([]string, 0, len(existing))
+		for name := range existing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			changes = append(changes, deletions(name, existing[name])...)
+		}
+	}
+
+	return changes
+}
+
+func deletions(name string, tasks map[int]*task.Task) []Change {
+	replicas := make([]int, 0, len(tasks))
+	for replica := range tasks {
+		replicas = append(replicas, replica)
+	}
+	sort.Ints(replicas)
+
+	changes := []Change{}
+	for _, replica := range replicas {
+		changes = append(changes, Change{Action: ActionDelete, Name: name, Replica: replica, Current: tasks[replica]})
+	}
+	return changes
+}
@@ -0,0 +1,82 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestDiff(t *testing.T) {
+	two := 2
+	web := Spec{Name: "web", Image: "nginx:1.25", Replicas: &two}
+	oldWeb := Spec{Name: "web", Image: "nginx:1.24", Replicas: &two}
+	old := Spec{Name: "old", Image: "busybox"}
+	m := Manifest{Name: "demo", Tasks: []Spec{web}}
+
+	tests := []struct {
+		name   string
+		active []*task.Task
+		prune  bool
+		want   []string
+	}{
+		{
+			name: "create",
+			want: []string{"create web/0", "create web/1"},
+		},
+		{
+			name:   "unchanged",
+			active: []*task.Task{web.NewTask("demo", 0), web.NewTask("demo", 1)},
+			want:   []string{"unchanged web/0", "unchanged web/1"},
+		},
+		{
+			name:   "update",
+			active: []*task.Task{oldWeb.NewTask("demo", 0), web.NewTask("demo", 1)},
+			want:   []string{"update web/0", "unchanged web/1"},
+		},
+		{
+			name:   "scale down",
+			active: []*task.Task{web.NewTask("demo", 0), web.NewTask("demo", 1), web.NewTask("demo", 2)},
+			want:   []string{"unchanged web/0", "unchanged web/1", "delete web/2"},
+		},
+		{
+			name:   "prune",
+			active: []*task.Task{web.NewTask("demo", 0), web.NewTask("demo", 1), old.NewTask("demo", 0)},
+			prune:  true,
+			want:   []string{"unchanged web/0", "unchanged web/1", "delete old/0"},
+		},
+		{
+			name:   "no prune",
+			active: []*task.Task{web.NewTask("demo", 0), web.NewTask("demo", 1), old.NewTask("demo", 0)},
+			want:   []string{"unchanged web/0", "unchanged web/1"},
+		},
+		{
+			name:   "other manifest",
+			active: []*task.Task{web.NewTask("other", 0), web.NewTask("other", 1), old.NewTask("other", 0)},
+			prune:  true,
+			want:   []string{"create web/0", "create web/1"},
+		},
+		{
+			name:   "unlabelled task",
+			active: []*task.Task{{Name: "web", Image: "nginx:1.25"}},
+			prune:  true,
+			want:   []string{"create web/0", "create web/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, c := range Diff(m, tt.active, tt.prune) {
+				got = append(got, fmt.Sprintf("%s %s/%d", c.Action, c.Name, c.Replica))
+				if c.Desired != nil && c.Desired.Labels[LabelManifest] != m.Name {
+					t.Errorf("%s %s/%d: desired task belongs to manifest %q", c.Action, c.Name, c.Replica, c.Desired.Labels[LabelManifest])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got changes %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
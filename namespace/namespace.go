@@ -0,0 +1,125 @@
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Default is the namespace of tasks and objects submitted without one. It
+// always exists.
+const Default = "default"
+
+// Namespace groups the tasks of a team and bounds the resources they may
+// request. Services, jobs and other objects belong to a namespace too,
+// though their names stay unique across the cluster.
+type Namespace struct {
+	Name      string    `json:"name"`
+	Quota     Quota     `json:"quota"`
+	Usage     Usage     `json:"usage"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrQuotaExceeded is returned when a submission would take a namespace
+// over its quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota caps the total requests of the active tasks of a namespace. Zero
+// fields are unlimited.
+type Quota struct {
+	Cpu    float64 `json:"cpu,omitempty"`
+	Memory int64   `json:"memory,omitempty"`
+	Disk   int64   `json:"disk,omitempty"`
+	Tasks  int     `json:"tasks,omitempty"`
+}
+
+// Usage is what the active tasks of a namespace request.
+type Usage struct {
+	Cpu    float64 `json:"cpu"`
+	Memory int64   `json:"memory"`
+	Disk   int64   `json:"disk"`
+	Tasks  int     `json:"tasks"`
+}
+
+func (u *Usage) Add(t task.Task) {
+	u.Cpu = roundCpu(u.Cpu + t.Cpu)
+	u.Memory += t.Memory
+	u.Disk += t.Disk
+	u.Tasks++
+}
+
+func (u *Usage) Remove(t task.Task) {
+	u.Cpu = roundCpu(u.Cpu - t.Cpu)
+	u.Memory -= t.Memory
+	u.Disk -= t.Disk
+	u.Tasks--
+}
+
+func (q Quota) Validate() error {
+	if q.Cpu < 0 || q.Memory < 0 || q.Disk < 0 || q.Tasks < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	return nil
+}
+
+// Check returns an error naming every limit the usage goes over.
+func (q Quota) Check(u Usage) error {
+	var exceeded []string
+	if q.Cpu > 0 && u.Cpu > q.Cpu {
+		exceeded = append(exceeded, fmt.Sprintf("cpu %g/%g", u.Cpu, q.Cpu))
+	}
+	if q.Memory > 0 && u.Memory > q.Memory {
+		exceeded = append(exceeded, fmt.Sprintf("memory %d/%d", u.Memory, q.Memory))
+	}
+	if q.Disk > 0 && u.Disk > q.Disk {
+		exceeded = append(exceeded, fmt.Sprintf("disk %d/%d", u.Disk, q.Disk))
+	}
+	if q.Tasks > 0 && u.Tasks > q.Tasks {
+		exceeded = append(exceeded, fmt.Sprintf("tasks %d/%d", u.Tasks, q.Tasks))
+	}
+
+	if len(exceeded) > 0 {
+		return fmt.Errorf("%w: %s", ErrQuotaExceeded, strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// roundCpu rounds to a thousandth of a CPU, so sums of fractional requests
+// compare exactly against the quota.
+func roundCpu(cpu float64) float64 {
+	return math.Round(cpu*1000) / 1000
+}
+
+// ValidateName checks a namespace name: at most 63 lowercase alphanumerics
+// or '-', starting and ending with an alphanumeric.
+func ValidateName(name string) error {
+	if name == "" || len(name) > 63 {
+		return fmt.Errorf("invalid namespace %q", name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		alnum := c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+		if !alnum && (c != '-' || i == 0 || i == len(name)-1) {
+			return fmt.Errorf("invalid namespace %q", name)
+		}
+	}
+	return nil
+}
+
+// OrDefault returns name, or the default namespace when it is empty.
+func OrDefault(name string) string {
+	if name == "" {
+		return Default
+	}
+	return name
+}
+
+// Matches reports whether an object in namespace name falls within the
+// filter ns. An empty filter matches every namespace.
+func Matches(ns string, name string) bool {
+	return ns == "" || OrDefault(ns) == OrDefault(name)
+}
@@ -0,0 +1,14 @@
+package node
+
+import "github.com/hugoleodev/pentagon/image"
+
+// HasImage reports whether the node holds the image in its cache.
+func (n *Node) HasImage(ref string) bool {
+	ref = image.Normalize(ref)
+	for _, r := range n.Images {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,55 @@
 package node
 
+const (
+	Ready = "ready"
+	Down  = "down"
+)
+
 type Node struct {
-	Name            string
-	Ip              string
-	Cores           int
-	Memory          int
-	MemoryAllocated int
-	Disk            int
-	DiskAllocated   int
-	Role            string
-	TaskCount       int
+	Name            string  `json:"name"`
+	Ip              string  `json:"ip"`
+	Api             string  `json:"api"`
+	Cores           int     `json:"cores"`
+	CpuAllocated    float64 `json:"cpu_allocated"`
+	Memory          int     `json:"memory"`
+	MemoryAllocated int     `json:"memory_allocated"`
+	Disk            int     `json:"disk"`
+	DiskAllocated   int     `json:"disk_allocated"`
+	Role            string  `json:"role"`
+	TaskCount       int     `json:"task_count"`
+	Status          string  `json:"status"`
+	// Unschedulable is set when the node is cordoned: its tasks keep
+	// running but no new task is placed on it.
+	Unschedulable bool `json:"unschedulable,omitempty"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Taints      []Taint           `json:"taints,omitempty"`
+	// Images lists the references of the images the node holds, so tasks
+	// can be placed where their image need not be pulled.
+	Images []string `json:"images,omitempty"`
+
+	// Tasks summarises the active tasks placed on the node. The manager
+	// fills it in before scheduling so placement rules and preemption can
+	// take other tasks into account.
+	Tasks []Resident `json:"-"`
+}
+
+// Resident is an active task placed on a node.
+type Resident struct {
+	ID       string
+	Labels   map[string]string
+	Priority int
+	Cpu      float64
+	Memory   int64
+	Disk     int64
+}
+
+func New(name string, api string, role string) *Node {
+	return &Node{
+		Name:   name,
+		Api:    api,
+		Role:   role,
+		Status: Ready,
+	}
 }
@@ -0,0 +1,54 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Taint effects.
+const (
+	// NoSchedule keeps tasks that do not tolerate the taint off the node.
+	NoSchedule = "NoSchedule"
+	// PreferNoSchedule avoids the node for such tasks when others fit.
+	PreferNoSchedule = "PreferNoSchedule"
+	// NoExecute also evicts such tasks already running on the node.
+	NoExecute = "NoExecute"
+)
+
+// Taint repels tasks that do not tolerate it, dedicating a node to the
+// tasks that do.
+type Taint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+func (t Taint) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + t.Effect
+	}
+	return t.Key + "=" + t.Value + ":" + t.Effect
+}
+
+func (t Taint) Validate() error {
+	if t.Key == "" {
+		return fmt.Errorf("taint key is required")
+	}
+	switch t.Effect {
+	case NoSchedule, PreferNoSchedule, NoExecute:
+		return nil
+	}
+	return fmt.Errorf("taint %s: unknown effect %q", t.Key, t.Effect)
+}
+
+// ParseTaint parses a taint written as KEY=VALUE:EFFECT or KEY:EFFECT.
+func ParseTaint(s string) (Taint, error) {
+	kv, effect, ok := strings.Cut(s, ":")
+	if !ok {
+		return Taint{}, fmt.Errorf("invalid taint %q, expected KEY=VALUE:EFFECT", s)
+	}
+
+	key, value, _ := strings.Cut(kv, "=")
+	t := Taint{Key: key, Value: value, Effect: effect}
+	return t, t.Validate()
+}
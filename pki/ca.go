@@ -0,0 +1,344 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Files a CA directory holds. The manager keeps its own certificate next
+// to the CA; workers keep theirs with a copy of the CA certificate.
+const (
+	CACertFile      = "ca.crt"
+	CAKeyFile       = "ca.key"
+	ManagerCertFile = "manager.crt"
+	ManagerKeyFile  = "manager.key"
+	WorkerCertFile  = "worker.crt"
+	WorkerKeyFile   = "worker.key"
+)
+
+// ManagerName is the common name of the manager certificate. Workers only
+// accept clients presenting it.
+const ManagerName = "pentagon-manager"
+
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 365 * 24 * time.Hour
+)
+
+// Usage is what a certificate issued by the CA may be used for.
+type Usage int
+
+const (
+	// Server certificates identify a worker to the manager.
+	Server Usage = iota
+	// Client certificates identify a user to the manager API.
+	Client
+	// Peer certificates identify the manager both as a server, to users
+	// and joining workers, and as a client, to workers.
+	Peer
+)
+
+// CA issues the certificates of a cluster.
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	// CertPEM is the CA certificate as written to ca.crt.
+	CertPEM []byte
+	// Reserved holds the names of the manager, which are never issued to
+	// a worker.
+	Reserved []string
+}
+
+// LoadOrCreateCA loads the CA kept in dir, creating one the first time.
+func LoadOrCreateCA(dir string) (*CA, error) {
+	ca, err := LoadCA(dir)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return ca, err
+	}
+	return CreateCA(dir)
+}
+
+// CreateCA creates a CA and writes it to dir, refusing to replace one.
+func CreateCA(dir string) (*CA, error) {
+	if _, err := os.Stat(filepath.Join(dir, CACertFile)); err == nil {
+		return nil, fmt.Errorf("a CA already exists in %s", dir)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "pentagon-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &CA{Cert: cert, Key: key, CertPEM: encodeCert(der)}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteFiles(dir, map[string][]byte{CACertFile: ca.CertPEM, CAKeyFile: keyPEM}); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// LoadCA loads the CA kept in dir.
+func LoadCA(dir string) (*CA, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, CACertFile))
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseCert(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Cert: cert, Key: key, CertPEM: certPEM}, nil
+}
+
+// Hash identifies the CA by the SHA-256 of its public key, in the form
+// workers pin it with when joining.
+func (ca *CA) Hash() string {
+	return Hash(ca.Cert)
+}
+
+// Hash returns the sha256:<hex> hash of a certificate's public key.
+func Hash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Issue creates a key and a certificate for the subject, returning both as
+// PEM. Hosts become the certificate's DNS and IP names.
+func (ca *CA) Issue(subject pkix.Name, hosts []string, usage Usage) (certPEM []byte, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM, err = ca.sign(subject, hosts, key.Public(), usage)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err = encodeKey(key)
+	return certPEM, keyPEM, err
+}
+
+// SignRequest issues a server certificate for a PEM certificate request,
+// as sent by a joining worker from address. The request's common name is
+// the worker name; the certificate is valid for that name and address
+// only, and never for a name of the manager.
+func (ca *CA) SignRequest(csrPEM []byte, address string) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("invalid certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request: %w", err)
+	}
+
+	name := csr.Subject.CommonName
+	if name == "" || name == ManagerName || ca.reserved(name) {
+		return nil, fmt.Errorf("invalid worker name %q", name)
+	}
+
+	hosts := []string{name}
+	if address != "" && !ca.reserved(address) {
+		hosts = append(hosts, address)
+	}
+
+	requested := csr.DNSNames
+	for _, ip := range csr.IPAddresses {
+		requested = append(requested, ip.String())
+	}
+	for _, h := range requested {
+		if !sameHost(h, name) && !sameHost(h, address) {
+			return nil, fmt.Errorf("worker %s may only be issued its own name and address %s, not %s", name, address, h)
+		}
+	}
+
+	return ca.sign(pkix.Name{CommonName: name}, hosts, csr.PublicKey, Server)
+}
+
+// reserved reports whether host is one of the manager's names.
+func (ca *CA) reserved(host string) bool {
+	for _, r := range ca.Reserved {
+		if sameHost(r, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameHost(a string, b string) bool {
+	if ipA, ipB := net.ParseIP(a), net.ParseIP(b); ipA != nil || ipB != nil {
+		return ipA != nil && ipA.Equal(ipB)
+	}
+	return strings.EqualFold(a, b)
+}
+
+func (ca *CA) sign(subject pkix.Name, hosts []string, pub crypto.PublicKey, usage Usage) ([]byte, error) {
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	switch usage {
+	case Server:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	case Client:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	case Peer:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if h != "" {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, pub, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCert(der), nil
+}
+
+// Hosts returns the names a server listening on address should be reached
+// by: the address itself unless it is a wildcard, the host name and the
+// loopback names.
+func Hosts(address string) []string {
+	hosts := []string{"localhost", "127.0.0.1"}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+	}
+	if address != "" && address != "0.0.0.0" && address != "::" {
+		hosts = append(hosts, address)
+	}
+
+	seen := make(map[string]bool)
+	unique := hosts[:0]
+	for _, h := range hosts {
+		if !seen[strings.ToLower(h)] {
+			seen[strings.ToLower(h)] = true
+			unique = append(unique, h)
+		}
+	}
+	return unique
+}
+
+// WriteFiles writes PEM files to dir, keys readable by the owner only.
+func WriteFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for name, data := range files {
+		mode := os.FileMode(0o644)
+		if strings.HasSuffix(name, ".key") {
+			mode = 0o600
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func parseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("invalid certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,127 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSignRequest(t *testing.T) {
+	ca, err := CreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.Reserved = []string{ManagerName, "manager.example.com", "10.0.0.1"}
+
+	tests := []struct {
+		name    string
+		cn      string
+		dns     []string
+		ips     []string
+		address string
+		wantDNS []string
+		wantIPs []string
+		err     string
+	}{
+		{name: "name and address", cn: "w1", address: "10.0.0.5", wantDNS: []string{"w1"}, wantIPs: []string{"10.0.0.5"}},
+		{name: "own sans", cn: "w1", dns: []string{"W1"}, ips: []string{"10.0.0.5"}, address: "10.0.0.5", wantDNS: []string{"w1"}, wantIPs: []string{"10.0.0.5"}},
+		{name: "no address", cn: "w1", wantDNS: []string{"w1"}},
+		{name: "address of the manager", cn: "w1", address: "10.0.0.1", wantDNS: []string{"w1"}},
+		{name: "empty name", cn: "", address: "10.0.0.5", err: "invalid worker name"},
+		{name: "manager name", cn: ManagerName, address: "10.0.0.5", err: "invalid worker name"},
+		{name: "manager host name", cn: "MANAGER.example.com", address: "10.0.0.5", err: "invalid worker name"},
+		{name: "manager ip as name", cn: "10.0.0.1", address: "10.0.0.5", err: "invalid worker name"},
+		{name: "other dns name", cn: "w1", dns: []string{"w2"}, address: "10.0.0.5", err: "not w2"},
+		{name: "manager dns name", cn: "w1", dns: []string{"manager.example.com"}, address: "10.0.0.5", err: "not manager.example.com"},
+		{name: "other ip", cn: "w1", ips: []string{"10.0.0.6"}, address: "10.0.0.5", err: "not 10.0.0.6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPEM, err := ca.SignRequest(newCSR(t, tt.cn, tt.dns, tt.ips), tt.address)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cert, err := parseCert(certPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cert.Subject.CommonName != tt.cn {
+				t.Fatalf("got common name %q, want %q", cert.Subject.CommonName, tt.cn)
+			}
+			if !reflect.DeepEqual(cert.DNSNames, tt.wantDNS) {
+				t.Fatalf("got DNS names %v, want %v", cert.DNSNames, tt.wantDNS)
+			}
+			var ips []string
+			for _, ip := range cert.IPAddresses {
+				ips = append(ips, ip.String())
+			}
+			if !reflect.DeepEqual(ips, tt.wantIPs) {
+				t.Fatalf("got IP addresses %v, want %v", ips, tt.wantIPs)
+			}
+			if !reflect.DeepEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}) {
+				t.Fatalf("got key usage %v, want server auth only", cert.ExtKeyUsage)
+			}
+			if err := cert.CheckSignatureFrom(ca.Cert); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSignRequestInvalid(t *testing.T) {
+	ca, err := CreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csr := newCSR(t, "w1", nil, nil)
+	block, _ := pem.Decode(csr)
+	block.Bytes[len(block.Bytes)-1] ^= 0xff
+
+	for name, data := range map[string][]byte{
+		"not pem":        []byte("w1"),
+		"wrong type":     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes}),
+		"bad signature":  pem.EncodeToMemory(block),
+		"truncated body": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: block.Bytes[:10]}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ca.SignRequest(data, "10.0.0.5"); err == nil {
+				t.Fatal("signed an invalid request")
+			}
+		})
+	}
+}
+
+func newCSR(t *testing.T, cn string, dns []string, ips []string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}, DNSNames: dns}
+	for _, ip := range ips {
+		template.IPAddresses = append(template.IPAddresses, net.ParseIP(ip))
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
@@ -0,0 +1,145 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JoinPath is where the manager issues worker certificates. It sits
+// outside /api as it is authenticated by the join token alone.
+const JoinPath = "/join"
+
+// JoinRequest asks the manager to sign a worker's certificate request.
+type JoinRequest struct {
+	CSR string `json:"csr"`
+}
+
+// JoinResponse carries the signed worker certificate and the CA
+// certificate, both PEM encoded.
+type JoinResponse struct {
+	Certificate string `json:"certificate"`
+	CA          string `json:"ca"`
+}
+
+// Join obtains a certificate for a worker from the manager at managerURL
+// and writes it, its key and the CA certificate to dir. The certificate is
+// valid for the worker name and the address the worker joins from. The
+// manager is trusted only if it presents a certificate issued by the CA
+// with hash caHash, as printed by the manager on start; token is the
+// manager's join token.
+func Join(ctx context.Context, managerURL string, name string, caHash string, token string, dir string) error {
+	if !strings.HasPrefix(caHash, "sha256:") {
+		return fmt.Errorf("a CA hash of the form sha256:<hex> is required to join")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: name}}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(JoinRequest{CSR: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))})
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(managerURL, "https://") {
+		managerURL = "https://" + strings.TrimPrefix(managerURL, "http://")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(managerURL, "/")+JoinPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	hc := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// The chain is verified against the pinned CA below instead of
+			// the system roots.
+			InsecureSkipVerify: true,
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				return verifyPinned(cs, caHash)
+			},
+		}},
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to join %s: %w", managerURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to join %s (%d): %s", managerURL, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	joined := JoinResponse{}
+	if err := json.Unmarshal(data, &joined); err != nil {
+		return fmt.Errorf("unable to decode join response: %w", err)
+	}
+	caCert, err := parseCert([]byte(joined.CA))
+	if err != nil {
+		return err
+	}
+	if Hash(caCert) != caHash {
+		return fmt.Errorf("the manager returned a CA that does not match %s", caHash)
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return err
+	}
+	return WriteFiles(dir, map[string][]byte{
+		CACertFile:     []byte(joined.CA),
+		WorkerCertFile: []byte(joined.Certificate),
+		WorkerKeyFile:  keyPEM,
+	})
+}
+
+// verifyPinned checks that the server's chain includes the CA with the
+// pinned hash and that its certificate was issued by that CA.
+func verifyPinned(cs tls.ConnectionState, caHash string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("the manager presented no certificate")
+	}
+
+	roots := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		if c.IsCA && Hash(c) == caHash {
+			roots.AddCert(c)
+		}
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName: cs.ServerName,
+		Roots:   roots,
+	})
+	if err != nil {
+		return fmt.Errorf("the manager's certificate is not from the CA %s: %w", caHash, err)
+	}
+	return nil
+}
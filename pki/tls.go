@@ -0,0 +1,190 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManagerTLS loads the manager's CA and certificate from dir, creating
+// them the first time, and returns the config its API serves with and the
+// configs it dials workers with, by worker host. Users may present client
+// certificates; workers must present the certificate the CA issued them.
+func ManagerTLS(dir string, address string) (server *tls.Config, workers func(host string) *tls.Config, ca *CA, err error) {
+	if ca, err = LoadOrCreateCA(dir); err != nil {
+		return nil, nil, nil, err
+	}
+
+	certPath := filepath.Join(dir, ManagerCertFile)
+	keyPath := filepath.Join(dir, ManagerKeyFile)
+	if _, err := os.Stat(certPath); errors.Is(err, os.ErrNotExist) {
+		certPEM, keyPEM, err := ca.Issue(pkix.Name{CommonName: ManagerName}, Hosts(address), Peer)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err := WriteFiles(dir, map[string][]byte{ManagerCertFile: certPEM, ManagerKeyFile: keyPEM}); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	cert, err := loadKeyPair(certPath, keyPath, ca.CertPEM)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ca.Reserved = managerNames(leaf)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+
+	server = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    pool,
+	}
+	workers = func(host string) *tls.Config {
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      pool,
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				if len(cs.PeerCertificates) == 0 {
+					return fmt.Errorf("worker %s presented no certificate", host)
+				}
+				return verifyWorker(cs.PeerCertificates[0], host)
+			},
+		}
+	}
+	return server, workers, ca, nil
+}
+
+// managerNames returns the names of the manager certificate that no
+// worker may be issued. Loopback names are left out: they stand for
+// whichever server runs on the dialing host, and a worker sharing the
+// manager's host is reached by them.
+func managerNames(cert *x509.Certificate) []string {
+	names := []string{ManagerName}
+	for _, name := range cert.DNSNames {
+		if !strings.EqualFold(name, "localhost") {
+			names = append(names, name)
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if !ip.IsLoopback() {
+			names = append(names, ip.String())
+		}
+	}
+	return names
+}
+
+// verifyWorker checks that the certificate a worker dialed at host
+// presents was issued to that worker: by its name, or by the address it
+// joined from.
+func verifyWorker(cert *x509.Certificate, host string) error {
+	if cert.Subject.CommonName == ManagerName {
+		return fmt.Errorf("worker %s presented the manager certificate", host)
+	}
+	if sameHost(cert.Subject.CommonName, host) {
+		return nil
+	}
+	for _, ip := range cert.IPAddresses {
+		if sameHost(ip.String(), host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("certificate of worker %s does not identify %s", cert.Subject.CommonName, host)
+}
+
+// WorkerTLS returns the config a worker serves its API with from the
+// certificate kept in dir. Only the manager's certificate is accepted from
+// clients, so neither users nor other workers can call the worker.
+func WorkerTLS(dir string) (*tls.Config, error) {
+	caPEM, err := os.ReadFile(filepath.Join(dir, CACertFile))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("invalid CA certificate in %s", dir)
+	}
+
+	cert, err := loadKeyPair(filepath.Join(dir, WorkerCertFile), filepath.Join(dir, WorkerKeyFile), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 || cs.PeerCertificates[0].Subject.CommonName != ManagerName {
+				return fmt.Errorf("client certificate does not identify the manager")
+			}
+			return nil
+		},
+	}, nil
+}
+
+// HasWorkerCert reports whether dir holds a worker certificate, i.e. the
+// worker has already joined.
+func HasWorkerCert(dir string) bool {
+	for _, name := range []string{CACertFile, WorkerCertFile, WorkerKeyFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ClientTLS returns the config a user client dials the manager with. The
+// CA file verifies the manager; the certificate and key, if given, are
+// presented to it.
+func ClientTLS(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("invalid CA certificate %s", caFile)
+		}
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := loadKeyPair(certFile, keyFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// loadKeyPair loads a certificate and its key, appending the CA
+// certificate, if given, so peers receive the whole chain.
+func loadKeyPair(certFile string, keyFile string, caPEM []byte) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(append(certPEM, caPEM...), keyPEM)
+}
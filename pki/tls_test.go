@@ -0,0 +1,52 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyWorker(t *testing.T) {
+	tests := []struct {
+		name string
+		cn   string
+		ips  []string
+		host string
+		ok   bool
+	}{
+		{"by name", "w1", nil, "w1", true},
+		{"by name, other case", "w1", nil, "W1", true},
+		{"by address", "w1", []string{"10.0.0.5"}, "10.0.0.5", true},
+		{"by ipv6 address", "w1", []string{"fd00::5"}, "fd00:0:0::5", true},
+		{"other worker", "w2", []string{"10.0.0.6"}, "w1", false},
+		{"other address", "w1", []string{"10.0.0.5"}, "10.0.0.6", false},
+		{"manager", ManagerName, []string{"10.0.0.5"}, "10.0.0.5", false},
+		{"no host", "w1", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: tt.cn}}
+			for _, ip := range tt.ips {
+				cert.IPAddresses = append(cert.IPAddresses, net.ParseIP(ip))
+			}
+			if err := verifyWorker(cert, tt.host); (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok %t", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestManagerNames(t *testing.T) {
+	cert := &x509.Certificate{
+		DNSNames:    []string{"localhost", "LOCALHOST", "manager.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1"), net.ParseIP("10.0.0.1")},
+	}
+
+	want := []string{ManagerName, "manager.example.com", "10.0.0.1"}
+	if got := managerNames(cert); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
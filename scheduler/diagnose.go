@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Unschedulable explains why no node can take a task.
+type Unschedulable struct {
+	Reason  string
+	Message string
+}
+
+func (u *Unschedulable) Error() string {
+	return u.Message
+}
+
+// Diagnose works out why none of the nodes can take the task. The reason
+// is the most common obstacle, cordons and placement rules aside, since
+// those are what freeing up capacity would not fix.
+func Diagnose(t task.Task, nodes []*node.Node) *Unschedulable {
+	if len(nodes) == 0 {
+		return &Unschedulable{
+			Reason:  task.ReasonNoNodes,
+			Message: "no nodes are available",
+		}
+	}
+
+	counts := map[string]int{}
+	for _, n := range nodes {
+		switch {
+		case n.Unschedulable:
+			counts[task.ReasonNodesCordoned]++
+		case !feasible(t, n, nodes):
+			counts[task.ReasonNoMatchingNode]++
+		default:
+			if reason := Shortage(t, n); reason != "" {
+				counts[reason]++
+			}
+		}
+	}
+
+	u := &Unschedulable{}
+	for _, reason := range []string{task.ReasonInsufficientCpu, task.ReasonInsufficientMemory, task.ReasonInsufficientDisk} {
+		if counts[reason] > counts[u.Reason] {
+			u.Reason = reason
+		}
+	}
+	if u.Reason == "" {
+		u.Reason = task.ReasonNoMatchingNode
+		if counts[task.ReasonNodesCordoned] == len(nodes) {
+			u.Reason = task.ReasonNodesCordoned
+		}
+	}
+
+	var parts []string
+	for _, c := range []struct {
+		reason string
+		text   string
+	}{
+		{task.ReasonNodesCordoned, "cordoned"},
+		{task.ReasonNoMatchingNode, "not matching placement rules or taints"},
+		{task.ReasonInsufficientCpu, "insufficient cpu"},
+		{task.ReasonInsufficientMemory, "insufficient memory"},
+		{task.ReasonInsufficientDisk, "insufficient disk"},
+	} {
+		if counts[c.reason] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[c.reason], c.text))
+		}
+	}
+	u.Message = fmt.Sprintf("0/%d nodes are available: %s", len(nodes), strings.Join(parts, ", "))
+
+	return u
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// PlaceGang places every task of a gang with the scheduler, accounting for
+// the resources and labels of the members already placed. It returns the
+// node chosen for each task, or an error if any of them does not fit.
+func PlaceGang(s Scheduler, tasks []task.Task, nodes []*node.Node) ([]*node.Node, error) {
+	// Members are placed on copies of the nodes, whose allocations grow
+	// as members are added.
+	sim := make([]*node.Node, len(nodes))
+	origin := make(map[*node.Node]*node.Node, len(nodes))
+	for i, n := range nodes {
+		c := *n
+		c.Tasks = append([]node.Resident{}, n.Tasks...)
+		sim[i] = &c
+		origin[&c] = n
+	}
+
+	placed := make([]*node.Node, len(tasks))
+	for i, t := range tasks {
+		candidates := s.SelectCandidateNodes(t, sim)
+		if len(candidates) == 0 {
+			u := Diagnose(t, sim)
+			u.Message = fmt.Sprintf("task %d (%s): %s", i, t.Name, u.Message)
+			return nil, u
+		}
+
+		scores := s.Score(t, candidates)
+		n := s.Pick(scores, candidates)
+		if n == nil {
+			return nil, fmt.Errorf("no node selected for task %v", t.ID)
+		}
+
+		n.TaskCount++
+		n.CpuAllocated += t.Cpu
+		n.MemoryAllocated += int(t.Memory)
+		n.DiskAllocated += int(t.Disk)
+		n.Tasks = append(n.Tasks, node.Resident{
+			ID:       t.ID.String(),
+			Labels:   t.Labels,
+			Priority: t.Priority,
+			Cpu:      t.Cpu,
+			Memory:   t.Memory,
+			Disk:     t.Disk,
+		})
+		placed[i] = origin[n]
+	}
+
+	return placed, nil
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestPlaceGang(t *testing.T) {
+	member := func(name string, cpu float64, p *task.Placement) task.Task {
+		return task.Task{ID: uuid.New(), Name: name, Cpu: cpu, Labels: map[string]string{"gang": "g"}, Placement: p}
+	}
+	together := &task.Placement{Affinity: []task.TaskAffinity{{Selector: "gang=g"}}}
+	apart := &task.Placement{AntiAffinity: []task.TaskAffinity{{Selector: "gang=g"}}}
+
+	tests := []struct {
+		name   string
+		tasks  []task.Task
+		want   []string
+		reason string
+		err    string
+	}{
+		{
+			name:  "spread by task count",
+			tasks: []task.Task{member("a", 1, nil), member("b", 1, nil), member("c", 1, nil)},
+			want:  []string{"n1", "n2", "n1"},
+		},
+		{
+			name:  "members use up capacity",
+			tasks: []task.Task{member("a", 3, nil), member("b", 3, nil), member("c", 3, nil)},
+			err:   "task 2 (c): ",
+		},
+		{
+			name:  "affinity between members",
+			tasks: []task.Task{member("a", 1, together), member("b", 1, together), member("c", 1, together)},
+			want:  []string{"n1", "n1", "n1"},
+		},
+		{
+			name:  "anti-affinity between members",
+			tasks: []task.Task{member("a", 1, apart), member("b", 1, apart)},
+			want:  []string{"n1", "n2"},
+		},
+		{
+			name:   "anti-affinity beyond the nodes",
+			tasks:  []task.Task{member("a", 1, apart), member("b", 1, apart), member("c", 1, apart)},
+			reason: task.ReasonNoMatchingNode,
+			err:    "task 2 (c): ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []*node.Node{testNode("n1", nil), testNode("n2", nil)}
+
+			placed, err := PlaceGang(&Greedy{Name: GreedyType}, tt.tasks, nodes)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want one starting with %q", err, tt.err)
+				}
+				u := &Unschedulable{}
+				if !errors.As(err, &u) || tt.reason != "" && u.Reason != tt.reason {
+					t.Fatalf("got error %#v, want reason %q", err, tt.reason)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := names(placed); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+				for i, n := range placed {
+					if n != nodes[0] && n != nodes[1] {
+						t.Fatalf("task %d placed on a copy of node %s", i, n.Name)
+					}
+				}
+			}
+
+			for _, n := range nodes {
+				if n.TaskCount != 0 || n.CpuAllocated != 0 || len(n.Tasks) != 0 {
+					t.Fatalf("placing the gang changed node %s", n.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package scheduler
+
+import (
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Greedy places each task on the node currently running the fewest tasks.
+type Greedy struct {
+	Name string
+}
+
+func (g *Greedy) SelectCandidateNodes(t task.Task, nodes []*node.Node) []*node.Node {
+	return filterPlacement(t, nodes)
+}
+
+func (g *Greedy) Score(t task.Task, nodes []*node.Node) map[string]float64 {
+	nodeScores := make(map[string]float64)
+
+	for _, n := range nodes {
+		nodeScores[n.Name] = float64(n.TaskCount) + placementPenalty(t, n, nodes)
+	}
+
+	return nodeScores
+}
+
+func (g *Greedy) Pick(scores map[string]float64, candidates []*node.Node) *node.Node {
+	return pickLowest(scores, candidates)
+}
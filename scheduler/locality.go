@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// ImageLocalityWeight is how much lower the score of a node holding the
+// image of a task is.
+const ImageLocalityWeight = 1.0
+
+// ImageLocality makes the scheduler it wraps prefer the nodes that already
+// hold the image of a task, which then starts without pulling it.
+type ImageLocality struct {
+	Scheduler
+}
+
+func (l ImageLocality) Score(t task.Task, nodes []*node.Node) map[string]float64 {
+	scores := l.Scheduler.Score(t, nodes)
+	for _, n := range nodes {
+		if _, ok := scores[n.Name]; ok && n.HasImage(t.Image) {
+			scores[n.Name] -= ImageLocalityWeight
+		}
+	}
+	return scores
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// filterPlacement returns the nodes that are not cordoned, have room for
+// the task, satisfy its required placement rules and whose NoSchedule and
+// NoExecute taints it tolerates.
+func filterPlacement(t task.Task, nodes []*node.Node) []*node.Node {
+	candidates := []*node.Node{}
+	for _, n := range nodes {
+		if !n.Unschedulable && feasible(t, n, nodes) && fits(t, n) {
+			candidates = append(candidates, n)
+		}
+	}
+	return candidates
+}
+
+func fits(t task.Task, n *node.Node) bool {
+	return Shortage(t, n) == ""
+}
+
+// Shortage returns the reason the node lacks room for the task's resource
+// requests, or "" if it has enough. Capacities a node does not report are
+// not checked.
+func Shortage(t task.Task, n *node.Node) string {
+	if n.Cores > 0 && n.CpuAllocated+t.Cpu > float64(n.Cores) {
+		return task.ReasonInsufficientCpu
+	}
+	if n.Memory > 0 && int64(n.MemoryAllocated)+t.Memory > int64(n.Memory) {
+		return task.ReasonInsufficientMemory
+	}
+	if n.Disk > 0 && int64(n.DiskAllocated)+t.Disk > int64(n.Disk) {
+		return task.ReasonInsufficientDisk
+	}
+	return ""
+}
+
+func feasible(t task.Task, n *node.Node, nodes []*node.Node) bool {
+	for _, taint := range n.Taints {
+		if taint.Effect != node.PreferNoSchedule && !t.Tolerates(taint) {
+			return false
+		}
+	}
+
+	p := t.Placement
+	if p == nil {
+		return true
+	}
+
+	if !labels.SelectorFromMap(p.NodeSelector).Matches(n.Labels) {
+		return false
+	}
+	if !parse(p.RequiredNodes).Matches(n.Labels) {
+		return false
+	}
+
+	for _, a := range p.Affinity {
+		if a.Weight != 0 {
+			continue
+		}
+		sel := parse(a.Selector)
+		if matchingTasks(sel, a.TopologyKey, n, nodes) > 0 {
+			continue
+		}
+		// The first of a group of tasks that attract each other has
+		// nothing to be placed next to yet.
+		if !sel.Matches(t.Labels) || matchingTasks(sel, "", nil, nodes) > 0 {
+			return false
+		}
+	}
+
+	for _, a := range p.AntiAffinity {
+		if a.Weight == 0 && matchingTasks(parse(a.Selector), a.TopologyKey, n, nodes) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// placementPenalty scores the preferred placement rules of the task and
+// the PreferNoSchedule taints of a node, lower being better like the
+// scheduler scores it is added to. A weight of 10 is worth one point, as is
+// each untolerated taint.
+func placementPenalty(t task.Task, n *node.Node, nodes []*node.Node) float64 {
+	var penalty float64
+	for _, taint := range n.Taints {
+		if taint.Effect == node.PreferNoSchedule && !t.Tolerates(taint) {
+			penalty++
+		}
+	}
+
+	p := t.Placement
+	if p == nil {
+		return penalty
+	}
+	for _, pref := range p.PreferredNodes {
+		if parse(pref.Selector).Matches(n.Labels) {
+			penalty -= float64(pref.Weight) / 10
+		}
+	}
+	for _, a := range p.Affinity {
+		if a.Weight > 0 && matchingTasks(parse(a.Selector), a.TopologyKey, n, nodes) > 0 {
+			penalty -= float64(a.Weight) / 10
+		}
+	}
+	for _, a := range p.AntiAffinity {
+		if a.Weight > 0 {
+			penalty += float64(a.Weight) / 10 * float64(matchingTasks(parse(a.Selector), a.TopologyKey, n, nodes))
+		}
+	}
+	return penalty
+}
+
+// matchingTasks counts the tasks matching sel in the topology domain of n.
+// With a nil node it counts them on every node.
+func matchingTasks(sel labels.Selector, topologyKey string, n *node.Node, nodes []*node.Node) int {
+	var domain string
+	if n != nil {
+		var ok bool
+		if domain, ok = topologyDomain(topologyKey, n); !ok {
+			return 0
+		}
+	}
+
+	count := 0
+	for _, other := range nodes {
+		if n != nil {
+			if d, ok := topologyDomain(topologyKey, other); !ok || d != domain {
+				continue
+			}
+		}
+		for _, r := range other.Tasks {
+			if sel.Matches(r.Labels) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// topologyDomain returns the domain of a node for a topology key, and false
+// if the node does not carry the key's label.
+func topologyDomain(topologyKey string, n *node.Node) (string, bool) {
+	if topologyKey == "" || topologyKey == task.TopologyNode {
+		return n.Name, true
+	}
+	v, ok := n.Labels[topologyKey]
+	return v, ok
+}
+
+// parse parses a selector validated when the task was submitted. An
+// invalid selector matches nothing.
+func parse(expr string) labels.Selector {
+	sel, err := labels.Parse(expr)
+	if err != nil {
+		return labels.Selector{{Key: "invalid", Operator: labels.In}}
+	}
+	return sel
+}
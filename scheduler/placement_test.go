@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestFilterPlacement(t *testing.T) {
+	// Three nodes over two zones: web runs on n1 and db on n3.
+	cluster := func() []*node.Node {
+		n1 := testNode("n1", map[string]string{"zone": "a", "disk": "ssd"})
+		n2 := testNode("n2", map[string]string{"zone": "a", "disk": "hdd"})
+		n3 := testNode("n3", map[string]string{"zone": "b", "disk": "nvme"})
+		n1.Tasks = []node.Resident{{ID: "1", Labels: map[string]string{"app": "web"}}}
+		n3.Tasks = []node.Resident{{ID: "2", Labels: map[string]string{"app": "db"}}}
+		return []*node.Node{n1, n2, n3}
+	}
+
+	tests := []struct {
+		name      string
+		task      task.Task
+		cordon    string
+		allocated float64
+		want      []string
+	}{
+		{
+			name: "no placement",
+			want: []string{"n1", "n2", "n3"},
+		},
+		{
+			name: "node selector",
+			task: task.Task{Placement: &task.Placement{NodeSelector: map[string]string{"zone": "a"}}},
+			want: []string{"n1", "n2"},
+		},
+		{
+			name: "required nodes",
+			task: task.Task{Placement: &task.Placement{RequiredNodes: "disk in (ssd,nvme)"}},
+			want: []string{"n1", "n3"},
+		},
+		{
+			name: "selector and required nodes",
+			task: task.Task{Placement: &task.Placement{NodeSelector: map[string]string{"zone": "a"}, RequiredNodes: "disk!=ssd"}},
+			want: []string{"n2"},
+		},
+		{
+			name: "affinity by node",
+			task: task.Task{Placement: &task.Placement{Affinity: []task.TaskAffinity{{Selector: "app=web"}}}},
+			want: []string{"n1"},
+		},
+		{
+			name: "affinity by zone",
+			task: task.Task{Placement: &task.Placement{Affinity: []task.TaskAffinity{{Selector: "app=web", TopologyKey: "zone"}}}},
+			want: []string{"n1", "n2"},
+		},
+		{
+			name: "affinity to nothing running",
+			task: task.Task{Placement: &task.Placement{Affinity: []task.TaskAffinity{{Selector: "app=cache"}}}},
+			want: []string{},
+		},
+		{
+			name: "first of a self-attracting group",
+			task: task.Task{
+				Labels:    map[string]string{"app": "cache"},
+				Placement: &task.Placement{Affinity: []task.TaskAffinity{{Selector: "app=cache"}}},
+			},
+			want: []string{"n1", "n2", "n3"},
+		},
+		{
+			name: "preferred affinity does not filter",
+			task: task.Task{Placement: &task.Placement{Affinity: []task.TaskAffinity{{Selector: "app=cache", Weight: 50}}}},
+			want: []string{"n1", "n2", "n3"},
+		},
+		{
+			name: "anti-affinity by node",
+			task: task.Task{Placement: &task.Placement{AntiAffinity: []task.TaskAffinity{{Selector: "app=web"}}}},
+			want: []string{"n2", "n3"},
+		},
+		{
+			name: "anti-affinity by zone",
+			task: task.Task{Placement: &task.Placement{AntiAffinity: []task.TaskAffinity{{Selector: "app in (web,db)", TopologyKey: "zone"}}}},
+			want: []string{},
+		},
+		{
+			name: "anti-affinity on a missing topology label",
+			task: task.Task{Placement: &task.Placement{AntiAffinity: []task.TaskAffinity{{Selector: "app=web", TopologyKey: "rack"}}}},
+			want: []string{"n1", "n2", "n3"},
+		},
+		{
+			name: "invalid selector matches nothing",
+			task: task.Task{Placement: &task.Placement{RequiredNodes: "zone in (a"}},
+			want: []string{},
+		},
+		{
+			name:   "cordoned node",
+			cordon: "n2",
+			want:   []string{"n1", "n3"},
+		},
+		{
+			name:      "no room",
+			task:      task.Task{Cpu: 2},
+			allocated: 3,
+			want:      []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := cluster()
+			for _, n := range nodes {
+				n.Unschedulable = n.Name == tt.cordon
+				n.CpuAllocated = tt.allocated
+			}
+
+			if got := names(filterPlacement(tt.task, nodes)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlacementPenalty(t *testing.T) {
+	n1 := testNode("n1", map[string]string{"disk": "ssd"})
+	n2 := testNode("n2", map[string]string{"disk": "hdd"})
+	n2.Tasks = []node.Resident{{ID: "1", Labels: map[string]string{"app": "web"}}, {ID: "2", Labels: map[string]string{"app": "web"}}}
+	nodes := []*node.Node{n1, n2}
+
+	p := &task.Placement{
+		PreferredNodes: []task.NodePreference{{Selector: "disk=ssd", Weight: 20}},
+		AntiAffinity:   []task.TaskAffinity{{Selector: "app=web", Weight: 10}},
+	}
+	tt := task.Task{Placement: p}
+
+	if got := placementPenalty(tt, n1, nodes); got != -2 {
+		t.Fatalf("penalty on n1 is %v, want -2", got)
+	}
+	if got := placementPenalty(tt, n2, nodes); got != 2 {
+		t.Fatalf("penalty on n2 is %v, want 2", got)
+	}
+}
+
+func TestTaints(t *testing.T) {
+	n1 := testNode("n1", nil)
+	n2 := testNode("n2", nil)
+	n2.Taints = []node.Taint{{Key: "gpu", Value: "true", Effect: node.NoSchedule}}
+	n3 := testNode("n3", nil)
+	n3.Taints = []node.Taint{{Key: "maintenance", Effect: node.NoExecute}}
+	n4 := testNode("n4", nil)
+	n4.Taints = []node.Taint{{Key: "spot", Effect: node.PreferNoSchedule}}
+	nodes := []*node.Node{n1, n2, n3, n4}
+
+	tests := []struct {
+		name        string
+		tolerations []task.Toleration
+		want        []string
+		penalty     float64
+	}{
+		{"none", nil, []string{"n1", "n4"}, 1},
+		{"no schedule", []task.Toleration{{Key: "gpu", Value: "true"}}, []string{"n1", "n2", "n4"}, 1},
+		{"no execute", []task.Toleration{{Key: "maintenance", Operator: task.TolerationExists, Effect: node.NoExecute}}, []string{"n1", "n3", "n4"}, 1},
+		{"prefer no schedule", []task.Toleration{{Key: "spot", Operator: task.TolerationExists}}, []string{"n1", "n4"}, 0},
+		{"everything", []task.Toleration{{Operator: task.TolerationExists}}, []string{"n1", "n2", "n3", "n4"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk := task.Task{Tolerations: tt.tolerations}
+			if got := names(filterPlacement(tk, nodes)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if got := placementPenalty(tk, n4, nodes); got != tt.penalty {
+				t.Fatalf("penalty on n4 is %v, want %v", got, tt.penalty)
+			}
+		})
+	}
+}
+
+func testNode(name string, labels map[string]string) *node.Node {
+	return &node.Node{Name: name, Cores: 4, Memory: 1 << 30, Disk: 1 << 30, Labels: labels}
+}
+
+func names(nodes []*node.Node) []string {
+	names := []string{}
+	for _, n := range nodes {
+		names = append(names, n.Name)
+	}
+	return names
+}
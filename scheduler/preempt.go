@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"sort"
+
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Preempt looks for a node where stopping tasks of lower priority than t
+// would make room for it. It returns the node and the tasks to stop,
+// preferring the node needing the fewest and least important victims, or
+// nil if no node can be freed up.
+func Preempt(t task.Task, nodes []*node.Node) (*node.Node, []node.Resident) {
+	var best *node.Node
+	var bestVictims []node.Resident
+
+	for _, n := range nodes {
+		if n.Unschedulable || !feasible(t, n, nodes) {
+			continue
+		}
+
+		victims, ok := selectVictims(t, n)
+		if !ok {
+			continue
+		}
+		if best == nil || betterVictims(victims, bestVictims) {
+			best, bestVictims = n, victims
+		}
+	}
+
+	return best, bestVictims
+}
+
+// selectVictims removes the lowest priority tasks from a copy of the node
+// until the task fits.
+func selectVictims(t task.Task, n *node.Node) ([]node.Resident, bool) {
+	var lower []node.Resident
+	for _, r := range n.Tasks {
+		if r.Priority < t.Priority {
+			lower = append(lower, r)
+		}
+	}
+	sort.SliceStable(lower, func(i, j int) bool {
+		return lower[i].Priority < lower[j].Priority
+	})
+
+	freed := *n
+	var victims []node.Resident
+	for _, r := range lower {
+		if fits(t, &freed) {
+			break
+		}
+		freed.CpuAllocated -= r.Cpu
+		freed.MemoryAllocated -= int(r.Memory)
+		freed.DiskAllocated -= int(r.Disk)
+		victims = append(victims, r)
+	}
+
+	if len(victims) == 0 || !fits(t, &freed) {
+		return nil, false
+	}
+	return victims, true
+}
+
+func betterVictims(a, b []node.Resident) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return maxPriority(a) < maxPriority(b)
+}
+
+func maxPriority(victims []node.Resident) int {
+	max := victims[0].Priority
+	for _, r := range victims[1:] {
+		if r.Priority > max {
+			max = r.Priority
+		}
+	}
+	return max
+}
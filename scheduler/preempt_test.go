@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+func TestPreempt(t *testing.T) {
+	// full builds a node with all four of its cores taken by tasks of the
+	// given priorities, one core each.
+	full := func(name string, priorities ...int) *node.Node {
+		n := testNode(name, nil)
+		for i, p := range priorities {
+			n.Tasks = append(n.Tasks, node.Resident{ID: name + "-" + string(rune('a'+i)), Priority: p, Cpu: 1})
+			n.CpuAllocated++
+		}
+		return n
+	}
+
+	tests := []struct {
+		name    string
+		task    task.Task
+		nodes   []*node.Node
+		node    string
+		victims []string
+	}{
+		{
+			name:    "lowest priority victim",
+			task:    task.Task{Priority: 10, Cpu: 1},
+			nodes:   []*node.Node{full("n1", 5, 0, 3, 5)},
+			node:    "n1",
+			victims: []string{"n1-b"},
+		},
+		{
+			name:    "enough victims to fit",
+			task:    task.Task{Priority: 10, Cpu: 2},
+			nodes:   []*node.Node{full("n1", 5, 0, 3, 5)},
+			node:    "n1",
+			victims: []string{"n1-b", "n1-c"},
+		},
+		{
+			name:  "equal priority is not preempted",
+			task:  task.Task{Priority: 5, Cpu: 1},
+			nodes: []*node.Node{full("n1", 5, 5, 5, 5)},
+		},
+		{
+			name:  "not enough lower priority tasks",
+			task:  task.Task{Priority: 5, Cpu: 2},
+			nodes: []*node.Node{full("n1", 0, 5, 5, 5)},
+		},
+		{
+			name:    "fewest victims",
+			task:    task.Task{Priority: 10, Cpu: 2},
+			nodes:   []*node.Node{full("n1", 0, 0, 0, 0), full("n2", 1, 1, 1)},
+			node:    "n2",
+			victims: []string{"n2-a"},
+		},
+		{
+			name:    "least important victims",
+			task:    task.Task{Priority: 10, Cpu: 1},
+			nodes:   []*node.Node{full("n1", 3, 5, 5, 5), full("n2", 5, 1, 5, 5)},
+			node:    "n2",
+			victims: []string{"n2-b"},
+		},
+		{
+			name:  "skips nodes the task may not run on",
+			task:  task.Task{Priority: 10, Cpu: 1, Placement: &task.Placement{NodeSelector: map[string]string{"gpu": "true"}}},
+			nodes: []*node.Node{full("n1", 0, 0, 0, 0)},
+		},
+		{
+			name:  "nothing to free on a node with room",
+			task:  task.Task{Priority: 10, Cpu: 1},
+			nodes: []*node.Node{full("n1", 0, 0, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, victims := Preempt(tt.task, tt.nodes)
+			if tt.node == "" {
+				if n != nil {
+					t.Fatalf("preempted %d tasks on node %s", len(victims), n.Name)
+				}
+				return
+			}
+			if n == nil || n.Name != tt.node {
+				t.Fatalf("got node %v, want %s", n, tt.node)
+			}
+
+			ids := []string{}
+			for _, v := range victims {
+				ids = append(ids, v.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.victims) {
+				t.Fatalf("got victims %v, want %v", ids, tt.victims)
+			}
+			if n.CpuAllocated != float64(len(n.Tasks)) {
+				t.Fatal("preemption changed the allocation of the node")
+			}
+		})
+	}
+}
@@ -1,7 +1,50 @@
 package scheduler
 
+import (
+	"fmt"
+
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+const (
+	RoundRobinType = "roundrobin"
+	GreedyType     = "greedy"
+)
+
 type Scheduler interface {
-	SelectCandidateNodes()
-	Score()
-	Pick()
+	SelectCandidateNodes(t task.Task, nodes []*node.Node) []*node.Node
+	Score(t task.Task, nodes []*node.Node) map[string]float64
+	Pick(scores map[string]float64, candidates []*node.Node) *node.Node
+}
+
+func New(schedulerType string) (Scheduler, error) {
+	switch schedulerType {
+	case "", RoundRobinType:
+		return &RoundRobin{Name: RoundRobinType}, nil
+	case GreedyType:
+		return &Greedy{Name: GreedyType}, nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q", schedulerType)
+	}
+}
+
+// pickLowest returns the candidate with the lowest score, keeping the
+// candidate order as the tie breaker.
+func pickLowest(scores map[string]float64, candidates []*node.Node) *node.Node {
+	var best *node.Node
+	var lowest float64
+
+	for _, n := range candidates {
+		score, ok := scores[n.Name]
+		if !ok {
+			continue
+		}
+		if best == nil || score < lowest {
+			best = n
+			lowest = score
+		}
+	}
+
+	return best
 }
@@ -0,0 +1,202 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxSize bounds the total size of the values of a secret.
+const MaxSize = 1 << 20
+
+// ErrDisabled is returned when the manager has no key to encrypt secrets
+// with.
+var ErrDisabled = errors.New("secrets are disabled, the manager has no secrets key")
+
+// Secret holds credentials tasks of its namespace may reference. Its
+// values are only ever sent to the manager, when creating or rotating it,
+// and to the workers starting tasks that use it: the manager stores them
+// sealed and the API returns the names of the keys alone.
+type Secret struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Data maps each key to its value. It is set on secrets being created
+	// or rotated only.
+	Data map[string]string `json:"data,omitempty"`
+	// Sealed is Data encrypted with the manager's secrets key, as stored.
+	Sealed    []byte    `json:"sealed,omitempty"`
+	Keys      []string  `json:"keys"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *Secret) Validate() error {
+	if err := ValidateName(s.Name); err != nil {
+		return err
+	}
+	return ValidateData(s.Data)
+}
+
+// ValidateData checks the values of a secret. Keys become file names when
+// a secret is mounted, so they are restricted to alphanumerics, '-', '_'
+// and '.'.
+func ValidateData(data map[string]string) error {
+	if len(data) == 0 {
+		return fmt.Errorf("a secret requires at least one key")
+	}
+
+	size := 0
+	for k, v := range data {
+		if err := validateKey(k); err != nil {
+			return err
+		}
+		size += len(k) + len(v)
+	}
+	if size > MaxSize {
+		return fmt.Errorf("secret values take %d bytes, more than the %d allowed", size, MaxSize)
+	}
+	return nil
+}
+
+// ValidateName checks a secret name: at most 63 lowercase alphanumerics,
+// '-' or '.', starting and ending with an alphanumeric.
+func ValidateName(name string) error {
+	if name == "" || len(name) > 63 {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		alnum := c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+		if !alnum && (c != '-' && c != '.' || i == 0 || i == len(name)-1) {
+			return fmt.Errorf("invalid secret name %q", name)
+		}
+	}
+	return nil
+}
+
+func validateKey(key string) error {
+	if key == "" || key == "." || key == ".." || len(key) > 253 {
+		return fmt.Errorf("invalid secret key %q", key)
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return fmt.Errorf("invalid secret key %q", key)
+		}
+	}
+	return nil
+}
+
+// Redacted returns a copy of the secret without its values, sealed or
+// not, as returned by the API.
+func (s *Secret) Redacted() *Secret {
+	r := *s
+	r.Data = nil
+	r.Sealed = nil
+	return &r
+}
+
+// KeySize is the size of a secrets key: secrets are sealed with AES-256-GCM.
+const KeySize = 32
+
+// GenerateKey returns a new random secrets key, base64 encoded as kept in
+// a key file.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey reads a base64 encoded secrets key from a file.
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("secrets key file %s must hold %d base64 encoded bytes", path, KeySize)
+	}
+	return key, nil
+}
+
+// Cipher seals and opens the values of secrets.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("a secrets key must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts the values of the secret into Sealed, records their keys
+// and clears Data. The ciphertext is bound to the secret's namespace and
+// name, so it cannot be moved to another secret.
+func (c *Cipher) Seal(s *Secret) error {
+	plaintext, err := json.Marshal(s.Data)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	s.Keys = make([]string, 0, len(s.Data))
+	for k := range s.Data {
+		s.Keys = append(s.Keys, k)
+	}
+	sort.Strings(s.Keys)
+
+	s.Sealed = c.aead.Seal(nonce, nonce, plaintext, additionalData(s))
+	s.Data = nil
+	return nil
+}
+
+// Open decrypts the values of a sealed secret.
+func (c *Cipher) Open(s *Secret) (map[string]string, error) {
+	if len(s.Sealed) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("secret %s is not sealed", s.Name)
+	}
+
+	nonce, ciphertext := s.Sealed[:c.aead.NonceSize()], s.Sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, additionalData(s))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open secret %s, was it sealed with another key? %w", s.Name, err)
+	}
+
+	data := map[string]string{}
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func additionalData(s *Secret) []byte {
+	return []byte(s.Namespace + "/" + s.Name)
+}
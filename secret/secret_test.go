@@ -0,0 +1,155 @@
+package secret
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	c := newTestCipher(t)
+	other := newTestCipher(t)
+	data := map[string]string{"password": "hunter2", "user": "admin"}
+
+	sealed := func() *Secret {
+		s := &Secret{Name: "db", Namespace: "a", Data: map[string]string{}}
+		for k, v := range data {
+			s.Data[k] = v
+		}
+		if err := c.Seal(s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	s := sealed()
+	if s.Data != nil {
+		t.Fatal("sealing left the values in Data")
+	}
+	if !reflect.DeepEqual(s.Keys, []string{"password", "user"}) {
+		t.Fatalf("got keys %v", s.Keys)
+	}
+	if strings.Contains(string(s.Sealed), "hunter2") {
+		t.Fatal("sealed secret holds a value in the clear")
+	}
+	opened, err := c.Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opened, data) {
+		t.Fatalf("got %v, want %v", opened, data)
+	}
+	if again := sealed(); reflect.DeepEqual(again.Sealed, s.Sealed) {
+		t.Fatal("sealing the same values twice gave the same ciphertext")
+	}
+
+	tests := []struct {
+		name   string
+		cipher *Cipher
+		change func(s *Secret)
+		err    string
+	}{
+		{"other key", other, func(s *Secret) {}, "sealed with another key"},
+		{"renamed", c, func(s *Secret) { s.Name = "cache" }, "unable to open secret cache"},
+		{"moved namespace", c, func(s *Secret) { s.Namespace = "b" }, "unable to open secret db"},
+		{"tampered", c, func(s *Secret) { s.Sealed[len(s.Sealed)-1] ^= 0xff }, "unable to open secret db"},
+		{"not sealed", c, func(s *Secret) { s.Sealed = nil }, "secret db is not sealed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sealed()
+			tt.change(s)
+			_, err := tt.cipher.Open(s)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewCipher(t *testing.T) {
+	for _, size := range []int{0, 16, KeySize - 1, KeySize + 1} {
+		if _, err := NewCipher(make([]byte, size)); err == nil {
+			t.Fatalf("accepted a %d byte key", size)
+		}
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	generated, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		ok      bool
+	}{
+		{"generated", generated, true},
+		{"trailing newline", generated + "\n", true},
+		{"not base64", "not a key!", false},
+		{"short", "c2hvcnQ=", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secrets.key")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := LoadKey(path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok %t", err, tt.ok)
+			}
+			if err == nil && len(key) != KeySize {
+				t.Fatalf("got a %d byte key", len(key))
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret Secret
+		err    string
+	}{
+		{"valid", Secret{Name: "db.prod-1", Data: map[string]string{"tls.crt": "x", "USER_NAME": "y"}}, ""},
+		{"uppercase name", Secret{Name: "DB", Data: map[string]string{"k": "v"}}, "invalid secret name"},
+		{"name ends with dash", Secret{Name: "db-", Data: map[string]string{"k": "v"}}, "invalid secret name"},
+		{"long name", Secret{Name: strings.Repeat("a", 64), Data: map[string]string{"k": "v"}}, "invalid secret name"},
+		{"no keys", Secret{Name: "db"}, "at least one key"},
+		{"key with slash", Secret{Name: "db", Data: map[string]string{"../k": "v"}}, "invalid secret key"},
+		{"dot key", Secret{Name: "db", Data: map[string]string{"..": "v"}}, "invalid secret key"},
+		{"too large", Secret{Name: "db", Data: map[string]string{"k": strings.Repeat("v", MaxSize)}}, "more than the"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.secret.Validate()
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func newTestCipher(t *testing.T) *Cipher {
+	t.Helper()
+
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// Labels set on every task created for a service.
+const (
+	LabelName    = "pentagon.io/service"
+	LabelID      = "pentagon.io/service-id"
+	LabelVersion = "pentagon.io/service-version"
+)
+
+const (
+	UpdateInProgress  = "updating"
+	UpdateCompleted   = "completed"
+	UpdateRollingBack = "rolling_back"
+	UpdateRolledBack  = "rolled_back"
+)
+
+type Service struct {
+	ID           uuid.UUID    `json:"id"`
+	Name         string       `json:"name"`
+	Namespace    string       `json:"namespace,omitempty"`
+	Replicas     int          `json:"replicas"`
+	Template     task.Task    `json:"template"`
+	Version      int          `json:"version"`
+	UpdateConfig UpdateConfig `json:"update_config"`
+	Update       *Update      `json:"update,omitempty"`
+	Status       Status       `json:"status"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// UpdateConfig controls how tasks are replaced when the template changes.
+type UpdateConfig struct {
+	// MaxSurge is how many tasks above the replica count may run while
+	// updating, MaxUnavailable how many below it.
+	MaxSurge       int `json:"max_surge"`
+	MaxUnavailable int `json:"max_unavailable"`
+	// FailureThreshold is the number of new tasks allowed to fail before
+	// the update is rolled back.
+	FailureThreshold int `json:"failure_threshold"`
+	// HealthTimeoutSeconds is how long a new task may take to pass its
+	// health check before it counts as failed.
+	HealthTimeoutSeconds int `json:"health_timeout_seconds"`
+}
+
+// Update tracks the progress of a rolling update.
+type Update struct {
+	State            string      `json:"state"`
+	FromVersion      int         `json:"from_version"`
+	ToVersion        int         `json:"to_version"`
+	PreviousTemplate task.Task   `json:"previous_template"`
+	Updated          int         `json:"updated"`
+	Total            int         `json:"total"`
+	Failed           int         `json:"failed"`
+	FailedTasks      []uuid.UUID `json:"failed_tasks,omitempty"`
+	Message          string      `json:"message,omitempty"`
+	StartedAt        time.Time   `json:"started_at"`
+	CompletedAt      time.Time   `json:"completed_at,omitempty"`
+}
+
+func (u *Update) InProgress() bool {
+	return u != nil && (u.State == UpdateInProgress || u.State == UpdateRollingBack)
+}
+
+func DefaultUpdateConfig() UpdateConfig {
+	return UpdateConfig{
+		MaxSurge:             1,
+		MaxUnavailable:       0,
+		FailureThreshold:     1,
+		HealthTimeoutSeconds: 60,
+	}
+}
+
+func (c UpdateConfig) HealthTimeout() time.Duration {
+	return time.Duration(c.HealthTimeoutSeconds) * time.Second
+}
+
+func (c UpdateConfig) Validate() error {
+	if c.MaxSurge < 0 || c.MaxUnavailable < 0 || c.FailureThreshold < 0 || c.HealthTimeoutSeconds < 0 {
+		return fmt.Errorf("update config values must not be negative")
+	}
+	if c.MaxSurge == 0 && c.MaxUnavailable == 0 {
+		return fmt.Errorf("max_surge and max_unavailable cannot both be zero")
+	}
+	return nil
+}
+
+// Status is the state observed by the last reconciliation of the service.
+type Status struct {
+	Running        int       `json:"running"`
+	Pending        int       `json:"pending"`
+	Message        string    `json:"message,omitempty"`
+	LastReconciled time.Time `json:"last_reconciled"`
+}
+
+func (s *Service) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("service name is required")
+	}
+	if s.Replicas < 0 {
+		return fmt.Errorf("service %s: replicas must not be negative", s.Name)
+	}
+	if s.Template.Image == "" {
+		return fmt.Errorf("service %s: template image is required", s.Name)
+	}
+	if err := s.Template.ValidateScheduling(); err != nil {
+		return fmt.Errorf("service %s: %w", s.Name, err)
+	}
+	if err := s.UpdateConfig.Validate(); err != nil {
+		return fmt.Errorf("service %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// NewTask returns a fresh task built from the service template.
+func (s *Service) NewTask() *task.Task {
+	t := s.Template
+	t.ID = uuid.New()
+	t.State = task.Pending
+	t.ContainerID = ""
+	t.StartTime = time.Time{}
+	t.FinishTime = time.Time{}
+
+	if t.Name == "" {
+		t.Name = s.Name
+	}
+
+	t.Namespace = s.Namespace
+
+	t.Labels = make(map[string]string, len(s.Template.Labels)+3)
+	for k, v := range s.Template.Labels {
+		t.Labels[k] = v
+	}
+	t.Labels[LabelName] = s.Name
+	t.Labels[LabelID] = s.ID.String()
+	t.Labels[LabelVersion] = strconv.Itoa(s.Version)
+
+	return &t
+}
+
+// TaskVersion returns the service version a task was created from.
+func TaskVersion(t *task.Task) int {
+	v, _ := strconv.Atoi(t.Labels[LabelVersion])
+	return v
+}
+
+// Owns reports whether the task was created for the service. Tasks are
+// matched on the service ID, so those of a deleted service of the same name
+// are not taken over.
+func (s *Service) Owns(t *task.Task) bool {
+	return t.Labels[LabelID] == s.ID.String() && namespace.OrDefault(t.Namespace) == namespace.OrDefault(s.Namespace)
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Store[T any] interface {
+	Put(key string, value T) error
+	Get(key string) (T, error)
+	List() ([]T, error)
+	Count() (int, error)
+	Delete(key string) error
+}
+
+// New returns a store of the given type. Persistent stores keep their data
+// in a file named after the bucket inside dir.
+func New[T any](dbType string, dir string, bucket string) (Store[T], error) {
+	switch dbType {
+	case "", "memory":
+		return NewMemoryStore[T](), nil
+	case "file":
+		return NewFileStore[T](filepath.Join(dir, bucket+".json"))
+	default:
+		return nil, fmt.Errorf("unknown store type %q", dbType)
+	}
+}
+
+type MemoryStore[T any] struct {
+	mu   sync.RWMutex
+	Db   map[string]T
+	keys []string
+}
+
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{
+		Db: make(map[string]T),
+	}
+}
+
+func (s *MemoryStore[T]) Put(key string, value T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Db[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.Db[key] = value
+	return nil
+}
+
+func (s *MemoryStore[T]) Get(key string) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.Db[key]
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("key %s: %w", key, ErrNotFound)
+	}
+	return value, nil
+}
+
+// List returns the stored values in insertion order.
+func (s *MemoryStore[T]) List() ([]T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]T, 0, len(s.keys))
+	for _, key := range s.keys {
+		values = append(values, s.Db[key])
+	}
+	return values, nil
+}
+
+func (s *MemoryStore[T]) Count() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.Db), nil
+}
+
+func (s *MemoryStore[T]) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Db[key]; !ok {
+		return fmt.Errorf("key %s: %w", key, ErrNotFound)
+	}
+	delete(s.Db, key)
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// FileStore keeps its values in memory and rewrites a JSON file on every
+// change, so state survives process restarts.
+type FileStore[T any] struct {
+	*MemoryStore[T]
+	Path string
+
+	flushMu sync.Mutex
+}
+
+type fileRecord[T any] struct {
+	Key   string `json:"key"`
+	Value T      `json:"value"`
+}
+
+func NewFileStore[T any](path string) (*FileStore[T], error) {
+	s := &FileStore[T]{
+		MemoryStore: NewMemoryStore[T](),
+		Path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []fileRecord[T]
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("unable to load store %s: %w", path, err)
+	}
+	for _, r := range records {
+		s.MemoryStore.Put(r.Key, r.Value)
+	}
+
+	return s, nil
+}
+
+func (s *FileStore[T]) Put(key string, value T) error {
+	s.MemoryStore.Put(key, value)
+	return s.flush()
+}
+
+func (s *FileStore[T]) Delete(key string) error {
+	if err := s.MemoryStore.Delete(key); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+func (s *FileStore[T]) flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.RLock()
+	records := make([]fileRecord[T], 0, len(s.keys))
+	for _, key := range s.keys {
+		records = append(records, fileRecord[T]{Key: key, Value: s.Db[key]})
+	}
+	data, err := json.Marshal(records)
+	s.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
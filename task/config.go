@@ -0,0 +1,39 @@
+package task
+
+import (
+	"fmt"
+	"path"
+)
+
+// ConfigRef mounts files of a config in a task's container. The config
+// must be in the task's namespace.
+type ConfigRef struct {
+	Config string `json:"config"`
+	// Key selects a file of the config, mounted at Path. Without one,
+	// every file of the config is mounted under the directory Path.
+	Key  string `json:"key,omitempty"`
+	Path string `json:"path"`
+	// RestartOnChange restarts the task when the config changes. Tasks of
+	// services are replaced by a rolling update; tasks run to completion,
+	// by jobs, cron tasks and workflows, or gang scheduled in groups are
+	// left running.
+	RestartOnChange bool `json:"restart_on_change,omitempty"`
+}
+
+func (r ConfigRef) Validate() error {
+	if r.Config == "" {
+		return fmt.Errorf("config reference: a config name is required")
+	}
+	if !path.IsAbs(r.Path) || path.Clean(r.Path) != r.Path || r.Path == "/" {
+		return fmt.Errorf("config %s: path %q must be a clean absolute path", r.Config, r.Path)
+	}
+	return nil
+}
+
+// ConfigFile is a resolved config reference: the content of a file to
+// mount at a path. The manager sends them to the worker along with the
+// task they are for.
+type ConfigFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
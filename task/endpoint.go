@@ -0,0 +1,19 @@
+package task
+
+import "github.com/google/uuid"
+
+// Endpoint is where a running task is reached: the host of the worker it
+// runs on and the host ports its container ports are published on.
+type Endpoint struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace,omitempty"`
+	// Service is the service the task was created for, if any.
+	Service string `json:"service,omitempty"`
+	Worker  string `json:"worker"`
+	Host    string `json:"host"`
+	// Ports maps container ports, such as 80/tcp, to the host ports they
+	// are published on.
+	Ports  map[string]string `json:"ports,omitempty"`
+	Health string            `json:"health,omitempty"`
+}
@@ -0,0 +1,36 @@
+package task
+
+import "fmt"
+
+// PullPolicy decides when a worker pulls the image of a task.
+type PullPolicy string
+
+const (
+	// PullAlways pulls the image every time the task starts.
+	PullAlways PullPolicy = "always"
+	// PullIfNotPresent pulls the image only when the worker does not have
+	// it yet.
+	PullIfNotPresent PullPolicy = "if-not-present"
+	// PullNever never pulls the image; the task fails unless the worker
+	// already has it.
+	PullNever PullPolicy = "never"
+)
+
+// ValidatePullPolicy accepts the pull policies and the empty one, which
+// leaves the choice to the worker.
+func ValidatePullPolicy(p PullPolicy) error {
+	switch p {
+	case "", PullAlways, PullIfNotPresent, PullNever:
+		return nil
+	}
+	return fmt.Errorf("invalid image pull policy %q, expected %s, %s or %s", p, PullAlways, PullIfNotPresent, PullNever)
+}
+
+// RegistryCredentials authenticate the pull of a task's image. The
+// manager reads them from the secret the task names in RegistryAuth, which
+// must hold a username and a password key, and sends them to the worker
+// along with the task.
+type RegistryCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,80 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/hugoleodev/pentagon/labels"
+)
+
+// TopologyNode is the topology key that treats every node as its own
+// domain. It is also used when a rule names no topology key.
+const TopologyNode = "node"
+
+// Placement constrains the nodes a task may be scheduled on, by the labels
+// of the nodes themselves and by the labels of tasks already running there.
+type Placement struct {
+	// NodeSelector lists labels a node must have.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// RequiredNodes is a label selector expression a node must match,
+	// e.g. "disk in (ssd,nvme)".
+	RequiredNodes string `json:"required_nodes,omitempty"`
+	// PreferredNodes favour nodes matching their selector without
+	// excluding the others.
+	PreferredNodes []NodePreference `json:"preferred_nodes,omitempty"`
+	// Affinity places the task in the same topology domain as the tasks
+	// matching each rule.
+	Affinity []TaskAffinity `json:"affinity,omitempty"`
+	// AntiAffinity keeps the task out of topology domains already running
+	// tasks matching each rule.
+	AntiAffinity []TaskAffinity `json:"anti_affinity,omitempty"`
+}
+
+type NodePreference struct {
+	Selector string `json:"selector"`
+	Weight   int    `json:"weight"`
+}
+
+// TaskAffinity is a rule about other tasks. Nodes sharing the same value
+// of the TopologyKey label, such as a zone, form one domain. A rule with a
+// zero Weight is required, otherwise it is a preference of that weight.
+type TaskAffinity struct {
+	Selector    string `json:"selector"`
+	TopologyKey string `json:"topology_key,omitempty"`
+	Weight      int    `json:"weight,omitempty"`
+}
+
+func (p *Placement) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	if err := labels.Validate(p.NodeSelector); err != nil {
+		return fmt.Errorf("node_selector: %w", err)
+	}
+	if _, err := labels.Parse(p.RequiredNodes); err != nil {
+		return fmt.Errorf("required_nodes: %w", err)
+	}
+	for _, pref := range p.PreferredNodes {
+		if _, err := labels.Parse(pref.Selector); err != nil {
+			return fmt.Errorf("preferred_nodes: %w", err)
+		}
+		if pref.Weight < 1 || pref.Weight > 100 {
+			return fmt.Errorf("preferred_nodes: weight must be between 1 and 100")
+		}
+	}
+	for _, rules := range [][]TaskAffinity{p.Affinity, p.AntiAffinity} {
+		for _, a := range rules {
+			sel, err := labels.Parse(a.Selector)
+			if err != nil {
+				return fmt.Errorf("affinity: %w", err)
+			}
+			if sel.Empty() {
+				return fmt.Errorf("affinity: a task selector is required")
+			}
+			if a.Weight < 0 || a.Weight > 100 {
+				return fmt.Errorf("affinity: weight must be between 0 and 100")
+			}
+		}
+	}
+	return nil
+}
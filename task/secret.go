@@ -0,0 +1,59 @@
+package task
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SecretRef makes values of a secret available to a task, either as an
+// environment variable or as files in its container. The secret must be
+// in the task's namespace. References are resolved when the task is
+// dispatched and the values handed to the worker apart from the task, so
+// they never appear in it.
+type SecretRef struct {
+	Secret string `json:"secret"`
+	// Key selects a value of the secret. It is required for Env; for Path,
+	// leaving it out mounts a file per key under Path.
+	Key string `json:"key,omitempty"`
+	// Env names the environment variable to set to the value.
+	Env string `json:"env,omitempty"`
+	// Path is the absolute path of the file to mount the value at, or of
+	// the directory to mount every value in.
+	Path string `json:"path,omitempty"`
+}
+
+func (r SecretRef) Validate() error {
+	if r.Secret == "" {
+		return fmt.Errorf("secret reference: a secret name is required")
+	}
+
+	switch {
+	case r.Env != "" && r.Path != "":
+		return fmt.Errorf("secret %s: set either env or path, not both", r.Secret)
+	case r.Env != "":
+		if r.Key == "" {
+			return fmt.Errorf("secret %s: a key is required to set env %s", r.Secret, r.Env)
+		}
+		if strings.ContainsAny(r.Env, "= \t\n") {
+			return fmt.Errorf("secret %s: invalid env name %q", r.Secret, r.Env)
+		}
+	case r.Path != "":
+		if !path.IsAbs(r.Path) || path.Clean(r.Path) != r.Path || r.Path == "/" {
+			return fmt.Errorf("secret %s: path %q must be a clean absolute path", r.Secret, r.Path)
+		}
+	default:
+		return fmt.Errorf("secret %s: an env or a path is required", r.Secret)
+	}
+	return nil
+}
+
+// SecretValue is a resolved secret reference: a value to set as an
+// environment variable or to mount as a file at a path. The manager sends
+// them to the worker along with the task they are for; they are never
+// stored with the task.
+type SecretValue struct {
+	Env   string `json:"env,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Value string `json:"value"`
+}
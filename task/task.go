@@ -8,24 +8,94 @@ import (
 )
 
 type Task struct {
-	ID            uuid.UUID         `json:"id"`
-	ContainerID   string            `json:"container_id"`
-	Name          string            `json:"name"`
-	State         State             `json:"state"`
-	Image         string            `json:"image"`
-	Cpu           float64           `json:"cpu"`
-	Memory        int64             `json:"memory"`
-	Disk          int64             `json:"disk"`
-	ExposedPorts  nat.PortSet       `json:"exposed_ports"`
-	PortBindings  map[string]string `json:"port_bindings"`
-	RestartPolicy string            `json:"restart_policy"`
-	StartTime     time.Time         `json:"start_time"`
-	FinishTime    time.Time         `json:"finish_time"`
+	ID          uuid.UUID `json:"id"`
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	Namespace   string    `json:"namespace,omitempty"`
+	State       State     `json:"state"`
+	// PendingReason and PendingMessage explain why a pending task has
+	// not been scheduled yet.
+	PendingReason  string            `json:"pending_reason,omitempty"`
+	PendingMessage string            `json:"pending_message,omitempty"`
+	Image          string            `json:"image"`
+	Cpu            float64           `json:"cpu"`
+	Memory         int64             `json:"memory"`
+	Disk           int64             `json:"disk"`
+	ExposedPorts   nat.PortSet       `json:"exposed_ports"`
+	PortBindings   map[string]string `json:"port_bindings"`
+	Env            []string          `json:"env,omitempty"`
+	Secrets        []SecretRef       `json:"secrets,omitempty"`
+	Configs        []ConfigRef       `json:"configs,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	RestartPolicy  string            `json:"restart_policy"`
+	// Priority orders pending tasks, higher first. A task that does not
+	// fit anywhere may preempt tasks of lower priority.
+	Priority    int          `json:"priority,omitempty"`
+	HostPorts   nat.PortMap  `json:"host_ports,omitempty"`
+	Placement   *Placement   `json:"placement,omitempty"`
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+	HealthCheck string       `json:"health_check,omitempty"`
+	Health      string       `json:"health,omitempty"`
+	StartTime   time.Time    `json:"start_time"`
+	FinishTime  time.Time    `json:"finish_time"`
+	ExitCode    int          `json:"exit_code"`
+	OOMKilled   bool         `json:"oom_killed"`
+	Error       string       `json:"error,omitempty"`
+	// Exited is set once the worker saw the container exit on its own,
+	// ExitCode then being the code it exited with.
+	Exited bool `json:"exited,omitempty"`
+	// StopReason records why the manager stopped the task before it
+	// exited.
+	StopReason string `json:"stop_reason,omitempty"`
+	// ConfigVersions records the version of each config the task was
+	// started with.
+	ConfigVersions map[string]int `json:"config_versions,omitempty"`
+	// ImagePullPolicy overrides the worker's pull policy for the image.
+	ImagePullPolicy PullPolicy `json:"image_pull_policy,omitempty"`
+	// RegistryAuth names the secret holding the credentials of the
+	// registry the image is pulled from.
+	RegistryAuth string `json:"registry_auth,omitempty"`
 }
 
+const (
+	Healthy   = "healthy"
+	Unhealthy = "unhealthy"
+)
+
+// Reasons a task is kept pending.
+const (
+	ReasonNoNodes            = "NoNodesAvailable"
+	ReasonNodesCordoned      = "NodesCordoned"
+	ReasonNoMatchingNode     = "NoMatchingNode"
+	ReasonInsufficientCpu    = "InsufficientCpu"
+	ReasonInsufficientMemory = "InsufficientMemory"
+	ReasonInsufficientDisk   = "InsufficientDisk"
+	ReasonPreempting         = "WaitingForPreemption"
+)
+
+// ReasonCancelled is the stop reason of tasks stopped on request, rather
+// than evicted, preempted or replaced.
+const ReasonCancelled = "cancelled"
+
 type TaskEvent struct {
 	ID        uuid.UUID `json:"id"`
 	State     State     `json:"state"`
 	Timestamp time.Time `json:"timestamp"`
 	Task      Task      `json:"task"`
+	// Reason explains events the manager generates itself, such as
+	// evictions, and the events workers return when starting tasks.
+	Reason string `json:"reason,omitempty"`
+	// Secrets carries the values of the task's secret references to the
+	// worker starting it. It is only set on the events sent to workers.
+	Secrets []SecretValue `json:"secrets,omitempty"`
+	// Configs carries the files of the task's config references to the
+	// worker starting it.
+	Configs []ConfigFile `json:"configs,omitempty"`
+	// RegistryAuth carries the credentials of the task's registry secret
+	// to the worker starting it.
+	RegistryAuth *RegistryCredentials `json:"registry_auth,omitempty"`
+	// Progress records what the worker did to start the task, such as the
+	// steps of its image pull. It is set on the events workers return.
+	Progress []string `json:"progress,omitempty"`
 }
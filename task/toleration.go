@@ -0,0 +1,74 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/hugoleodev/pentagon/node"
+)
+
+// Toleration operators.
+const (
+	TolerationEqual  = "Equal"
+	TolerationExists = "Exists"
+)
+
+// Toleration allows a task onto nodes carrying a matching taint. An empty
+// Key with the Exists operator tolerates every taint, and an empty Effect
+// matches every effect.
+type Toleration struct {
+	Key      string `json:"key,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+}
+
+func (tol Toleration) Tolerates(taint node.Taint) bool {
+	if tol.Effect != "" && tol.Effect != taint.Effect {
+		return false
+	}
+	if tol.Key == "" {
+		return tol.Operator == TolerationExists
+	}
+	if tol.Key != taint.Key {
+		return false
+	}
+
+	switch tol.Operator {
+	case TolerationExists:
+		return true
+	case "", TolerationEqual:
+		return tol.Value == taint.Value
+	}
+	return false
+}
+
+func (tol Toleration) Validate() error {
+	switch tol.Operator {
+	case "", TolerationEqual:
+		if tol.Key == "" {
+			return fmt.Errorf("toleration: a key is required with the Equal operator")
+		}
+	case TolerationExists:
+		if tol.Value != "" {
+			return fmt.Errorf("toleration %s: a value cannot be used with the Exists operator", tol.Key)
+		}
+	default:
+		return fmt.Errorf("toleration %s: unknown operator %q", tol.Key, tol.Operator)
+	}
+
+	switch tol.Effect {
+	case "", node.NoSchedule, node.PreferNoSchedule, node.NoExecute:
+		return nil
+	}
+	return fmt.Errorf("toleration %s: unknown effect %q", tol.Key, tol.Effect)
+}
+
+// Tolerates reports whether the task tolerates the taint.
+func (t *Task) Tolerates(taint node.Taint) bool {
+	for _, tol := range t.Tolerations {
+		if tol.Tolerates(taint) {
+			return true
+		}
+	}
+	return false
+}
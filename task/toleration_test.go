@@ -0,0 +1,88 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/hugoleodev/pentagon/node"
+)
+
+func TestTolerates(t *testing.T) {
+	gpu := node.Taint{Key: "gpu", Value: "true", Effect: node.NoSchedule}
+
+	tests := []struct {
+		name string
+		tol  Toleration
+		want bool
+	}{
+		{"equal", Toleration{Key: "gpu", Value: "true"}, true},
+		{"explicit equal", Toleration{Key: "gpu", Operator: TolerationEqual, Value: "true", Effect: node.NoSchedule}, true},
+		{"other value", Toleration{Key: "gpu", Value: "false"}, false},
+		{"other key", Toleration{Key: "ssd", Value: "true"}, false},
+		{"exists", Toleration{Key: "gpu", Operator: TolerationExists}, true},
+		{"other effect", Toleration{Key: "gpu", Operator: TolerationExists, Effect: node.NoExecute}, false},
+		{"everything", Toleration{Operator: TolerationExists}, true},
+		{"everything of an effect", Toleration{Operator: TolerationExists, Effect: node.NoSchedule}, true},
+		{"empty key with equal", Toleration{Value: "true"}, false},
+		{"unknown operator", Toleration{Key: "gpu", Operator: "Like", Value: "true"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tol.Tolerates(gpu); got != tt.want {
+				t.Fatalf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskTolerates(t *testing.T) {
+	task := &Task{Tolerations: []Toleration{
+		{Key: "gpu", Operator: TolerationExists},
+		{Key: "maintenance", Value: "soon", Effect: node.NoExecute},
+	}}
+
+	tests := []struct {
+		taint node.Taint
+		want  bool
+	}{
+		{node.Taint{Key: "gpu", Effect: node.NoSchedule}, true},
+		{node.Taint{Key: "maintenance", Value: "soon", Effect: node.NoExecute}, true},
+		{node.Taint{Key: "maintenance", Value: "soon", Effect: node.NoSchedule}, false},
+		{node.Taint{Key: "dedicated", Value: "db", Effect: node.NoSchedule}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taint.String(), func(t *testing.T) {
+			if got := task.Tolerates(tt.taint); got != tt.want {
+				t.Fatalf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	if (&Task{}).Tolerates(node.Taint{Key: "gpu", Effect: node.PreferNoSchedule}) {
+		t.Fatal("a task without tolerations tolerates a taint")
+	}
+}
+
+func TestTolerationValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tol  Toleration
+		ok   bool
+	}{
+		{"equal", Toleration{Key: "gpu", Value: "true", Effect: node.NoSchedule}, true},
+		{"exists without key", Toleration{Operator: TolerationExists}, true},
+		{"equal without key", Toleration{Value: "true"}, false},
+		{"exists with value", Toleration{Key: "gpu", Operator: TolerationExists, Value: "true"}, false},
+		{"unknown operator", Toleration{Key: "gpu", Operator: "Like"}, false},
+		{"unknown effect", Toleration{Key: "gpu", Effect: "NoStart"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tol.Validate(); (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok %t", err, tt.ok)
+			}
+		})
+	}
+}
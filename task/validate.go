@@ -0,0 +1,34 @@
+package task
+
+import "github.com/hugoleodev/pentagon/labels"
+
+// ValidateScheduling checks the placement rules, tolerations, image pull
+// policy and secret and config references of a task, and that its labels
+// stay clear of the reserved prefix.
+func (t *Task) ValidateScheduling() error {
+	if err := labels.ValidateUnreserved(t.Labels); err != nil {
+		return err
+	}
+	if err := t.Placement.Validate(); err != nil {
+		return err
+	}
+	for _, tol := range t.Tolerations {
+		if err := tol.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := ValidatePullPolicy(t.ImagePullPolicy); err != nil {
+		return err
+	}
+	for _, ref := range t.Secrets {
+		if err := ref.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, ref := range t.Configs {
+		if err := ref.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
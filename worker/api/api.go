@@ -1,12 +1,18 @@
 package api
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/auth"
+	"github.com/hugoleodev/pentagon/labels"
+	"github.com/hugoleodev/pentagon/scheduler"
 	"github.com/hugoleodev/pentagon/task"
 	"github.com/hugoleodev/pentagon/worker"
 )
@@ -16,15 +22,26 @@ type API struct {
 	Port    int
 	Worker  *worker.Worker
 	Router  fiber.Router
+	// Token is the credential the manager must present. Empty leaves the
+	// API open.
+	Token string
+	// TLS, if set, is the config the API serves with.
+	TLS *tls.Config
 }
 
 func (a *API) initRouter(app *fiber.App) {
-	a.Router = app.Group("/api")
+	a.Router = app.Group("/api", auth.RequireToken(a.Token))
 	a.Router.Get("/tasks", a.GetTasksHandler)
 	a.Router.Post("/tasks", a.StartTaskHandler)
 	a.Router.Delete("/tasks/:taskId", a.StopTaskHandler)
+	a.Router.Get("/tasks/:taskId/logs", a.GetTaskLogsHandler)
+
+	a.Router.Get("/images", a.GetImagesHandler)
+	a.Router.Post("/images/pull", a.PullImagesHandler)
+	a.Router.Post("/images/gc", a.CollectImagesHandler)
 
 	a.Router.Get("/stats", a.GetStatsHandler)
+	a.Router.Get("/node", a.GetNodeHandler)
 }
 
 func (a *API) Start() {
@@ -34,7 +51,17 @@ func (a *API) Start() {
 
 	a.initRouter(app)
 
-	log.Fatal().Err(app.Listen(fmt.Sprintf("%s:%d", a.Address, a.Port)))
+	address := fmt.Sprintf("%s:%d", a.Address, a.Port)
+	if a.TLS == nil {
+		log.Fatal().Err(app.Listen(address)).Msgf("Worker API on %s stopped", address)
+		return
+	}
+
+	ln, err := tls.Listen("tcp", address, a.TLS)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unable to listen on %s", address)
+	}
+	log.Fatal().Err(app.Listener(ln)).Msgf("Worker API on %s stopped", address)
 }
 
 func (a *API) StartTaskHandler(ctx *fiber.Ctx) error {
@@ -47,16 +74,67 @@ func (a *API) StartTaskHandler(ctx *fiber.Ctx) error {
 		})
 	}
 
-	result := a.Worker.StartTask(&te.Task)
+	if err := a.Worker.Admit(te.Task); err != nil {
+		// A start sent again is answered with the task as it is, without
+		// running a second container for it.
+		if current, ok := a.Worker.GetTask(te.Task.ID); ok && errors.Is(err, worker.ErrTaskActive) {
+			return ctx.Status(fiber.StatusOK).JSON(task.TaskEvent{
+				ID:        uuid.New(),
+				State:     current.State,
+				Timestamp: time.Now().UTC(),
+				Task:      *current,
+				Reason:    fmt.Sprintf("already %s on worker %s", current.State, a.Worker.Name),
+			})
+		}
+		var u *scheduler.Unschedulable
+		if errors.As(err, &u) {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": u.Message,
+				"reason":  u.Reason,
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	result := a.Worker.StartTask(&te)
 	log.Info().Msgf("Adding task %s: %v\n", te.Task.ID, result.Error)
 
-	return ctx.Status(fiber.StatusCreated).JSON(te.Task)
+	// The event returned records how the start went, pull progress
+	// included, without the secret values and config files of te.
+	started := task.TaskEvent{
+		ID:        uuid.New(),
+		State:     te.Task.State,
+		Timestamp: time.Now().UTC(),
+		Task:      te.Task,
+		Reason:    fmt.Sprintf("started by worker %s", a.Worker.Name),
+		Progress:  result.Progress,
+	}
+	if result.Error != nil {
+		started.Reason = fmt.Sprintf("failed to start on worker %s: %v", a.Worker.Name, result.Error)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(started)
 
 }
 
 func (a *API) GetTasksHandler(ctx *fiber.Ctx) error {
+	sel, err := labels.Parse(ctx.Query("selector"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
 
-	return ctx.Status(fiber.StatusOK).JSON(a.Worker.GetTasks())
+	tasks := []*task.Task{}
+	for _, t := range a.Worker.GetTasks() {
+		if sel.Matches(t.Labels) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(tasks)
 }
 
 func (a *API) StopTaskHandler(ctx *fiber.Ctx) error {
@@ -69,7 +147,7 @@ func (a *API) StopTaskHandler(ctx *fiber.Ctx) error {
 	}
 
 	tID, err := uuid.Parse(taskID)
-	taskToStop, ok := a.Worker.Db[tID]
+	taskToStop, ok := a.Worker.GetTask(tID)
 	if !ok || err != nil {
 		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "task not found",
@@ -89,6 +167,41 @@ func (a *API) StopTaskHandler(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusNoContent).JSON(responseMessage)
 }
 
+func (a *API) GetTaskLogsHandler(ctx *fiber.Ctx) error {
+	tID, err := uuid.Parse(ctx.Params("taskId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "task not found",
+		})
+	}
+
+	t, ok := a.Worker.GetTask(tID)
+	if !ok {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "task not found",
+		})
+	}
+
+	if t.ContainerID == "" {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": fmt.Sprintf("task %v has no container", t.ID),
+		})
+	}
+
+	logs, err := a.Worker.GetTaskLogs(t, ctx.QueryInt("tail"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).SendString(logs)
+}
+
+func (a *API) GetNodeHandler(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(a.Worker.Node())
+}
+
 func (a *API) GetStatsHandler(ctx *fiber.Ctx) error {
 	log.Info().Msg("Getting stats")
 	return ctx.Status(fiber.StatusOK).JSON(a.Worker.Stats)
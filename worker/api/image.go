@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hugoleodev/pentagon/image"
+	"github.com/hugoleodev/pentagon/worker"
+)
+
+func (a *API) GetImagesHandler(ctx *fiber.Ctx) error {
+	images, err := a.Worker.Images()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(images)
+}
+
+// PullImagesHandler pulls images ahead of the tasks that need them. It
+// answers once every pull is over, whether it succeeded or not.
+func (a *API) PullImagesHandler(ctx *fiber.Ctx) error {
+	req := worker.ImagePullRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if len(req.Images) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "at least one image is required",
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(a.Worker.PullImages(req))
+}
+
+// CollectImagesHandler removes unused images by the worker's policy, or
+// every unused image past the minimum age with all=true.
+func (a *API) CollectImagesHandler(ctx *fiber.Ctx) error {
+	removed, err := a.Worker.CollectImages(ctx.QueryBool("all"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if removed == nil {
+		removed = []image.Image{}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(removed)
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/internal/docker"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+// configsPath is the directory the config files of a task are written to.
+func (w *Worker) configsPath(id uuid.UUID) string {
+	return filepath.Join(w.ConfigsDir, id.String())
+}
+
+// applyConfigs writes the config files of a task under the configs
+// directory and bind mounts them read-only at their paths.
+func (w *Worker) applyConfigs(t *task.Task, configs []task.ConfigFile, config *docker.Config) error {
+	dir := w.configsPath(t.ID)
+	for i, c := range configs {
+		m, err := bindFile(dir, 0o755, i, c.Path, c.Content)
+		if err != nil {
+			return err
+		}
+		config.Mounts = append(config.Mounts, m)
+	}
+	return nil
+}
+
+// removeConfigs deletes the config files of a task once its container is
+// gone.
+func (w *Worker) removeConfigs(id uuid.UUID) {
+	if err := os.RemoveAll(w.configsPath(id)); err != nil {
+		log.Info().Msgf("Error removing configs of task %s: %v\n", id, err)
+	}
+}
+
+// removeFiles deletes the secret and config files of a task.
+func (w *Worker) removeFiles(id uuid.UUID) {
+	w.removeSecrets(id)
+	w.removeConfigs(id)
+}
@@ -0,0 +1,226 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hugoleodev/pentagon/image"
+	"github.com/hugoleodev/pentagon/internal/docker"
+	"github.com/hugoleodev/pentagon/stats"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultImageGCInterval    = 5 * time.Minute
+	DefaultImageGCHighPercent = 85
+	DefaultImageGCLowPercent  = 80
+	DefaultImageGCMinAge      = 2 * time.Hour
+)
+
+// ImageGC is the policy unused images are collected by. When the disk
+// holding them is used above HighPercent, the images no container uses and
+// not used for MinAge are removed, least recently used first, until the
+// usage falls to LowPercent. A zero HighPercent disables collection.
+type ImageGC struct {
+	HighPercent int
+	LowPercent  int
+	MinAge      time.Duration
+	Interval    time.Duration
+}
+
+// ImagePullRequest asks a worker to pull images ahead of the tasks that
+// need them.
+type ImagePullRequest struct {
+	Images []string `json:"images"`
+	// RegistryAuth, if set, replaces the worker's own credentials.
+	RegistryAuth *task.RegistryCredentials `json:"registry_auth,omitempty"`
+}
+
+// Images returns the images of the worker's cache, and refreshes the list
+// the worker reports to the manager.
+func (w *Worker) Images() ([]image.Image, error) {
+	ctx := context.Background()
+	d := docker.New(&docker.Config{})
+
+	summaries, err := d.ListImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inUse, err := d.ImagesInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.imagesMu.Lock()
+	defer w.imagesMu.Unlock()
+
+	now := time.Now().UTC()
+	images := make([]image.Image, 0, len(summaries))
+	var refs []string
+	for _, s := range summaries {
+		img := image.Image{
+			ID:       s.ID,
+			Tags:     s.RepoTags,
+			Digests:  s.RepoDigests,
+			Size:     s.Size,
+			Created:  time.Unix(s.Created, 0).UTC(),
+			LastUsed: w.started,
+			InUse:    inUse[s.ID],
+		}
+		if used, ok := w.imageUsed[s.ID]; ok {
+			img.LastUsed = used
+		}
+		if img.InUse {
+			img.LastUsed = now
+		}
+		images = append(images, img)
+		refs = append(refs, s.RepoTags...)
+		refs = append(refs, s.RepoDigests...)
+	}
+	w.imageRefs = refs
+
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].LastUsed.After(images[j].LastUsed)
+	})
+	return images, nil
+}
+
+// PullImages pulls images, even those the worker already has so moving
+// tags are brought up to date, one after the other.
+func (w *Worker) PullImages(req ImagePullRequest) []image.Pull {
+	ctx := context.Background()
+
+	pulls := make([]image.Pull, 0, len(req.Images))
+	for _, ref := range req.Images {
+		d := docker.New(&docker.Config{Image: ref, PullPolicy: task.PullAlways})
+		pull := image.Pull{Image: ref}
+
+		err := w.applyRegistryAuth(req.RegistryAuth, &d.Config)
+		if err == nil {
+			pull.Progress, err = d.PullImage(ctx)
+		}
+		if err == nil {
+			var id string
+			if id, err = d.ImageID(ctx, ref); err == nil {
+				w.markImageUsed(id, ref)
+			}
+		}
+		if err != nil {
+			log.Info().Msgf("Error pre-pulling image %s: %v\n", ref, err)
+			pull.Error = err.Error()
+		} else {
+			log.Info().Msgf("Pre-pulled image %s", ref)
+		}
+		pulls = append(pulls, pull)
+	}
+	return pulls
+}
+
+// CollectImages removes unused images by the worker's policy and returns
+// them. With all set, it removes every image no container uses and not
+// used for the policy's minimum age, whatever the disk usage.
+func (w *Worker) CollectImages(all bool) ([]image.Image, error) {
+	var toFree int64
+	if !all {
+		if w.ImageGC.HighPercent <= 0 {
+			return nil, nil
+		}
+		disk, err := stats.ReadDisk("/")
+		if err != nil {
+			return nil, err
+		}
+		if disk.All == 0 || disk.Used*100 < disk.All*uint64(w.ImageGC.HighPercent) {
+			return nil, nil
+		}
+		toFree = int64(disk.Used) - int64(disk.All*uint64(w.ImageGC.LowPercent)/100)
+		log.Info().Msgf("Disk usage %d%% is above %d%%, collecting images to free %d bytes", disk.Used*100/disk.All, w.ImageGC.HighPercent, toFree)
+	}
+
+	images, err := w.Images()
+	if err != nil {
+		return nil, err
+	}
+
+	// Least recently used first.
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].LastUsed.Before(images[j].LastUsed)
+	})
+
+	ctx := context.Background()
+	d := docker.New(&docker.Config{})
+	cutoff := time.Now().Add(-w.ImageGC.MinAge)
+
+	var removed []image.Image
+	var freed int64
+	for _, img := range images {
+		if !all && freed >= toFree {
+			break
+		}
+		if img.InUse || img.LastUsed.After(cutoff) {
+			continue
+		}
+
+		if err := d.RemoveImage(ctx, img.ID); err != nil {
+			log.Info().Msgf("Error removing image %s: %v\n", img.ID, err)
+			continue
+		}
+		log.Info().Msgf("Removed image %s %v, last used %s", img.ID, img.Tags, img.LastUsed.Format(time.RFC3339))
+		removed = append(removed, img)
+		freed += img.Size
+
+		w.imagesMu.Lock()
+		delete(w.imageUsed, img.ID)
+		w.imagesMu.Unlock()
+	}
+
+	if !all && freed < toFree {
+		log.Info().Msgf("Freed %d of the %d bytes needed, the other images are in use or too recent", freed, toFree)
+	}
+
+	// Refresh the list reported to the manager.
+	if _, err := w.Images(); err != nil {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// ManageImages periodically refreshes the list of images the worker
+// reports to the manager and collects unused ones.
+func (w *Worker) ManageImages() {
+	for {
+		if _, err := w.Images(); err != nil {
+			log.Info().Msgf("Error listing images: %v\n", err)
+		} else if _, err := w.CollectImages(false); err != nil {
+			log.Info().Msgf("Error collecting images: %v\n", err)
+		}
+		time.Sleep(w.ImageGC.Interval)
+	}
+}
+
+// markImageUsed records that the image with the given ID, pulled as ref,
+// was just used.
+func (w *Worker) markImageUsed(id, ref string) {
+	w.imagesMu.Lock()
+	defer w.imagesMu.Unlock()
+
+	w.imageUsed[id] = time.Now().UTC()
+
+	ref = image.Normalize(ref)
+	for _, r := range w.imageRefs {
+		if r == ref {
+			return
+		}
+	}
+	w.imageRefs = append(w.imageRefs, ref)
+}
+
+// cachedImages returns the references of the images the worker holds, as
+// last listed.
+func (w *Worker) cachedImages() []string {
+	w.imagesMu.Lock()
+	defer w.imagesMu.Unlock()
+
+	return append([]string{}, w.imageRefs...)
+}
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"github.com/docker/docker/api/types/registry"
+	"github.com/hugoleodev/pentagon/internal/docker"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+// applyRegistryAuth sets the credentials the image of a container is
+// pulled with: the task's own, or else the worker's for the image's
+// registry.
+func (w *Worker) applyRegistryAuth(creds *task.RegistryCredentials, config *docker.Config) error {
+	host := docker.RegistryHost(config.Image)
+	auth, ok := w.Registries[host]
+	if creds != nil {
+		auth = registry.AuthConfig{Username: creds.Username, Password: creds.Password, ServerAddress: host}
+	} else if !ok {
+		return nil
+	}
+
+	encoded, err := docker.EncodeAuth(auth)
+	if err != nil {
+		return err
+	}
+	config.RegistryAuth = encoded
+	return nil
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/internal/docker"
+	"github.com/hugoleodev/pentagon/task"
+	"github.com/rs/zerolog/log"
+)
+
+// secretsPath is the directory the secret files of a task are written to.
+func (w *Worker) secretsPath(id uuid.UUID) string {
+	return filepath.Join(w.SecretsDir, id.String())
+}
+
+// applySecrets adds the secret values of a task to its container config.
+// Environment variables are appended to a copy of the task's, so they are
+// never recorded with the task. Files are written under the secrets
+// directory and bind mounted read-only at their paths; the directory is
+// private to the worker, while the files are readable by any user of the
+// container.
+func (w *Worker) applySecrets(t *task.Task, secrets []task.SecretValue, config *docker.Config) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	env := append([]string{}, config.Env...)
+	dir := w.secretsPath(t.ID)
+	for i, s := range secrets {
+		if s.Env != "" {
+			env = append(env, s.Env+"="+s.Value)
+			continue
+		}
+
+		m, err := bindFile(dir, 0o700, i, s.Path, s.Value)
+		if err != nil {
+			return err
+		}
+		config.Mounts = append(config.Mounts, m)
+	}
+	config.Env = env
+
+	return nil
+}
+
+// bindFile writes the content of a file mounted at target as the index-th
+// file of dir, creating dir with perm, and returns its read-only bind
+// mount.
+func bindFile(dir string, perm os.FileMode, index int, target, content string) (mount.Mount, error) {
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return mount.Mount{}, err
+	}
+	file := filepath.Join(dir, strconv.Itoa(index))
+	if err := os.WriteFile(file, []byte(content), 0o444); err != nil {
+		return mount.Mount{}, err
+	}
+	return mount.Mount{
+		Type:     mount.TypeBind,
+		Source:   file,
+		Target:   target,
+		ReadOnly: true,
+	}, nil
+}
+
+// removeSecrets deletes the secret files of a task once its container is
+// gone.
+func (w *Worker) removeSecrets(id uuid.UUID) {
+	if err := os.RemoveAll(w.secretsPath(id)); err != nil {
+		log.Info().Msgf("Error removing secrets of task %s: %v\n", id, err)
+	}
+}
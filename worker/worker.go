@@ -2,30 +2,104 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/docker/docker/api/types/registry"
 	"github.com/golang-collections/collections/queue"
 	"github.com/google/uuid"
 	"github.com/hugoleodev/pentagon/internal/docker"
+	"github.com/hugoleodev/pentagon/node"
+	"github.com/hugoleodev/pentagon/scheduler"
 	"github.com/hugoleodev/pentagon/task"
 )
 
+const (
+	DefaultRunInterval     = 10 * time.Second
+	DefaultStatsInterval   = 15 * time.Second
+	DefaultInspectInterval = 15 * time.Second
+
+	// DefaultSecretsDir is on the tmpfs most Linux hosts mount at
+	// /dev/shm, so secret files never reach a disk.
+	DefaultSecretsDir = "/dev/shm/pentagon-secrets"
+	DefaultConfigsDir = "/tmp/pentagon-configs"
+
+	DefaultPullPolicy = task.PullIfNotPresent
+)
+
 type Worker struct {
-	Name      string
-	Queue     queue.Queue
-	Db        map[uuid.UUID]*task.Task
-	TaskCount int
-	Stats     *Stats
+	Name string
+	// Labels describe the node the worker runs on, such as its zone or
+	// disk type, and are matched by task placement rules.
+	Labels map[string]string
+	// Taints keep tasks that do not tolerate them off the worker.
+	Taints        []node.Taint
+	Queue         queue.Queue
+	Db            map[uuid.UUID]*task.Task
+	TaskCount     int
+	Stats         *Stats
+	RunInterval   time.Duration
+	StatsInterval time.Duration
+	// InspectInterval is how often running containers are inspected to
+	// detect tasks that finished on their own.
+	InspectInterval time.Duration
+	// SecretsDir is where the secret files of tasks are written to be
+	// mounted in their containers.
+	SecretsDir string
+	// ConfigsDir is where the config files of tasks are written to be
+	// mounted in their containers.
+	ConfigsDir string
+	// PullPolicy applies to the images of tasks that do not set one.
+	PullPolicy task.PullPolicy
+	// Registries holds the worker's credentials for private registries,
+	// keyed by registry host. A task's own credentials take precedence.
+	Registries map[string]registry.AuthConfig
+	// ImageGC is the policy unused images are collected by.
+	ImageGC ImageGC
+	// DNS and DNSSearch, if set, are the name servers and search domains
+	// of the containers, such as those of the manager's DNS server.
+	DNS       []string
+	DNSSearch []string
+
+	// mu guards Db, which is shared by the API and the worker's loops, and
+	// stopping, the tasks whose containers are being stopped.
+	mu       sync.RWMutex
+	stopping map[uuid.UUID]bool
+
+	// imagesMu guards imageUsed, when each image was last used by ID, and
+	// imageRefs, the references of the images the worker holds.
+	imagesMu  sync.Mutex
+	imageUsed map[string]time.Time
+	imageRefs []string
+	started   time.Time
 }
 
 func New(name string) *Worker {
 	return &Worker{
-		Name:  name,
-		Queue: *queue.New(),
-		Db:    make(map[uuid.UUID]*task.Task),
+		Name:          name,
+		Queue:         *queue.New(),
+		Db:            make(map[uuid.UUID]*task.Task),
+		stopping:      make(map[uuid.UUID]bool),
+		RunInterval:   DefaultRunInterval,
+		StatsInterval: DefaultStatsInterval,
+
+		InspectInterval: DefaultInspectInterval,
+		SecretsDir:      DefaultSecretsDir,
+		ConfigsDir:      DefaultConfigsDir,
+		PullPolicy:      DefaultPullPolicy,
+		ImageGC: ImageGC{
+			HighPercent: DefaultImageGCHighPercent,
+			LowPercent:  DefaultImageGCLowPercent,
+			MinAge:      DefaultImageGCMinAge,
+			Interval:    DefaultImageGCInterval,
+		},
+		imageUsed: make(map[string]time.Time),
+		started:   time.Now().UTC(),
 	}
 }
 
@@ -38,11 +112,69 @@ func (w *Worker) CollectStats() {
 		log.Info().Msg("Collecting stats")
 		w.Stats = GetStats()
 		w.Stats.TaskCount = w.TaskCount
-		time.Sleep(15 * time.Second)
+		time.Sleep(w.StatsInterval)
+	}
+}
+
+// Node describes the worker to the manager.
+func (w *Worker) Node() *node.Node {
+	n := node.New(w.Name, "", "worker")
+	n.Labels = w.Labels
+	n.Taints = w.Taints
+	n.Images = w.cachedImages()
+	n.Cores = runtime.NumCPU()
+	if s := w.Stats; s != nil {
+		n.Memory = int(s.Memory.MemTotal)
+		n.Disk = int(s.DiskTotal())
 	}
+	return n
+}
+
+// ErrTaskActive is returned by Admit for a task the worker already holds
+// as scheduled or running, such as one the manager sent again after its
+// first start request timed out.
+var ErrTaskActive = errors.New("task is already scheduled or running on this worker")
+
+// Admit checks that the node has room for the task next to the tasks
+// already placed on it, and reserves that room by recording the task as
+// scheduled. The error is a *scheduler.Unschedulable when it has not, and
+// ErrTaskActive when the task is already there.
+func (w *Worker) Admit(t task.Task) error {
+	n := w.Node()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if current, ok := w.Db[t.ID]; ok && (current.State == task.Scheduled || current.State == task.Running) {
+		return ErrTaskActive
+	}
+
+	for _, other := range w.Db {
+		if other.State != task.Scheduled && other.State != task.Running {
+			continue
+		}
+		n.CpuAllocated += other.Cpu
+		n.MemoryAllocated += int(other.Memory)
+		n.DiskAllocated += int(other.Disk)
+	}
+
+	if reason := scheduler.Shortage(t, n); reason != "" {
+		return &scheduler.Unschedulable{
+			Reason: reason,
+			Message: fmt.Sprintf("task requests cpu %g, memory %d, disk %d with cpu %g/%d, memory %d/%d, disk %d/%d allocated",
+				t.Cpu, t.Memory, t.Disk, n.CpuAllocated, n.Cores, n.MemoryAllocated, n.Memory, n.DiskAllocated, n.Disk),
+		}
+	}
+
+	t.State = task.Scheduled
+	w.Db[t.ID] = &t
+	return nil
 }
 
 func (w *Worker) GetTasks() []*task.Task {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
 	tasks := []*task.Task{}
 
 	for _, t := range w.Db {
@@ -52,6 +184,21 @@ func (w *Worker) GetTasks() []*task.Task {
 	return tasks
 }
 
+func (w *Worker) GetTask(id uuid.UUID) (*task.Task, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	t, ok := w.Db[id]
+	return t, ok
+}
+
+func (w *Worker) putTask(t *task.Task) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.Db[t.ID] = t
+}
+
 // RunTask runs a task from the worker's queue and
 // returns the result of the task execution.
 func (w *Worker) runTask() docker.DockerResult {
@@ -63,18 +210,19 @@ func (w *Worker) runTask() docker.DockerResult {
 
 	taskQueued := t.(task.Task)
 
-	taskPersisted := w.Db[taskQueued.ID]
+	taskPersisted, ok := w.GetTask(taskQueued.ID)
 
-	if taskPersisted == nil {
+	if !ok {
 		taskPersisted = &taskQueued
-		w.Db[taskQueued.ID] = &taskQueued
+		w.putTask(&taskQueued)
 	}
 
 	var result docker.DockerResult
 	if task.ValidStateTransition(taskPersisted.State, taskQueued.State) {
 		switch taskQueued.State {
 		case task.Scheduled:
-			result = w.StartTask(taskPersisted)
+			te := task.TaskEvent{Task: *taskPersisted}
+			result = w.StartTask(&te)
 		case task.Completed:
 			result = w.StopTask(taskPersisted)
 		default:
@@ -88,23 +236,54 @@ func (w *Worker) runTask() docker.DockerResult {
 	return result
 }
 
-func (w *Worker) StartTask(t *task.Task) docker.DockerResult {
+// StartTask runs the container of the task of an event, with the values
+// of its secret references, the files of its configs and its registry
+// credentials as resolved by the manager.
+func (w *Worker) StartTask(te *task.TaskEvent) docker.DockerResult {
 	ctx := context.Background()
+	t := &te.Task
 	t.StartTime = time.Now().UTC()
 	config := docker.NewConfig(t)
 	d := docker.New(config)
-	result := d.Run(ctx)
+	if d.Config.PullPolicy == "" {
+		d.Config.PullPolicy = w.PullPolicy
+	}
+	d.Config.DNS = w.DNS
+	d.Config.DNSSearch = w.DNSSearch
+
+	var result docker.DockerResult
+	if err := w.applySecrets(t, te.Secrets, &d.Config); err != nil {
+		result.Error = fmt.Errorf("unable to materialise secrets: %w", err)
+	} else if err := w.applyConfigs(t, te.Configs, &d.Config); err != nil {
+		result.Error = fmt.Errorf("unable to materialise configs: %w", err)
+	} else if err := w.applyRegistryAuth(te.RegistryAuth, &d.Config); err != nil {
+		result.Error = fmt.Errorf("unable to encode registry credentials: %w", err)
+	} else {
+		result = d.Run(ctx)
+	}
 
 	if result.Error != nil {
 		log.Info().Msgf("Error running task %s: %v\n", t.ID, result.Error)
+		w.removeFiles(t.ID)
 		t.State = task.Failed
-		w.Db[t.ID] = t
+		t.Error = result.Error.Error()
+		t.FinishTime = time.Now().UTC()
+		w.putTask(t)
 		return result
 	}
 
 	t.ContainerID = result.ContainerId
 	t.State = task.Running
-	w.Db[t.ID] = t
+
+	if resp, err := d.Inspect(ctx, t.ContainerID); err == nil {
+		if resp.NetworkSettings != nil {
+			t.HostPorts = resp.NetworkSettings.NetworkSettingsBase.Ports
+		}
+		if resp.ContainerJSONBase != nil {
+			w.markImageUsed(resp.Image, t.Image)
+		}
+	}
+	w.putTask(t)
 
 	log.Info().Msgf("Started container %s with ID %v for task %v", config.Name, t.ContainerID, t.ID)
 
@@ -122,15 +301,27 @@ func (w *Worker) RunTasks() {
 		} else {
 			log.Info().Msg("No tasks to process currently.\n")
 		}
-		log.Info().Msg("Sleeping for 10 seconds.")
-		time.Sleep(10 * time.Second)
+		log.Info().Msgf("Sleeping for %v.", w.RunInterval)
+		time.Sleep(w.RunInterval)
+	}
+
+}
+
+func (w *Worker) GetTaskLogs(t *task.Task, tail int) (string, error) {
+	if t.ContainerID == "" {
+		return "", fmt.Errorf("task %v has no container", t.ID)
 	}
 
+	d := docker.New(docker.NewConfig(t))
+	return d.Logs(context.Background(), t.ContainerID, tail)
 }
 
 func (w *Worker) StopTask(t *task.Task) docker.DockerResult {
 	ctx := context.Background()
 
+	w.setStopping(t.ID, true)
+	defer w.setStopping(t.ID, false)
+
 	config := docker.NewConfig(t)
 	d := docker.New(config)
 
@@ -139,12 +330,109 @@ func (w *Worker) StopTask(t *task.Task) docker.DockerResult {
 	if result.Error != nil {
 		log.Info().Msgf("Error stopping container %s with ID %s: %v\n", config.Name, t.ContainerID, result.Error)
 	}
+	w.removeFiles(t.ID)
 
-	t.FinishTime = time.Now().UTC()
-	t.State = task.Completed
-	w.Db[t.ID] = t
+	stopped := *t
+	stopped.FinishTime = time.Now().UTC()
+	stopped.State = task.Completed
+	stopped.ExitCode = result.ExitCode
+	w.putTask(&stopped)
 	log.Info().Msgf("Stopped and removed container %s with ID %v for task %v", config.Name, t.ContainerID, t.ID)
 
 	return result
 
 }
+
+func (w *Worker) setStopping(id uuid.UUID, stopping bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if stopping {
+		w.stopping[id] = true
+	} else {
+		delete(w.stopping, id)
+	}
+}
+
+// InspectTasks periodically checks the containers of running tasks so that
+// tasks whose containers exit on their own are completed or failed.
+func (w *Worker) InspectTasks() {
+	for {
+		log.Info().Msg("Checking status of tasks")
+		w.updateTasks()
+		log.Info().Msgf("Task updates completed, sleeping for %v", w.InspectInterval)
+		time.Sleep(w.InspectInterval)
+	}
+}
+
+func (w *Worker) updateTasks() {
+	for _, t := range w.GetTasks() {
+		if t.State != task.Running || t.ContainerID == "" {
+			continue
+		}
+
+		d := docker.New(docker.NewConfig(t))
+		resp, err := d.Inspect(context.Background(), t.ContainerID)
+
+		updated := *t
+		switch {
+		case docker.IsNotFound(err):
+			log.Info().Msgf("Container %s for task %s no longer exists", t.ContainerID, t.ID)
+			updated.State = task.Failed
+			updated.Error = "container not found"
+			updated.FinishTime = time.Now().UTC()
+		case err != nil:
+			log.Info().Msgf("Error inspecting container for task %s: %v\n", t.ID, err)
+			continue
+		case resp.State == nil:
+			continue
+		case resp.State.Status == "exited" || resp.State.Status == "dead":
+			updated.Exited = true
+			updated.ExitCode = resp.State.ExitCode
+			updated.OOMKilled = resp.State.OOMKilled
+			updated.Error = resp.State.Error
+			updated.FinishTime = parseDockerTime(resp.State.FinishedAt)
+
+			if updated.ExitCode == 0 && !updated.OOMKilled {
+				updated.State = task.Completed
+			} else {
+				updated.State = task.Failed
+			}
+			log.Info().Msgf("Task %s finished with exit code %d (oom killed: %t)", t.ID, updated.ExitCode, updated.OOMKilled)
+		default:
+			if resp.NetworkSettings != nil {
+				updated.HostPorts = resp.NetworkSettings.NetworkSettingsBase.Ports
+			}
+		}
+
+		if !w.updateTask(&updated) {
+			log.Info().Msgf("Task %s changed while its container was inspected, leaving it", t.ID)
+			continue
+		}
+		if updated.State != task.Running {
+			w.removeFiles(t.ID)
+		}
+	}
+}
+
+// updateTask stores the result of inspecting a running task, unless the
+// task changed since it was read or its container is being stopped.
+func (w *Worker) updateTask(updated *task.Task) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current, ok := w.Db[updated.ID]
+	if !ok || w.stopping[updated.ID] || current.State != task.Running || current.ContainerID != updated.ContainerID {
+		return false
+	}
+	w.Db[updated.ID] = updated
+	return true
+}
+
+func parseDockerTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.IsZero() || t.Year() < 2 {
+		return time.Now().UTC()
+	}
+	return t.UTC()
+}
@@ -0,0 +1,175 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugoleodev/pentagon/namespace"
+	"github.com/hugoleodev/pentagon/task"
+)
+
+const (
+	// LabelName is set on every task created for a workflow.
+	LabelName = "pentagon.io/workflow"
+	// LabelID holds the ID of the workflow a task was created for.
+	LabelID = "pentagon.io/workflow-id"
+	// LabelStep names the workflow step a task runs.
+	LabelStep = "pentagon.io/workflow-step"
+)
+
+// Workflow and step states.
+const (
+	Waiting   = "waiting"
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	Skipped   = "skipped"
+)
+
+// Workflow is a set of steps forming a directed acyclic graph. A step
+// starts once every step it depends on has succeeded, and is skipped if
+// any of them failed or was skipped.
+type Workflow struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace,omitempty"`
+	Steps     []Step    `json:"steps"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Step struct {
+	Name      string    `json:"name"`
+	Template  task.Task `json:"template"`
+	DependsOn []string  `json:"depends_on,omitempty"`
+}
+
+type Status struct {
+	State       string                `json:"state"`
+	Steps       map[string]StepStatus `json:"steps"`
+	Message     string                `json:"message,omitempty"`
+	StartedAt   time.Time             `json:"started_at"`
+	CompletedAt time.Time             `json:"completed_at,omitempty"`
+}
+
+type StepStatus struct {
+	State       string    `json:"state"`
+	TaskID      uuid.UUID `json:"task_id,omitempty"`
+	ExitCode    int       `json:"exit_code"`
+	Message     string    `json:"message,omitempty"`
+	StartedAt   time.Time `json:"started_at,omitempty"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+}
+
+func (w *Workflow) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("workflow name is required")
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow %s: at least one step is required", w.Name)
+	}
+
+	steps := make(map[string]*Step, len(w.Steps))
+	for i := range w.Steps {
+		s := &w.Steps[i]
+		if s.Name == "" {
+			return fmt.Errorf("workflow %s: step %d has no name", w.Name, i)
+		}
+		if _, ok := steps[s.Name]; ok {
+			return fmt.Errorf("workflow %s: duplicate step %s", w.Name, s.Name)
+		}
+		if s.Template.Image == "" {
+			return fmt.Errorf("workflow %s: step %s: template image is required", w.Name, s.Name)
+		}
+		if err := s.Template.ValidateScheduling(); err != nil {
+			return fmt.Errorf("workflow %s: step %s: %w", w.Name, s.Name, err)
+		}
+		switch s.Template.RestartPolicy {
+		case "", "no", "on-failure":
+		default:
+			return fmt.Errorf("workflow %s: step %s: restart policy %q would prevent tasks from completing", w.Name, s.Name, s.Template.RestartPolicy)
+		}
+		steps[s.Name] = s
+	}
+
+	for _, s := range w.Steps {
+		for _, dep := range s.DependsOn {
+			if _, ok := steps[dep]; !ok {
+				return fmt.Errorf("workflow %s: step %s depends on unknown step %s", w.Name, s.Name, dep)
+			}
+		}
+	}
+
+	// Depth-first search for cycles: a step still on the stack when it is
+	// reached again closes a cycle.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(w.Steps))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("workflow %s: dependency cycle through step %s", w.Name, name)
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		for _, dep := range steps[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+	for _, s := range w.Steps {
+		if err := visit(s.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Workflow) Finished() bool {
+	return w.Status.State == Succeeded || w.Status.State == Failed
+}
+
+// NewTask returns a fresh task built from the template of a step.
+func (w *Workflow) NewTask(s Step) *task.Task {
+	t := s.Template
+	t.ID = uuid.New()
+	t.State = task.Pending
+	t.ContainerID = ""
+	t.StartTime = time.Time{}
+	t.FinishTime = time.Time{}
+
+	if t.Name == "" {
+		t.Name = w.Name + "-" + s.Name
+	}
+
+	t.Namespace = w.Namespace
+
+	t.Labels = make(map[string]string, len(s.Template.Labels)+3)
+	for k, v := range s.Template.Labels {
+		t.Labels[k] = v
+	}
+	t.Labels[LabelName] = w.Name
+	t.Labels[LabelID] = w.ID.String()
+	t.Labels[LabelStep] = s.Name
+
+	return &t
+}
+
+func (w *Workflow) Owns(t *task.Task) bool {
+	return t.Labels[LabelID] == w.ID.String() && namespace.OrDefault(t.Namespace) == namespace.OrDefault(w.Namespace)
+}
+
+// StepFinished reports whether a step state is final.
+func StepFinished(state string) bool {
+	return state == Succeeded || state == Failed || state == Skipped
+}